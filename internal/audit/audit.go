@@ -0,0 +1,216 @@
+// Package audit records who changed what. Entries are written in the same
+// transaction as the change they describe and keep only the fields that
+// differ between the before and after state.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/logging"
+)
+
+// Entities.
+const (
+	EntityMatch = "match"
+	EntityUser  = "user"
+)
+
+type ctxKey struct{}
+
+// WithActor returns a copy of ctx attributing changes to userID.
+func WithActor(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, userID)
+}
+
+// Actor returns the user that changes made with ctx are attributed to.
+func Actor(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(ctxKey{}).(int64)
+	return id, ok
+}
+
+// Execer is satisfied by *sql.DB, *sql.Tx and *dbpkg.Queries.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// now is replaced in tests.
+var now = time.Now
+
+type Entry struct {
+	ID        int64           `json:"id"`
+	At        time.Time       `json:"at"`
+	UserID    *int64          `json:"user_id,omitempty"`
+	UserEmail string          `json:"user_email,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  *int64          `json:"entity_id,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// Record writes an entry for action on entity/entityID (0 for none), made by
+// the actor in ctx. before and after are marshalled to JSON; when both are
+// objects only the fields that differ are kept.
+func Record(ctx context.Context, db Execer, action, entity string, entityID int64, before, after any) error {
+	b, a, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	var user, id, requestID any
+	if uid, ok := Actor(ctx); ok {
+		user = uid
+	}
+	if entityID != 0 {
+		id = entityID
+	}
+	for _, attr := range logging.Attrs(ctx) {
+		if attr.Key == "request_id" {
+			requestID = attr.Value.String()
+		}
+	}
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO audit_log (at_ms, user_id, action, entity, entity_id, before, after, request_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		now().UnixMilli(), user, action, entity, id, nullJSON(b), nullJSON(a), requestID)
+	return err
+}
+
+func nullJSON(b json.RawMessage) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// Diff marshals before and after. When both are JSON objects, fields with
+// equal values are dropped from both, so an update stores just what changed.
+// A nil side stays empty.
+func Diff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	b, err := marshal(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := marshal(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	var bm, am map[string]json.RawMessage
+	if json.Unmarshal(b, &bm) != nil || json.Unmarshal(a, &am) != nil || bm == nil || am == nil {
+		return b, a, nil
+	}
+	for k, v := range bm {
+		if w, ok := am[k]; ok && bytes.Equal(v, w) {
+			delete(bm, k)
+			delete(am, k)
+		}
+	}
+	if b, err = json.Marshal(bm); err != nil {
+		return nil, nil, err
+	}
+	a, err = json.Marshal(am)
+	return b, a, err
+}
+
+func marshal(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// Filter narrows List; empty fields match everything.
+type Filter struct {
+	UserID   int64
+	Action   string // exact, or a prefix ending in "." such as "match."
+	Entity   string
+	EntityID int64
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64 // for paging: only entries older than this id
+	Limit    int
+}
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service { return &Service{db: db} }
+
+// List returns entries, newest first.
+func (s *Service) List(ctx context.Context, f Filter) ([]Entry, error) {
+	var where []string
+	var args []any
+	if f.UserID != 0 {
+		where = append(where, "a.user_id = ?")
+		args = append(args, f.UserID)
+	}
+	if strings.HasSuffix(f.Action, ".") {
+		where = append(where, "a.action LIKE ? ESCAPE '\\'")
+		args = append(args, strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(f.Action)+"%")
+	} else if f.Action != "" {
+		where = append(where, "a.action = ?")
+		args = append(args, f.Action)
+	}
+	if f.Entity != "" {
+		where = append(where, "a.entity = ?")
+		args = append(args, f.Entity)
+	}
+	if f.EntityID != 0 {
+		where = append(where, "a.entity_id = ?")
+		args = append(args, f.EntityID)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "a.at_ms >= ?")
+		args = append(args, f.Since.UnixMilli())
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "a.at_ms < ?")
+		args = append(args, f.Until.UnixMilli())
+	}
+	if f.BeforeID != 0 {
+		where = append(where, "a.id < ?")
+		args = append(args, f.BeforeID)
+	}
+	query := `SELECT a.id, a.at_ms, a.user_id, u.email, a.action, a.entity, a.entity_id, a.before, a.after, a.request_id
+		FROM audit_log a LEFT JOIN users u ON u.id = a.user_id`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	query += ` ORDER BY a.id DESC LIMIT ?`
+	args = append(args, f.Limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Entry{}
+	for rows.Next() {
+		var (
+			e                           Entry
+			at                          int64
+			email, before, after, reqID sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &at, &e.UserID, &email, &e.Action, &e.Entity, &e.EntityID, &before, &after, &reqID); err != nil {
+			return nil, err
+		}
+		e.At = time.UnixMilli(at).UTC()
+		e.UserEmail = email.String
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+		e.RequestID = reqID.String
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
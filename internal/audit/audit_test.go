@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "modernc.org/sqlite"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/logging"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO users (id, email, password_hash) VALUES (7, 'a@example.com', 'x')`); err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	return NewService(db)
+}
+
+func TestDiff_KeepsChangedFields(t *testing.T) {
+	b, a, err := Diff(map[string]any{"goals_for": 1, "team": "H43", "notes": nil}, map[string]any{"goals_for": 2, "team": "H43", "notes": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"goals_for":1,"notes":null}` || string(a) != `{"goals_for":2,"notes":"x"}` {
+		t.Fatalf("unexpected diff %s -> %s", b, a)
+	}
+
+	// A create keeps the whole after state
+	b, a, _ = Diff(nil, map[string]int{"id": 1})
+	if b != nil || string(a) != `{"id":1}` {
+		t.Fatalf("unexpected create diff %s -> %s", b, a)
+	}
+}
+
+func TestRecordAndList(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+	at := time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+
+	admin := logging.With(WithActor(ctx, 7), "request_id", "req-1")
+	Record(admin, s.db, "match.update", EntityMatch, 1, map[string]int{"goals_for": 1}, map[string]int{"goals_for": 2})
+	at = at.Add(time.Hour)
+	Record(admin, s.db, "user.set_admin", EntityUser, 3, map[string]bool{"is_admin": false}, map[string]bool{"is_admin": true})
+	Record(ctx, s.db, "matches.delete_all", EntityMatch, 0, []int{1}, nil)
+
+	all, err := s.List(ctx, Filter{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("list: %v %+v", err, all)
+	}
+	if all[2].UserEmail != "a@example.com" || all[2].RequestID != "req-1" || *all[2].EntityID != 1 ||
+		string(all[2].After) != `{"goals_for":2}` || !all[2].At.Equal(time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected entry %+v", all[2])
+	}
+	if all[0].UserID != nil || all[0].EntityID != nil || all[0].After != nil {
+		t.Fatalf("expected a system entry without user or id: %+v", all[0])
+	}
+
+	for name, tc := range map[string]struct {
+		f    Filter
+		want int
+	}{
+		"user":      {Filter{UserID: 7}, 2},
+		"prefix":    {Filter{Action: "match."}, 1},
+		"exact":     {Filter{Action: "matches.delete_all"}, 1},
+		"entity":    {Filter{Entity: EntityMatch, EntityID: 1}, 1},
+		"since":     {Filter{Since: at}, 2},
+		"until":     {Filter{Until: at}, 1},
+		"before_id": {Filter{BeforeID: all[0].ID, Limit: 1}, 1},
+	} {
+		got, err := s.List(ctx, tc.f)
+		if err != nil || len(got) != tc.want {
+			t.Errorf("%s: expected %d entries, got %d (%v)", name, tc.want, len(got), err)
+		}
+	}
+}
+
+func TestAdminRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newTestService(t)
+	Record(WithActor(context.Background(), 7), s.db, "user.delete", EntityUser, 9, map[string]string{"email": "b@example.com"}, nil)
+	r := gin.New()
+	RegisterAdminRoutes(r, s, func(c *gin.Context) { c.Next() })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/audit?user_id=7&entity=user", nil))
+	var list []Entry
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list) != 1 || list[0].Action != "user.delete" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	for _, q := range []string{"user_id=x", "since=yesterday"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/audit?"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes mounts GET /api/admin/audit behind the given admin middleware.
+func RegisterAdminRoutes(r *gin.Engine, s *Service, admin gin.HandlerFunc) {
+	r.GET("/api/admin/audit", admin, func(c *gin.Context) {
+		f := Filter{Action: c.Query("action"), Entity: c.Query("entity")}
+		f.Limit, _ = strconv.Atoi(c.Query("limit"))
+		for k, p := range map[string]*int64{"user_id": &f.UserID, "entity_id": &f.EntityID, "before_id": &f.BeforeID} {
+			if v := c.Query(k); v != "" {
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + k})
+					return
+				}
+				*p = n
+			}
+		}
+		for k, p := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+			if v := c.Query(k); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + k + " (RFC 3339)"})
+					return
+				}
+				*p = t
+			}
+		}
+
+		list, err := s.List(c.Request.Context(), f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppPassword lets a client that cannot use the login form, such as a
+// calendar app, sign in with the user's email and a generated secret. Each
+// can be revoked on its own; CanWrite allows changes, not only reads.
+type AppPassword struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	CanWrite   bool       `json:"can_write"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+const appPasswordKey = "auth.app_password"
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAppPassword adds an app password and returns it with the secret,
+// which is not stored and cannot be shown again.
+func (r *Repository) CreateAppPassword(ctx context.Context, userID int64, name string, canWrite bool) (AppPassword, string, error) {
+	secret, err := NewToken()
+	if err != nil {
+		return AppPassword{}, "", err
+	}
+	p := AppPassword{Name: name, CanWrite: canWrite, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO app_passwords (user_id, name, secret_hash, can_write, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		userID, name, hashSecret(secret), canWrite, p.CreatedAt,
+	).Scan(&p.ID)
+	if err != nil {
+		return AppPassword{}, "", err
+	}
+	return p, secret, nil
+}
+
+// ListAppPasswords returns the app passwords of a user, oldest first.
+func (r *Repository) ListAppPasswords(ctx context.Context, userID int64) ([]AppPassword, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, can_write, created_at, last_used_at FROM app_passwords WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AppPassword{}
+	for rows.Next() {
+		var p AppPassword
+		if err := rows.Scan(&p.ID, &p.Name, &p.CanWrite, &p.CreatedAt, &p.LastUsedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// DeleteAppPassword revokes one of a user's app passwords.
+func (r *Repository) DeleteAppPassword(ctx context.Context, userID, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM app_passwords WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetUserByAppPassword resolves the user an email and app password belong
+// to, and records the use.
+func (r *Repository) GetUserByAppPassword(ctx context.Context, email, secret string) (User, AppPassword, error) {
+	var u User
+	var p AppPassword
+	err := r.db.QueryRowContext(ctx, `
+        SELECT u.id, u.email, u.password_hash, u.created_at, COALESCE(u.is_admin,0),
+               p.id, p.name, p.can_write, p.created_at, p.last_used_at
+        FROM app_passwords p
+        JOIN users u ON u.id = p.user_id
+        WHERE p.secret_hash = ? AND u.email = ?
+    `, hashSecret(secret), strings.TrimSpace(strings.ToLower(email))).Scan(
+		&u.ID, &u.Email, &u.PasswordHash, &u.CreatedAt, &u.IsAdmin,
+		&p.ID, &p.Name, &p.CanWrite, &p.CreatedAt, &p.LastUsedAt)
+	if err != nil {
+		return User{}, AppPassword{}, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if _, err := r.db.ExecContext(ctx, `UPDATE app_passwords SET last_used_at = ? WHERE id = ?`, now, p.ID); err != nil {
+		return User{}, AppPassword{}, err
+	}
+	p.LastUsedAt = &now
+	return u, p, nil
+}
+
+// AppPasswordRequired authenticates with HTTP Basic auth, the user's email
+// and an app password, and asks for credentials in realm otherwise.
+func AppPasswordRequired(repo *Repository, realm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, secret, ok := c.Request.BasicAuth()
+		if ok {
+			u, p, err := repo.GetUserByAppPassword(c.Request.Context(), email, secret)
+			if err == nil {
+				withUser(c, u)
+				c.Set(appPasswordKey, p)
+				c.Next()
+				return
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "auth failed"})
+				return
+			}
+		}
+		c.Header("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	}
+}
+
+// CurrentAppPassword returns the app password a request was authenticated
+// with by AppPasswordRequired.
+func CurrentAppPassword(c *gin.Context) (AppPassword, bool) {
+	v, ok := c.Get(appPasswordKey)
+	if !ok {
+		return AppPassword{}, false
+	}
+	p, ok := v.(AppPassword)
+	return p, ok
+}
+
+func registerAppPasswordRoutes(api *gin.RouterGroup, repo *Repository) {
+	api.GET("/me/app-passwords", func(c *gin.Context) {
+		u, ok := CurrentUser(c, repo)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		list, err := repo.ListAppPasswords(c.Request.Context(), u.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	// The secret is in this response only
+	api.POST("/me/app-passwords", func(c *gin.Context) {
+		u, ok := CurrentUser(c, repo)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var req struct {
+			Name     string `json:"name"`
+			CanWrite bool   `json:"can_write"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1 to 100 characters"})
+			return
+		}
+		p, secret, err := repo.CreateAppPassword(c.Request.Context(), u.ID, req.Name, req.CanWrite)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"app_password": p, "username": u.Email, "password": secret})
+	})
+
+	api.DELETE("/me/app-passwords/:id", func(c *gin.Context) {
+		u, ok := CurrentUser(c, repo)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err := repo.DeleteAppPassword(c.Request.Context(), u.ID, id); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAppPasswords(t *testing.T) {
+	t.Setenv("COOKIE_SECURE", "false")
+	db := newTestDB(t)
+	r := newRouterWithAuth(t, db)
+	_ = doJSON(r, http.MethodPost, "/api/auth/register", map[string]any{"email": "coach@example.com", "password": "123456789012", "password_confirm": "123456789012"})
+	ck := loginAndGetCookie(t, r, "coach@example.com", "123456789012")
+
+	repo := NewRepository(db)
+	r.GET("/dav", AppPasswordRequired(repo, "X-Matches"), func(c *gin.Context) {
+		p, _ := CurrentAppPassword(c)
+		c.JSON(http.StatusOK, p)
+	})
+	dav := func(user, pass string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/dav", nil)
+		req.SetBasicAuth(user, pass)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := doJSONWithCookie(r, http.MethodPost, "/api/auth/me/app-passwords", map[string]any{"name": " "}, ck); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an empty name, got %d", w.Code)
+	}
+	w := doJSONWithCookie(r, http.MethodPost, "/api/auth/me/app-passwords", map[string]any{"name": "iPhone", "can_write": true}, ck)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		AppPassword AppPassword `json:"app_password"`
+		Username    string      `json:"username"`
+		Password    string      `json:"password"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Username != "coach@example.com" || len(created.Password) != 64 || !created.AppPassword.CanWrite {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+
+	if w := dav("coach@example.com", "123456789012"); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("the account password must not work, got %d", w.Code)
+	}
+	if w := dav("other@example.com", created.Password); w.Code != http.StatusUnauthorized {
+		t.Fatalf("the secret belongs to one user, got %d", w.Code)
+	}
+	if w := dav("Coach@Example.com", created.Password); w.Code != http.StatusOK {
+		t.Fatalf("basic auth: %d", w.Code)
+	}
+
+	w = doJSONWithCookie(r, http.MethodGet, "/api/auth/me/app-passwords", nil, ck)
+	var list []AppPassword
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Name != "iPhone" || list[0].LastUsedAt == nil {
+		t.Fatalf("unexpected list: %s", w.Body.String())
+	}
+
+	id := strconv.FormatInt(created.AppPassword.ID, 10)
+	if w := doJSONWithCookie(r, http.MethodDelete, "/api/auth/me/app-passwords/"+id, nil, ck); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := dav("coach@example.com", created.Password); w.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked password still works: %d", w.Code)
+	}
+	if w := doJSONWithCookie(r, http.MethodDelete, "/api/auth/me/app-passwords/"+id, nil, ck); w.Code != http.StatusNotFound {
+		t.Fatalf("second delete: %d", w.Code)
+	}
+}
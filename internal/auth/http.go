@@ -11,6 +11,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xaitan80/X-Matches/internal/audit"
+	"github.com/xaitan80/X-Matches/internal/logging"
+	"github.com/xaitan80/X-Matches/internal/settings"
 )
 
 const CookieName = "session_token"
@@ -33,11 +37,15 @@ func cookieSecure() bool {
 	return true
 }
 
-func RegisterRoutes(r *gin.Engine, db *sql.DB) {
+func RegisterRoutes(r *gin.Engine, db *sql.DB, cfg *settings.Service) {
 	repo := NewRepository(db)
 	api := r.Group("/api/auth")
 
 	api.POST("/register", func(c *gin.Context) {
+		if !cfg.Current(c.Request.Context()).RegistrationOpen {
+			c.JSON(http.StatusForbidden, gin.H{"error": "registration closed"})
+			return
+		}
 		var req struct {
 			Email           string `json:"email"`
 			Password        string `json:"password"`
@@ -114,6 +122,7 @@ func RegisterRoutes(r *gin.Engine, db *sql.DB) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 			return
 		}
+		withUser(c, u)
 
 		s, err := repo.CreateSession(c.Request.Context(), u.ID, ttlFromEnv())
 		if err != nil {
@@ -195,6 +204,8 @@ func RegisterRoutes(r *gin.Engine, db *sql.DB) {
 		_ = repo.ReserveEmail(c.Request.Context(), u.Email, &u.ID)
 		c.JSON(http.StatusOK, gin.H{"ok": true, "email": email})
 	})
+
+	registerAppPasswordRoutes(api, repo)
 }
 
 // CurrentUser resolves user from the session cookie for convenience.
@@ -207,9 +218,17 @@ func CurrentUser(c *gin.Context, repo *Repository) (User, bool) {
 	if err != nil {
 		return User{}, false
 	}
+	withUser(c, u)
 	return u, true
 }
 
+// withUser adds the user id to the request's log fields and attributes the
+// changes the request makes to u in the audit log.
+func withUser(c *gin.Context, u User) {
+	ctx := logging.With(c.Request.Context(), "user_id", u.ID)
+	c.Request = c.Request.WithContext(audit.WithActor(ctx, u.ID))
+}
+
 // AuthRequired middleware example (unused for now)
 func AuthRequired(repo *Repository) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -218,7 +237,8 @@ func AuthRequired(repo *Repository) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			return
 		}
-		if _, err := repo.GetUserBySession(c.Request.Context(), tok); err != nil {
+		u, err := repo.GetUserBySession(c.Request.Context(), tok)
+		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 				return
@@ -226,6 +246,7 @@ func AuthRequired(repo *Repository) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "auth failed"})
 			return
 		}
+		withUser(c, u)
 		c.Next()
 	}
 }
@@ -243,6 +264,7 @@ func AdminRequired(repo *Repository) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			return
 		}
+		withUser(c, u)
 		// Allow admin via DB flag or ADMIN_EMAILS env list
 		if u.IsAdmin || isAdminEmail(u.Email) {
 			c.Next()
@@ -367,6 +389,10 @@ func RegisterAdminRoutes(r *gin.Engine, repo *Repository) {
 			}
 		}
 		if err := repo.SetAdmin(c.Request.Context(), id, req.IsAdmin); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	_ "modernc.org/sqlite"
 
 	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
 )
 
 func newTestDB(t *testing.T) *sql.DB {
@@ -38,7 +40,7 @@ func newRouterWithAuth(t *testing.T, db *sql.DB) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
-	RegisterRoutes(r, db)
+	RegisterRoutes(r, db, nil)
 	// also mount admin routes for admin-related tests
 	RegisterAdminRoutes(r, NewRepository(db))
 	return r
@@ -537,3 +539,20 @@ func TestAdmin_CannotDeleteSelf(t *testing.T) {
 		t.Fatalf("expected 400 when deleting self, got %d", w.Code)
 	}
 }
+
+func TestRegister_ClosedBySettings(t *testing.T) {
+	db := newTestDB(t)
+	cfg := settings.NewService(db)
+	closed := settings.Defaults()
+	closed.RegistrationOpen = false
+	if _, err := cfg.Update(context.Background(), closed); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, db, cfg)
+	w := doJSON(r, http.MethodPost, "/api/auth/register", map[string]any{"email": "user@example.com", "password": "123456789012", "password_confirm": "123456789012"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when registration closed, got %d", w.Code)
+	}
+}
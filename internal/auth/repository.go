@@ -6,7 +6,18 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"errors"
+	"log/slog"
 	"time"
+
+	"github.com/xaitan80/X-Matches/internal/audit"
+)
+
+// Audit actions for users.
+const (
+	AuditResetPassword  = "user.reset_password"
+	AuditChangePassword = "user.change_password"
+	AuditSetAdmin       = "user.set_admin"
+	AuditDeleteUser     = "user.delete"
 )
 
 type Repository struct {
@@ -50,6 +61,7 @@ func (r *Repository) CreateUser(ctx context.Context, email, passwordHash string)
 	if err := tx.Commit(); err != nil {
 		return User{}, err
 	}
+	slog.InfoContext(ctx, "user created", "new_user_id", res.ID, "admin", res.IsAdmin)
 	return res, nil
 }
 
@@ -105,6 +117,7 @@ func (r *Repository) CreateSession(ctx context.Context, userID int64, ttl time.D
 	if err := row.Scan(&s.Token, &s.UserID, &s.ExpiresAt, &s.CreatedAt); err != nil {
 		return Session{}, err
 	}
+	slog.InfoContext(ctx, "session created", "session_user_id", userID, "expires_at", s.ExpiresAt)
 	return s, nil
 }
 
@@ -153,9 +166,29 @@ func (r *Repository) ListUsers(ctx context.Context) ([]User, error) {
 	return out, rows.Err()
 }
 
+// SetPasswordHash replaces a user's password. It is audited as a reset when
+// someone other than the user does it.
 func (r *Repository) SetPasswordHash(ctx context.Context, userID int64, newHash string) error {
-	_, err := r.db.ExecContext(ctx, `UPDATE users SET password_hash = ? WHERE id = ?`, newHash, userID)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = ? WHERE id = ?`, newHash, userID); err != nil {
+		return err
+	}
+	action := AuditResetPassword
+	if actor, ok := audit.Actor(ctx); ok && actor == userID {
+		action = AuditChangePassword
+	}
+	if err := audit.Record(ctx, tx, action, audit.EntityUser, userID, nil, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "password changed", "target_user_id", userID)
+	return nil
 }
 
 func (r *Repository) SetAdmin(ctx context.Context, userID int64, isAdmin bool) error {
@@ -163,8 +196,27 @@ func (r *Repository) SetAdmin(ctx context.Context, userID int64, isAdmin bool) e
 	if isAdmin {
 		val = 1
 	}
-	_, err := r.db.ExecContext(ctx, `UPDATE users SET is_admin = ? WHERE id = ?`, val, userID)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var was bool
+	if err := tx.QueryRowContext(ctx, `SELECT is_admin FROM users WHERE id = ?`, userID).Scan(&was); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET is_admin = ? WHERE id = ?`, val, userID); err != nil {
+		return err
+	}
+	if err := audit.Record(ctx, tx, AuditSetAdmin, audit.EntityUser, userID,
+		map[string]bool{"is_admin": was}, map[string]bool{"is_admin": isAdmin}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "admin flag changed", "target_user_id", userID, "admin", isAdmin)
+	return nil
 }
 
 func (r *Repository) DeleteUser(ctx context.Context, userID int64) error {
@@ -173,13 +225,33 @@ func (r *Repository) DeleteUser(ctx context.Context, userID int64) error {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
+	var before struct {
+		Email   string `json:"email"`
+		IsAdmin bool   `json:"is_admin"`
+	}
+	found := true
+	err = tx.QueryRowContext(ctx, `SELECT email, is_admin FROM users WHERE id = ?`, userID).Scan(&before.Email, &before.IsAdmin)
+	if errors.Is(err, sql.ErrNoRows) {
+		found = false
+	} else if err != nil {
+		return err
+	}
 	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, userID); err != nil {
 		return err
 	}
 	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, userID); err != nil {
 		return err
 	}
-	return tx.Commit()
+	if found {
+		if err := audit.Record(ctx, tx, AuditDeleteUser, audit.EntityUser, userID, before, nil); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "user deleted", "target_user_id", userID)
+	return nil
 }
 
 func (r *Repository) CountOtherAdmins(ctx context.Context, excludeID int64) (int64, error) {
@@ -0,0 +1,142 @@
+// Package backup writes consistent copies of the SQLite database with
+// VACUUM INTO. Backups run as jobs so a large database never blocks a request.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/jobs"
+)
+
+// JobBackup is the job type that writes one backup file.
+const JobBackup = "db.backup"
+
+var ErrDisabled = errors.New("backups are not configured")
+
+// File is a backup on disk.
+type File struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Service writes backups of db into Dir and keeps the newest Keep of them.
+type Service struct {
+	db   *sql.DB
+	jobs *jobs.Queue
+	now  func() time.Time
+
+	Dir  string
+	Keep int
+}
+
+// NewService creates a Service. An empty dir disables backups.
+func NewService(db *sql.DB, q *jobs.Queue, dir string) *Service {
+	s := &Service{db: db, jobs: q, now: time.Now, Dir: dir, Keep: 14}
+	q.Handle(JobBackup, func(ctx context.Context, j jobs.Job) (any, error) {
+		return s.Write(ctx)
+	})
+	return s
+}
+
+// Enqueue schedules a backup and returns the job id.
+func (s *Service) Enqueue(ctx context.Context) (int64, error) {
+	if s.Dir == "" {
+		return 0, ErrDisabled
+	}
+	return s.jobs.Enqueue(ctx, JobBackup, struct{}{})
+}
+
+// Write copies the database into a new file in Dir and prunes old backups.
+func (s *Service) Write(ctx context.Context) (File, error) {
+	if s.Dir == "" {
+		return File{}, jobs.Permanent(ErrDisabled)
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return File{}, err
+	}
+	name := "xmatches-" + s.now().UTC().Format("20060102-150405") + ".db"
+	full := filepath.Join(s.Dir, name)
+	// VACUUM INTO refuses to overwrite, so a retried job must start clean
+	_ = os.Remove(full)
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, full); err != nil {
+		return File{}, fmt.Errorf("vacuum into %s: %w", name, err)
+	}
+	st, err := os.Stat(full)
+	if err != nil {
+		return File{}, err
+	}
+	if err := s.prune(); err != nil {
+		return File{}, err
+	}
+	return File{Name: name, Size: st.Size(), CreatedAt: st.ModTime()}, nil
+}
+
+// List returns the backups in Dir, newest first.
+func (s *Service) List() ([]File, error) {
+	if s.Dir == "" {
+		return []File{}, nil
+	}
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []File{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "xmatches-") || filepath.Ext(e.Name()) != ".db" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, File{Name: e.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	// Names embed the timestamp, so they sort chronologically
+	sort.Slice(out, func(i, j int) bool { return out[i].Name > out[j].Name })
+	return out, nil
+}
+
+func (s *Service) prune() error {
+	if s.Keep <= 0 {
+		return nil
+	}
+	list, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, f := range list[min(s.Keep, len(list)):] {
+		if err := os.Remove(filepath.Join(s.Dir, f.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run enqueues a backup every interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := s.Enqueue(ctx); err != nil {
+				slog.ErrorContext(ctx, "backup: enqueue", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+)
+
+func TestService_WritesAndPrunesBackups(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ctx := context.Background()
+	q := jobs.New(db)
+
+	if _, err := NewService(db, q, "").Enqueue(ctx); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("expected backups to be disabled without a dir: %v", err)
+	}
+
+	s := NewService(db, q, t.TempDir())
+	s.Keep = 2
+	now := time.Date(2025, 9, 20, 3, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	for range 3 {
+		if _, err := s.Enqueue(ctx); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		if ran, err := q.RunNext(ctx); !ran || err != nil {
+			t.Fatalf("expected the backup job to run: ran=%v err=%v", ran, err)
+		}
+		now = now.Add(24 * time.Hour)
+	}
+
+	list, err := s.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "xmatches-20250922-030000.db" || list[1].Name != "xmatches-20250921-030000.db" {
+		t.Fatalf("expected the two newest backups, got %+v", list)
+	}
+
+	// The copy is a working database
+	cp, err := sql.Open("sqlite", filepath.Join(s.Dir, list[0].Name))
+	if err != nil {
+		t.Fatalf("open backup: %v", err)
+	}
+	defer cp.Close()
+	var n int
+	if err := cp.QueryRow(`SELECT COUNT(*) FROM jobs`).Scan(&n); err != nil || n != 3 {
+		t.Fatalf("expected the jobs table in the backup: n=%d err=%v", n, err)
+	}
+}
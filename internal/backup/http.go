@@ -0,0 +1,56 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes mounts /api/admin/backups behind the given admin middleware.
+func RegisterAdminRoutes(r *gin.Engine, s *Service, admin gin.HandlerFunc) {
+	g := r.Group("/api/admin/backups")
+	g.Use(admin)
+
+	g.GET("", func(c *gin.Context) {
+		list, err := s.List()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	// The backup is written by a worker; follow it via /api/admin/jobs/:id
+	g.POST("", func(c *gin.Context) {
+		id, err := s.Enqueue(c.Request.Context())
+		if errors.Is(err, ErrDisabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"job_id": id, "status_url": fmt.Sprintf("/api/admin/jobs/%d", id)})
+	})
+
+	g.GET("/:name", func(c *gin.Context) {
+		name := c.Param("name")
+		list, err := s.List()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Only serve files List knows about, never an arbitrary path
+		for _, f := range list {
+			if f.Name == name {
+				c.FileAttachment(filepath.Join(s.Dir, f.Name), f.Name)
+				return
+			}
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	})
+}
@@ -0,0 +1,326 @@
+// Package calsync mirrors the matches into an external CalDAV calendar,
+// such as a club master calendar on Radicale, and pulls back the edits made
+// there. Each match is one event whose UID carries the match id; the
+// remote_events table remembers the ETag and match version both sides had
+// at the last sync, so a pass can tell which side changed.
+package calsync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/matches"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// JobSync is the job type that runs one sync pass.
+const JobSync = "caldav.sync"
+
+var (
+	ErrDisabled      = errors.New("caldav sync is not configured")
+	ErrInvalidWinner = errors.New("winner must be local, remote or newest")
+)
+
+// Winner decides a conflict: the match and its event both changed since
+// the last sync.
+type Winner string
+
+const (
+	WinnerLocal  Winner = "local"  // X-Matches overwrites the event
+	WinnerRemote Winner = "remote" // the event's edits are applied to the match
+	WinnerNewest Winner = "newest" // the later change, by LAST-MODIFIED
+)
+
+// ParseWinner reads a Winner; empty means WinnerLocal.
+func ParseWinner(s string) (Winner, error) {
+	switch w := Winner(s); w {
+	case "":
+		return WinnerLocal, nil
+	case WinnerLocal, WinnerRemote, WinnerNewest:
+		return w, nil
+	}
+	return "", ErrInvalidWinner
+}
+
+// Result counts what a sync pass did. Errors are per match; they do not
+// stop the pass and are retried on the next one.
+type Result struct {
+	Pushed    int      `json:"pushed"`
+	Pulled    int      `json:"pulled"`
+	Deleted   int      `json:"deleted"`
+	Conflicts int      `json:"conflicts"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// Status is the last pass, for the admin API.
+type Status struct {
+	Enabled   bool       `json:"enabled"`
+	URL       string     `json:"url,omitempty"`
+	Winner    Winner     `json:"winner"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	LastError string     `json:"last_error,omitempty"`
+	Last      *Result    `json:"last,omitempty"`
+}
+
+// Service syncs the matches of repo with the calendar client points to.
+type Service struct {
+	q      *dbpkg.Queries
+	repo   *matches.Repository
+	cfg    *settings.Service
+	jobs   *jobs.Queue
+	client *Client
+	winner Winner
+	now    func() time.Time
+
+	mu     sync.Mutex // one pass at a time
+	smu    sync.Mutex // guards status, which is read during a pass
+	status Status
+}
+
+// NewService creates a Service. A nil client disables the sync.
+func NewService(db *sql.DB, repo *matches.Repository, cfg *settings.Service, q *jobs.Queue, client *Client, winner Winner) *Service {
+	if winner == "" {
+		winner = WinnerLocal
+	}
+	s := &Service{q: dbpkg.New(db), repo: repo, cfg: cfg, jobs: q, client: client, winner: winner, now: time.Now}
+	s.status = Status{Enabled: client != nil, Winner: winner}
+	if client != nil {
+		s.status.URL = client.URL
+	}
+	q.Handle(JobSync, func(ctx context.Context, j jobs.Job) (any, error) {
+		if s.client == nil {
+			return nil, jobs.Permanent(ErrDisabled)
+		}
+		return s.Sync(ctx)
+	})
+	return s
+}
+
+// Enqueue schedules a sync pass and returns the job id.
+func (s *Service) Enqueue(ctx context.Context) (int64, error) {
+	if s.client == nil {
+		return 0, ErrDisabled
+	}
+	return s.jobs.Enqueue(ctx, JobSync, struct{}{})
+}
+
+// Status returns how the last pass went.
+func (s *Service) Status() Status {
+	s.smu.Lock()
+	defer s.smu.Unlock()
+	return s.status
+}
+
+// Run enqueues a sync pass every interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := s.Enqueue(ctx); err != nil {
+				slog.ErrorContext(ctx, "calsync: enqueue", "err", err)
+			}
+		}
+	}
+}
+
+// remoteEvent is one of our events as found in the calendar.
+type remoteEvent struct {
+	Object
+	event matches.CalendarEvent
+}
+
+// Sync runs one pass: it pushes new and changed matches, pulls the time,
+// place and status edits made in the calendar, and removes the events of
+// deleted matches. Events without one of our UIDs are left alone, so the
+// calendar can hold other things too. An event deleted in the calendar is
+// put back; matches are deleted in X-Matches.
+func (s *Service) Sync(ctx context.Context) (Result, error) {
+	if s.client == nil {
+		return Result{}, ErrDisabled
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.sync(ctx)
+	now := s.now().UTC()
+	s.smu.Lock()
+	defer s.smu.Unlock()
+	s.status.LastRunAt = &now
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+		return res, err
+	}
+	s.status.Last = &res
+	return res, nil
+}
+
+func (s *Service) sync(ctx context.Context) (Result, error) {
+	var res Result
+	loc := s.cfg.Current(ctx).Location()
+	objects, err := s.client.List(ctx)
+	if err != nil {
+		return res, err
+	}
+	remote := map[int64]remoteEvent{}
+	for _, o := range objects {
+		events, err := matches.ParseCalendarEvents(o.Data, loc)
+		if err != nil || len(events) == 0 {
+			continue
+		}
+		if id, ok := matches.MatchIDFromUID(events[0].UID); ok {
+			remote[id] = remoteEvent{Object: o, event: events[0]}
+		}
+	}
+	rows, err := s.q.ListRemoteEvents(ctx)
+	if err != nil {
+		return res, err
+	}
+	links := make(map[int64]dbpkg.RemoteEvent, len(rows))
+	for _, l := range rows {
+		links[l.MatchID] = l
+	}
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	alive := make(map[int64]bool, len(list))
+	for _, m := range list {
+		alive[m.ID] = true
+		var link *dbpkg.RemoteEvent
+		if l, ok := links[m.ID]; ok {
+			link = &l
+		}
+		var rem *remoteEvent
+		if r, ok := remote[m.ID]; ok {
+			rem = &r
+		}
+		if err := s.syncMatch(ctx, m, link, rem, &res); err != nil {
+			slog.WarnContext(ctx, "calsync: match", "match_id", m.ID, "err", err)
+			res.Errors = append(res.Errors, fmt.Sprintf("match %d: %v", m.ID, err))
+		}
+	}
+
+	// Deleted here, or in the trash: the event goes, whatever was edited in it
+	gone := map[int64]string{}
+	for id, l := range links {
+		if !alive[id] {
+			gone[id] = l.Href
+		}
+	}
+	for id, r := range remote {
+		if !alive[id] {
+			gone[id] = r.Href
+		}
+	}
+	for id, href := range gone {
+		err := s.client.Delete(ctx, href, "")
+		if err == nil {
+			err = s.q.DeleteRemoteEvent(ctx, id)
+		}
+		if err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("match %d: %v", id, err))
+			continue
+		}
+		res.Deleted++
+	}
+	return res, nil
+}
+
+// syncMatch brings m and its event in line. link is the state at the last
+// sync and rem the event now; either can be missing.
+func (s *Service) syncMatch(ctx context.Context, m dbpkg.Match, link *dbpkg.RemoteEvent, rem *remoteEvent, res *Result) error {
+	switch {
+	case rem == nil:
+		// New, or deleted in the calendar
+		href, err := s.client.Href(fmt.Sprintf("x-matches-%d.ics", m.ID))
+		if err != nil {
+			return err
+		}
+		if link != nil {
+			href = link.Href
+		}
+		return s.push(ctx, m, href, "", res)
+	case link == nil:
+		// Ours but never linked, as after restoring a backup: nothing
+		// says which side changed, so the winner decides without a conflict
+		return s.resolve(ctx, m, rem, res)
+	}
+	localChanged := m.Version != link.Version
+	remoteChanged := rem.ETag != link.Etag
+	switch {
+	case localChanged && remoteChanged:
+		res.Conflicts++
+		return s.resolve(ctx, m, rem, res)
+	case remoteChanged:
+		return s.pull(ctx, m, rem, res)
+	case localChanged:
+		return s.push(ctx, m, rem.Href, rem.ETag, res)
+	}
+	return nil
+}
+
+func (s *Service) resolve(ctx context.Context, m dbpkg.Match, rem *remoteEvent, res *Result) error {
+	remoteWins := s.winner == WinnerRemote
+	if s.winner == WinnerNewest && m.UpdatedAt != nil && !rem.event.LastModified.IsZero() {
+		remoteWins = rem.event.LastModified.After(*m.UpdatedAt)
+	}
+	if remoteWins {
+		return s.pull(ctx, m, rem, res)
+	}
+	return s.push(ctx, m, rem.Href, rem.ETag, res)
+}
+
+// push writes m's event over the version etag names, or creates it.
+func (s *Service) push(ctx context.Context, m dbpkg.Match, href, etag string, res *Result) error {
+	etag, err := s.client.Put(ctx, href, s.repo.CalendarObject(ctx, m), etag)
+	if err != nil {
+		return err
+	}
+	res.Pushed++
+	return s.link(ctx, m, href, etag)
+}
+
+// pull applies the event's edits to m. The event is left as the calendar
+// wrote it; only what the match can hold is taken from it.
+func (s *Service) pull(ctx context.Context, m dbpkg.Match, rem *remoteEvent, res *Result) error {
+	row, changed, err := s.repo.ApplyEvent(ctx, m, rem.event)
+	switch {
+	case errors.Is(err, matches.ErrVersionMismatch):
+		// Edited here meanwhile; the next pass sees a conflict
+		return err
+	case err != nil:
+		// An edit the match cannot take, such as an invalid status
+		// change: put our version back rather than fail every pass
+		if perr := s.push(ctx, m, rem.Href, rem.ETag, res); perr != nil {
+			return errors.Join(err, perr)
+		}
+		return err
+	}
+	if changed {
+		res.Pulled++
+	}
+	return s.link(ctx, row, rem.Href, rem.ETag)
+}
+
+func (s *Service) link(ctx context.Context, m dbpkg.Match, href, etag string) error {
+	return s.q.UpsertRemoteEvent(ctx, dbpkg.UpsertRemoteEventParams{
+		MatchID:  m.ID,
+		Uid:      matches.EventUID(m.ID),
+		Href:     href,
+		Etag:     etag,
+		Version:  m.Version,
+		SyncedAt: s.now().UTC(),
+	})
+}
@@ -0,0 +1,297 @@
+package calsync
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/matches"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// fakeDAV is a calendar collection at /cal/ that keeps its events in memory.
+type fakeDAV struct {
+	mu   sync.Mutex
+	objs map[string]*Object
+	n    int
+}
+
+func newFakeDAV() *fakeDAV { return &fakeDAV{objs: map[string]*Object{}} }
+
+func (f *fakeDAV) nextETag() string {
+	f.n++
+	return fmt.Sprintf(`"e%d"`, f.n)
+}
+
+// edit changes an event as a calendar app would.
+func (f *fakeDAV) edit(t *testing.T, href string, oldnew ...string) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.objs[href]
+	if o == nil {
+		t.Fatalf("no event at %s", href)
+	}
+	for i := 0; i+1 < len(oldnew); i += 2 {
+		if !strings.Contains(o.Data, oldnew[i]) {
+			t.Fatalf("event %s has no %q:\n%s", href, oldnew[i], o.Data)
+		}
+		o.Data = strings.Replace(o.Data, oldnew[i], oldnew[i+1], 1)
+	}
+	o.ETag = f.nextETag()
+}
+
+func (f *fakeDAV) get(href string) (Object, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.objs[href]
+	if !ok {
+		return Object{}, false
+	}
+	return *o, true
+}
+
+func (f *fakeDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := r.URL.Path
+	o := f.objs[p]
+	if m := r.Header.Get("If-Match"); m != "" && (o == nil || o.ETag != m) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if r.Header.Get("If-None-Match") == "*" && o != nil {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	switch r.Method {
+	case "REPORT":
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?><multistatus xmlns="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`)
+		for href, o := range f.objs {
+			fmt.Fprintf(w, `<response><href>%s</href><propstat><prop><getetag>%s</getetag><C:calendar-data>`, href, o.ETag)
+			_ = xml.EscapeText(w, []byte(o.Data))
+			fmt.Fprint(w, `</C:calendar-data></prop><status>HTTP/1.1 200 OK</status></propstat></response>`)
+		}
+		fmt.Fprint(w, `</multistatus>`)
+	case http.MethodGet:
+		if o == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", o.ETag)
+		fmt.Fprint(w, o.Data)
+	case http.MethodPut:
+		b, _ := io.ReadAll(r.Body)
+		code := http.StatusNoContent
+		if o == nil {
+			o = &Object{Href: p}
+			f.objs[p] = o
+			code = http.StatusCreated
+		}
+		o.Data, o.ETag = string(b), f.nextETag()
+		w.Header().Set("ETag", o.ETag)
+		w.WriteHeader(code)
+	case http.MethodDelete:
+		if o == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.objs, p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestService(t *testing.T, url string) (*Service, *matches.Repository) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	cfg := settings.NewService(db)
+	repo := matches.NewRepository(db, cfg)
+	var client *Client
+	if url != "" {
+		client = NewClient(url, os.Getenv("CALSYNC_RADICALE_USER"), os.Getenv("CALSYNC_RADICALE_PASSWORD"))
+	}
+	return NewService(db, repo, cfg, jobs.New(db), client, WinnerLocal), repo
+}
+
+func mustSync(t *testing.T, s *Service, want Result) {
+	t.Helper()
+	got, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("sync: got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseWinner(t *testing.T) {
+	for in, want := range map[string]Winner{"": WinnerLocal, "remote": WinnerRemote, "newest": WinnerNewest} {
+		if got, err := ParseWinner(in); err != nil || got != want {
+			t.Errorf("%q: got %q %v", in, got, err)
+		}
+	}
+	if _, err := ParseWinner("X-Matches"); err != ErrInvalidWinner {
+		t.Errorf("expected ErrInvalidWinner, got %v", err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	dav := newFakeDAV()
+	srv := httptest.NewServer(dav)
+	defer srv.Close()
+	s, repo := newTestService(t, srv.URL+"/cal")
+	ctx := context.Background()
+
+	// Someone else's event in the shared calendar
+	dav.objs["/cal/training.ics"] = &Object{Href: "/cal/training.ics", ETag: `"t"`,
+		Data: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:training@club\r\nDTSTART:20250921T160000Z\r\nSUMMARY:Träning\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"}
+
+	a, _ := repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", TimeRaw: "14:30", HomeTeam: "IK Sund", AwayTeam: "Lugi", Venue: "Hallen", City: "Lund"})
+	b, _ := repo.Create(ctx, matches.Match{DateRaw: "2025-09-27", TimeRaw: "10:00", HomeTeam: "H43", AwayTeam: "IK Sund"})
+	hrefA, hrefB := fmt.Sprintf("/cal/x-matches-%d.ics", a.ID), fmt.Sprintf("/cal/x-matches-%d.ics", b.ID)
+
+	mustSync(t, s, Result{Pushed: 2})
+	if o, ok := dav.get(hrefA); !ok || !strings.Contains(o.Data, "UID:"+matches.EventUID(a.ID)) {
+		t.Fatalf("expected the event for match %d: %+v", a.ID, o)
+	}
+	mustSync(t, s, Result{})
+
+	// Moved and relocated in the calendar
+	dav.edit(t, hrefA, "DTSTART:20250920T123000Z", "DTSTART:20250920T130000Z",
+		"DTEND:20250920T133000Z", "DTEND:20250920T140000Z", `LOCATION:Hallen\, Lund`, `LOCATION:Sporthallen\, Lund`)
+	mustSync(t, s, Result{Pulled: 1})
+	got, _ := repo.Get(ctx, a.ID)
+	if *got.TimeRaw != "15:00" || *got.Venue != "Sporthallen" || *got.City != "Lund" {
+		t.Fatalf("remote edit not pulled: time=%q venue=%q", *got.TimeRaw, *got.Venue)
+	}
+	mustSync(t, s, Result{})
+
+	// Changed here
+	if _, err := repo.PatchAt(ctx, b.ID, matches.AnyVersion, matches.MatchPatch{Notes: matches.Field[string]{Set: true, Value: "Buss 08:15"}}); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	mustSync(t, s, Result{Pushed: 1})
+	if o, _ := dav.get(hrefB); !strings.Contains(o.Data, "Buss 08:15") {
+		t.Fatalf("local change not pushed:\n%s", o.Data)
+	}
+
+	// Both changed: X-Matches wins by default
+	conflict := func() {
+		if _, err := repo.PatchAt(ctx, a.ID, matches.AnyVersion, matches.MatchPatch{Referees: matches.Field[string]{Set: true, Value: "Domare"}}); err != nil {
+			t.Fatalf("patch: %v", err)
+		}
+		dav.edit(t, hrefA, "DTSTART:20250920T130000Z", "DTSTART:20250920T140000Z", "DTEND:20250920T140000Z", "DTEND:20250920T150000Z")
+	}
+	conflict()
+	mustSync(t, s, Result{Pushed: 1, Conflicts: 1})
+	if o, _ := dav.get(hrefA); !strings.Contains(o.Data, "DTSTART:20250920T130000Z") {
+		t.Fatalf("the local version should have won:\n%s", o.Data)
+	}
+	s.winner = WinnerRemote
+	conflict()
+	mustSync(t, s, Result{Pulled: 1, Conflicts: 1})
+	if got, _ := repo.Get(ctx, a.ID); *got.TimeRaw != "16:00" {
+		t.Fatalf("the remote version should have won: %q", *got.TimeRaw)
+	}
+	mustSync(t, s, Result{})
+
+	// Deleted in the calendar: put back. Deleted here: removed there.
+	dav.mu.Lock()
+	delete(dav.objs, hrefA)
+	dav.mu.Unlock()
+	if err := repo.Delete(ctx, b.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	mustSync(t, s, Result{Pushed: 1, Deleted: 1})
+	if _, ok := dav.get(hrefA); !ok {
+		t.Fatal("the event should be back")
+	}
+	if _, ok := dav.get(hrefB); ok {
+		t.Fatal("the event of the deleted match should be gone")
+	}
+	if o, ok := dav.get("/cal/training.ics"); !ok || o.ETag != `"t"` {
+		t.Fatal("other events must be left alone")
+	}
+	if st := s.Status(); st.LastRunAt == nil || st.Last == nil || st.Last.Deleted != 1 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
+
+func TestSync_Disabled(t *testing.T) {
+	s, _ := newTestService(t, "")
+	if _, err := s.Enqueue(context.Background()); err != ErrDisabled {
+		t.Fatalf("expected ErrDisabled, got %v", err)
+	}
+}
+
+// TestSync_Radicale runs against a real server, e.g.
+//
+//	docker run -d -p 5232:5232 tomsquest/docker-radicale
+//	CALSYNC_RADICALE_URL=http://localhost:5232/test/matcher/ go test ./internal/calsync
+//
+// The calendar collection must exist; matches synced by the test are removed again.
+func TestSync_Radicale(t *testing.T) {
+	url := os.Getenv("CALSYNC_RADICALE_URL")
+	if url == "" {
+		t.Skip("CALSYNC_RADICALE_URL is not set")
+	}
+	s, repo := newTestService(t, url)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", TimeRaw: "14:30", HomeTeam: "IK Sund", AwayTeam: "Lugi", Venue: "Hallen"})
+	if res, err := s.Sync(ctx); err != nil || res.Pushed != 1 || len(res.Errors) > 0 {
+		t.Fatalf("push: %+v %v", res, err)
+	}
+	t.Cleanup(func() {
+		_ = repo.Delete(ctx, m.ID)
+		_, _ = s.Sync(ctx)
+	})
+
+	objs, err := s.client.List(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var obj Object
+	for _, o := range objs {
+		if strings.Contains(o.Data, "UID:"+matches.EventUID(m.ID)) {
+			obj = o
+		}
+	}
+	if obj.Href == "" {
+		t.Fatal("the event is not on the server")
+	}
+	moved := strings.Replace(obj.Data, "DTSTART:20250920T123000Z", "DTSTART:20250920T130000Z", 1)
+	moved = strings.Replace(moved, "DTEND:20250920T133000Z", "DTEND:20250920T140000Z", 1)
+	if _, err := s.client.Put(ctx, obj.Href, moved, obj.ETag); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if res, err := s.Sync(ctx); err != nil || res.Pulled != 1 {
+		t.Fatalf("pull: %+v %v", res, err)
+	}
+	if got, _ := repo.Get(ctx, m.ID); *got.TimeRaw != "15:00" {
+		t.Fatalf("time not pulled: %q", *got.TimeRaw)
+	}
+}
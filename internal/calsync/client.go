@@ -0,0 +1,210 @@
+package calsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// ErrPrecondition means the event changed on the server since its ETag was read.
+var ErrPrecondition = errors.New("caldav: precondition failed")
+
+// Object is a calendar object resource: one .ics file in the collection.
+type Object struct {
+	Href string
+	ETag string
+	Data string
+}
+
+// Client talks to one calendar collection on a CalDAV server, such as
+// https://dav.example.com/club/matcher/ on Radicale.
+type Client struct {
+	URL      string
+	Username string
+	Password string
+	HTTP     *http.Client
+}
+
+// NewClient creates a Client for the collection at rawURL.
+func NewClient(rawURL, username, password string) *Client {
+	if !strings.HasSuffix(rawURL, "/") {
+		rawURL += "/"
+	}
+	return &Client{
+		URL:      rawURL,
+		Username: username,
+		Password: password,
+		HTTP:     &http.Client{Timeout: 30 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+// resolve turns an href from the server, usually an absolute path, into a URL.
+func (c *Client) resolve(href string) (string, error) {
+	base, err := url.Parse(c.URL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// Href returns the path of the object called name in the collection.
+func (c *Client) Href(name string) (string, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return "", err
+	}
+	return u.ResolveReference(&url.URL{Path: name}).EscapedPath(), nil
+}
+
+func (c *Client) do(ctx context.Context, method, href string, body []byte, header map[string]string) (*http.Response, error) {
+	u, err := c.resolve(href)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	req.Header.Set("User-Agent", "X-Matches-CalDAV/1")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	return c.HTTP.Do(req)
+}
+
+// statusError describes an unexpected response, with the start of its body.
+func statusError(method, href string, res *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("caldav: %s %s: %s %s", method, href, res.Status, strings.TrimSpace(string(b)))
+}
+
+const listQuery = `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT"/></C:comp-filter></C:filter>
+</C:calendar-query>`
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ETag string `xml:"DAV: getetag"`
+				Data string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// List returns every event in the collection with its data.
+func (c *Client) List(ctx context.Context) ([]Object, error) {
+	res, err := c.do(ctx, "REPORT", c.URL, []byte(listQuery), map[string]string{
+		"Content-Type": "application/xml; charset=utf-8",
+		"Depth":        "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusMultiStatus {
+		return nil, statusError("REPORT", c.URL, res)
+	}
+	var ms multistatus
+	if err := xml.NewDecoder(res.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("caldav: REPORT %s: %w", c.URL, err)
+	}
+	out := []Object{}
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstats {
+			// Only the 200 propstat carries values; the collection itself has no data
+			if strings.Contains(ps.Status, " 200") && ps.Prop.Data != "" {
+				out = append(out, Object{Href: r.Href, ETag: ps.Prop.ETag, Data: ps.Prop.Data})
+			}
+		}
+	}
+	return out, nil
+}
+
+// Get fetches one event.
+func (c *Client) Get(ctx context.Context, href string) (Object, error) {
+	res, err := c.do(ctx, http.MethodGet, href, nil, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return Object{}, statusError(http.MethodGet, href, res)
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Href: href, ETag: res.Header.Get("ETag"), Data: string(b)}, nil
+}
+
+// Put writes an event and returns its new ETag. With an etag it only
+// replaces that version of the event; without one it only creates.
+func (c *Client) Put(ctx context.Context, href, data, etag string) (string, error) {
+	header := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	if etag != "" {
+		header["If-Match"] = etag
+	} else {
+		header["If-None-Match"] = "*"
+	}
+	res, err := c.do(ctx, http.MethodPut, href, []byte(data), header)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+	case http.StatusPreconditionFailed:
+		return "", fmt.Errorf("%w: PUT %s", ErrPrecondition, href)
+	default:
+		return "", statusError(http.MethodPut, href, res)
+	}
+	if t := res.Header.Get("ETag"); t != "" && !strings.HasPrefix(t, "W/") {
+		return t, nil
+	}
+	// Servers that change the data on the way in send no strong ETag
+	obj, err := c.Get(ctx, href)
+	return obj.ETag, err
+}
+
+// Delete removes an event; one that is already gone is not an error. An
+// empty etag deletes whatever version is there.
+func (c *Client) Delete(ctx context.Context, href, etag string) error {
+	var header map[string]string
+	if etag != "" {
+		header = map[string]string{"If-Match": etag}
+	}
+	res, err := c.do(ctx, http.MethodDelete, href, nil, header)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound, http.StatusGone:
+		return nil
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: DELETE %s", ErrPrecondition, href)
+	}
+	return statusError(http.MethodDelete, href, res)
+}
@@ -0,0 +1,33 @@
+package calsync
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes mounts /api/admin/calsync behind the given admin middleware.
+func RegisterAdminRoutes(r *gin.Engine, s *Service, admin gin.HandlerFunc) {
+	g := r.Group("/api/admin/calsync")
+	g.Use(admin)
+
+	g.GET("", func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.Status())
+	})
+
+	// Syncs now instead of waiting for the interval; follow it via /api/admin/jobs/:id
+	g.POST("", func(c *gin.Context) {
+		id, err := s.Enqueue(c.Request.Context())
+		if errors.Is(err, ErrDisabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"job_id": id, "status_url": fmt.Sprintf("/api/admin/jobs/%d", id)})
+	})
+}
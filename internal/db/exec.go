@@ -0,0 +1,12 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ExecContext runs a statement on the connection or transaction behind q, for
+// writes that live outside the generated queries (such as audit entries).
+func (q *Queries) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return q.db.ExecContext(ctx, query, args...)
+}
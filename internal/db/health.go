@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Ready reports an error unless every embedded migration has been applied and
+// the database accepts writes.
+func Ready(ctx context.Context, db *sql.DB) error {
+	goose.SetBaseFS(embedMigrations)
+	all, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("collect migrations: %w", err)
+	}
+	latest, err := all.Last()
+	if err != nil {
+		return fmt.Errorf("collect migrations: %w", err)
+	}
+	current, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return fmt.Errorf("db version: %w", err)
+	}
+	if current < latest.Version {
+		return fmt.Errorf("migrations pending: at %d, want %d", current, latest.Version)
+	}
+
+	// Take the write lock without changing anything; fails on a read-only
+	// file or a lock held longer than busy_timeout
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return fmt.Errorf("db not writable: %w", err)
+	}
+	_, err = conn.ExecContext(ctx, `ROLLBACK`)
+	return err
+}
@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createMatch = `-- name: CreateMatch :one
@@ -15,11 +16,17 @@ INSERT INTO matches (
   league, team, opponent, home_team, away_team, venue, court, city,
   gather_time, gather_place, match_number, referees, notes,
   played, goals_for, goals_against, player_notes,
-  top_scorer_team, top_scorer_opponent
+  top_scorer_team, top_scorer_opponent,
+  status, status_reason,
+  overtime_for, overtime_against, shootout_for, shootout_against,
+  updated_at, created_at, change_seq
 ) VALUES (
-  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+  ?, ?,
+  ?, ?, ?, ?,
+  ?, ?, ?
 )
-RETURNING id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent
+RETURNING id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq
 `
 
 type CreateMatchParams struct {
@@ -48,6 +55,15 @@ type CreateMatchParams struct {
 	PlayerNotes       *string
 	TopScorerTeam     *string
 	TopScorerOpponent *string
+	Status            string
+	StatusReason      *string
+	OvertimeFor       *int64
+	OvertimeAgainst   *int64
+	ShootoutFor       *int64
+	ShootoutAgainst   *int64
+	UpdatedAt         *time.Time
+	CreatedAt         *time.Time
+	ChangeSeq         int64
 }
 
 func (q *Queries) CreateMatch(ctx context.Context, arg CreateMatchParams) (Match, error) {
@@ -77,6 +93,15 @@ func (q *Queries) CreateMatch(ctx context.Context, arg CreateMatchParams) (Match
 		arg.PlayerNotes,
 		arg.TopScorerTeam,
 		arg.TopScorerOpponent,
+		arg.Status,
+		arg.StatusReason,
+		arg.OvertimeFor,
+		arg.OvertimeAgainst,
+		arg.ShootoutFor,
+		arg.ShootoutAgainst,
+		arg.UpdatedAt,
+		arg.CreatedAt,
+		arg.ChangeSeq,
 	)
 	var i Match
 	err := row.Scan(
@@ -106,38 +131,80 @@ func (q *Queries) CreateMatch(ctx context.Context, arg CreateMatchParams) (Match
 		&i.PlayerNotes,
 		&i.TopScorerTeam,
 		&i.TopScorerOpponent,
+		&i.Status,
+		&i.StatusReason,
+		&i.RescheduledTo,
+		&i.Sequence,
+		&i.OvertimeFor,
+		&i.OvertimeAgainst,
+		&i.ShootoutFor,
+		&i.ShootoutAgainst,
+		&i.DeletedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
 
-const deleteMatch = `-- name: DeleteMatch :exec
-DELETE FROM matches WHERE id = ?
-`
-
-func (q *Queries) DeleteMatch(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteMatch, id)
-	return err
-}
-
-const deleteAllMatches = `-- name: DeleteAllMatches :exec
-DELETE FROM matches
+const getMatch = `-- name: GetMatch :one
+SELECT id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq FROM matches WHERE id = ? AND deleted_at IS NULL
 `
 
-func (q *Queries) DeleteAllMatches(ctx context.Context) (int64, error) {
-	res, err := q.db.ExecContext(ctx, deleteAllMatches)
-	if err != nil {
-		return 0, err
-	}
-	n, _ := res.RowsAffected()
-	return n, nil
+func (q *Queries) GetMatch(ctx context.Context, id int64) (Match, error) {
+	row := q.db.QueryRowContext(ctx, getMatch, id)
+	var i Match
+	err := row.Scan(
+		&i.ID,
+		&i.StartIso,
+		&i.EndIso,
+		&i.DateRaw,
+		&i.TimeRaw,
+		&i.EndTimeRaw,
+		&i.Weekday,
+		&i.League,
+		&i.Team,
+		&i.Opponent,
+		&i.HomeTeam,
+		&i.AwayTeam,
+		&i.Venue,
+		&i.Court,
+		&i.City,
+		&i.GatherTime,
+		&i.GatherPlace,
+		&i.MatchNumber,
+		&i.Referees,
+		&i.Notes,
+		&i.Played,
+		&i.GoalsFor,
+		&i.GoalsAgainst,
+		&i.PlayerNotes,
+		&i.TopScorerTeam,
+		&i.TopScorerOpponent,
+		&i.Status,
+		&i.StatusReason,
+		&i.RescheduledTo,
+		&i.Sequence,
+		&i.OvertimeFor,
+		&i.OvertimeAgainst,
+		&i.ShootoutFor,
+		&i.ShootoutAgainst,
+		&i.DeletedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.ChangeSeq,
+	)
+	return i, err
 }
 
-const getMatch = `-- name: GetMatch :one
-SELECT id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent FROM matches WHERE id = ?
+const getMatchByNumber = `-- name: GetMatchByNumber :one
+SELECT id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq FROM matches WHERE match_number = ? AND deleted_at IS NULL ORDER BY id LIMIT 1
 `
 
-func (q *Queries) GetMatch(ctx context.Context, id int64) (Match, error) {
-	row := q.db.QueryRowContext(ctx, getMatch, id)
+func (q *Queries) GetMatchByNumber(ctx context.Context, matchNumber *string) (Match, error) {
+	row := q.db.QueryRowContext(ctx, getMatchByNumber, matchNumber)
 	var i Match
 	err := row.Scan(
 		&i.ID,
@@ -166,12 +233,95 @@ func (q *Queries) GetMatch(ctx context.Context, id int64) (Match, error) {
 		&i.PlayerNotes,
 		&i.TopScorerTeam,
 		&i.TopScorerOpponent,
+		&i.Status,
+		&i.StatusReason,
+		&i.RescheduledTo,
+		&i.Sequence,
+		&i.OvertimeFor,
+		&i.OvertimeAgainst,
+		&i.ShootoutFor,
+		&i.ShootoutAgainst,
+		&i.DeletedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
 
+const listDeletedMatches = `-- name: ListDeletedMatches :many
+SELECT id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq FROM matches
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+`
+
+func (q *Queries) ListDeletedMatches(ctx context.Context) ([]Match, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedMatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Match
+	for rows.Next() {
+		var i Match
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartIso,
+			&i.EndIso,
+			&i.DateRaw,
+			&i.TimeRaw,
+			&i.EndTimeRaw,
+			&i.Weekday,
+			&i.League,
+			&i.Team,
+			&i.Opponent,
+			&i.HomeTeam,
+			&i.AwayTeam,
+			&i.Venue,
+			&i.Court,
+			&i.City,
+			&i.GatherTime,
+			&i.GatherPlace,
+			&i.MatchNumber,
+			&i.Referees,
+			&i.Notes,
+			&i.Played,
+			&i.GoalsFor,
+			&i.GoalsAgainst,
+			&i.PlayerNotes,
+			&i.TopScorerTeam,
+			&i.TopScorerOpponent,
+			&i.Status,
+			&i.StatusReason,
+			&i.RescheduledTo,
+			&i.Sequence,
+			&i.OvertimeFor,
+			&i.OvertimeAgainst,
+			&i.ShootoutFor,
+			&i.ShootoutAgainst,
+			&i.DeletedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.ChangeSeq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listMatches = `-- name: ListMatches :many
-SELECT id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent FROM matches
+SELECT id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq FROM matches
+WHERE deleted_at IS NULL
 ORDER BY (start_iso IS NULL), start_iso, id
 `
 
@@ -211,6 +361,19 @@ func (q *Queries) ListMatches(ctx context.Context) ([]Match, error) {
 			&i.PlayerNotes,
 			&i.TopScorerTeam,
 			&i.TopScorerOpponent,
+			&i.Status,
+			&i.StatusReason,
+			&i.RescheduledTo,
+			&i.Sequence,
+			&i.OvertimeFor,
+			&i.OvertimeAgainst,
+			&i.ShootoutFor,
+			&i.ShootoutAgainst,
+			&i.DeletedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -225,6 +388,288 @@ func (q *Queries) ListMatches(ctx context.Context) ([]Match, error) {
 	return items, nil
 }
 
+const listMatchesChangedSince = `-- name: ListMatchesChangedSince :many
+SELECT id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq FROM matches
+WHERE change_seq > ?
+ORDER BY change_seq, id
+`
+
+func (q *Queries) ListMatchesChangedSince(ctx context.Context, changeSeq int64) ([]Match, error) {
+	rows, err := q.db.QueryContext(ctx, listMatchesChangedSince, changeSeq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Match
+	for rows.Next() {
+		var i Match
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartIso,
+			&i.EndIso,
+			&i.DateRaw,
+			&i.TimeRaw,
+			&i.EndTimeRaw,
+			&i.Weekday,
+			&i.League,
+			&i.Team,
+			&i.Opponent,
+			&i.HomeTeam,
+			&i.AwayTeam,
+			&i.Venue,
+			&i.Court,
+			&i.City,
+			&i.GatherTime,
+			&i.GatherPlace,
+			&i.MatchNumber,
+			&i.Referees,
+			&i.Notes,
+			&i.Played,
+			&i.GoalsFor,
+			&i.GoalsAgainst,
+			&i.PlayerNotes,
+			&i.TopScorerTeam,
+			&i.TopScorerOpponent,
+			&i.Status,
+			&i.StatusReason,
+			&i.RescheduledTo,
+			&i.Sequence,
+			&i.OvertimeFor,
+			&i.OvertimeAgainst,
+			&i.ShootoutFor,
+			&i.ShootoutAgainst,
+			&i.DeletedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.ChangeSeq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedMatches = `-- name: PurgeDeletedMatches :execrows
+DELETE FROM matches WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+`
+
+func (q *Queries) PurgeDeletedMatches(ctx context.Context, deletedAt *time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedMatches, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeMatch = `-- name: PurgeMatch :execrows
+DELETE FROM matches WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) PurgeMatch(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeMatch, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreDeletedMatch = `-- name: RestoreDeletedMatch :one
+UPDATE matches SET deleted_at = NULL, updated_at = ?, change_seq = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NOT NULL
+RETURNING id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq
+`
+
+type RestoreDeletedMatchParams struct {
+	UpdatedAt *time.Time
+	ChangeSeq int64
+	ID        int64
+}
+
+func (q *Queries) RestoreDeletedMatch(ctx context.Context, arg RestoreDeletedMatchParams) (Match, error) {
+	row := q.db.QueryRowContext(ctx, restoreDeletedMatch,
+		arg.UpdatedAt,
+		arg.ChangeSeq,
+		arg.ID,
+	)
+	var i Match
+	err := row.Scan(
+		&i.ID,
+		&i.StartIso,
+		&i.EndIso,
+		&i.DateRaw,
+		&i.TimeRaw,
+		&i.EndTimeRaw,
+		&i.Weekday,
+		&i.League,
+		&i.Team,
+		&i.Opponent,
+		&i.HomeTeam,
+		&i.AwayTeam,
+		&i.Venue,
+		&i.Court,
+		&i.City,
+		&i.GatherTime,
+		&i.GatherPlace,
+		&i.MatchNumber,
+		&i.Referees,
+		&i.Notes,
+		&i.Played,
+		&i.GoalsFor,
+		&i.GoalsAgainst,
+		&i.PlayerNotes,
+		&i.TopScorerTeam,
+		&i.TopScorerOpponent,
+		&i.Status,
+		&i.StatusReason,
+		&i.RescheduledTo,
+		&i.Sequence,
+		&i.OvertimeFor,
+		&i.OvertimeAgainst,
+		&i.ShootoutFor,
+		&i.ShootoutAgainst,
+		&i.DeletedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.ChangeSeq,
+	)
+	return i, err
+}
+
+const setMatchScore = `-- name: SetMatchScore :one
+UPDATE matches SET
+  goals_for = ?,
+  goals_against = ?,
+  overtime_for = ?,
+  overtime_against = ?,
+  updated_at = ?,
+  change_seq = ?,
+  version = version + 1
+WHERE id = ?
+RETURNING id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq
+`
+
+type SetMatchScoreParams struct {
+	GoalsFor        *int64
+	GoalsAgainst    *int64
+	OvertimeFor     *int64
+	OvertimeAgainst *int64
+	UpdatedAt       *time.Time
+	ChangeSeq       int64
+	ID              int64
+}
+
+func (q *Queries) SetMatchScore(ctx context.Context, arg SetMatchScoreParams) (Match, error) {
+	row := q.db.QueryRowContext(ctx, setMatchScore,
+		arg.GoalsFor,
+		arg.GoalsAgainst,
+		arg.OvertimeFor,
+		arg.OvertimeAgainst,
+		arg.UpdatedAt,
+		arg.ChangeSeq,
+		arg.ID,
+	)
+	var i Match
+	err := row.Scan(
+		&i.ID,
+		&i.StartIso,
+		&i.EndIso,
+		&i.DateRaw,
+		&i.TimeRaw,
+		&i.EndTimeRaw,
+		&i.Weekday,
+		&i.League,
+		&i.Team,
+		&i.Opponent,
+		&i.HomeTeam,
+		&i.AwayTeam,
+		&i.Venue,
+		&i.Court,
+		&i.City,
+		&i.GatherTime,
+		&i.GatherPlace,
+		&i.MatchNumber,
+		&i.Referees,
+		&i.Notes,
+		&i.Played,
+		&i.GoalsFor,
+		&i.GoalsAgainst,
+		&i.PlayerNotes,
+		&i.TopScorerTeam,
+		&i.TopScorerOpponent,
+		&i.Status,
+		&i.StatusReason,
+		&i.RescheduledTo,
+		&i.Sequence,
+		&i.OvertimeFor,
+		&i.OvertimeAgainst,
+		&i.ShootoutFor,
+		&i.ShootoutAgainst,
+		&i.DeletedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.ChangeSeq,
+	)
+	return i, err
+}
+
+const softDeleteAllMatches = `-- name: SoftDeleteAllMatches :execrows
+UPDATE matches SET deleted_at = ?, updated_at = ?, change_seq = ?, version = version + 1
+WHERE deleted_at IS NULL
+`
+
+type SoftDeleteAllMatchesParams struct {
+	DeletedAt *time.Time
+	UpdatedAt *time.Time
+	ChangeSeq int64
+}
+
+func (q *Queries) SoftDeleteAllMatches(ctx context.Context, arg SoftDeleteAllMatchesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteAllMatches,
+		arg.DeletedAt,
+		arg.UpdatedAt,
+		arg.ChangeSeq,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteMatch = `-- name: SoftDeleteMatch :execrows
+UPDATE matches SET deleted_at = ?, updated_at = ?, change_seq = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL
+`
+
+type SoftDeleteMatchParams struct {
+	DeletedAt *time.Time
+	UpdatedAt *time.Time
+	ChangeSeq int64
+	ID        int64
+}
+
+func (q *Queries) SoftDeleteMatch(ctx context.Context, arg SoftDeleteMatchParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteMatch,
+		arg.DeletedAt,
+		arg.UpdatedAt,
+		arg.ChangeSeq,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateMatch = `-- name: UpdateMatch :one
 UPDATE matches
 SET
@@ -252,9 +697,20 @@ SET
   goals_against = ?,
   player_notes = ?,
   top_scorer_team = ?,
-  top_scorer_opponent = ?
+  top_scorer_opponent = ?,
+  status = ?,
+  status_reason = ?,
+  rescheduled_to = ?,
+  sequence = ?,
+  overtime_for = ?,
+  overtime_against = ?,
+  shootout_for = ?,
+  shootout_against = ?,
+  updated_at = ?,
+  change_seq = ?,
+  version = version + 1
 WHERE id = ?
-RETURNING id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent
+RETURNING id, start_iso, end_iso, date_raw, time_raw, end_time_raw, weekday, league, team, opponent, home_team, away_team, venue, court, city, gather_time, gather_place, match_number, referees, notes, played, goals_for, goals_against, player_notes, top_scorer_team, top_scorer_opponent, status, status_reason, rescheduled_to, sequence, overtime_for, overtime_against, shootout_for, shootout_against, deleted_at, version, updated_at, created_at, change_seq
 `
 
 type UpdateMatchParams struct {
@@ -283,6 +739,16 @@ type UpdateMatchParams struct {
 	PlayerNotes       *string
 	TopScorerTeam     *string
 	TopScorerOpponent *string
+	Status            string
+	StatusReason      *string
+	RescheduledTo     *int64
+	Sequence          int64
+	OvertimeFor       *int64
+	OvertimeAgainst   *int64
+	ShootoutFor       *int64
+	ShootoutAgainst   *int64
+	UpdatedAt         *time.Time
+	ChangeSeq         int64
 	ID                int64
 }
 
@@ -313,6 +779,16 @@ func (q *Queries) UpdateMatch(ctx context.Context, arg UpdateMatchParams) (Match
 		arg.PlayerNotes,
 		arg.TopScorerTeam,
 		arg.TopScorerOpponent,
+		arg.Status,
+		arg.StatusReason,
+		arg.RescheduledTo,
+		arg.Sequence,
+		arg.OvertimeFor,
+		arg.OvertimeAgainst,
+		arg.ShootoutFor,
+		arg.ShootoutAgainst,
+		arg.UpdatedAt,
+		arg.ChangeSeq,
 		arg.ID,
 	)
 	var i Match
@@ -343,6 +819,19 @@ func (q *Queries) UpdateMatch(ctx context.Context, arg UpdateMatchParams) (Match
 		&i.PlayerNotes,
 		&i.TopScorerTeam,
 		&i.TopScorerOpponent,
+		&i.Status,
+		&i.StatusReason,
+		&i.RescheduledTo,
+		&i.Sequence,
+		&i.OvertimeFor,
+		&i.OvertimeAgainst,
+		&i.ShootoutFor,
+		&i.ShootoutAgainst,
+		&i.DeletedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -0,0 +1,11 @@
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS settings (
+    key         TEXT PRIMARY KEY,
+    value       TEXT NOT NULL,
+    updated_at  TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS settings;
+
@@ -0,0 +1,13 @@
+
+-- +goose Up
+ALTER TABLE matches ADD COLUMN status TEXT NOT NULL DEFAULT 'scheduled'
+    CHECK (status IN ('scheduled', 'postponed', 'cancelled', 'played', 'walkover'));
+ALTER TABLE matches ADD COLUMN status_reason TEXT;
+ALTER TABLE matches ADD COLUMN rescheduled_to INTEGER REFERENCES matches(id) ON DELETE SET NULL;
+ALTER TABLE matches ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;
+
+UPDATE matches SET status = 'played' WHERE played = 1;
+
+-- +goose Down
+-- SQLite cannot drop columns easily; recreate would be needed. No-op.
+SELECT 1;
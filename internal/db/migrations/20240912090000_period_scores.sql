@@ -0,0 +1,25 @@
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS leagues (
+    name            TEXT PRIMARY KEY COLLATE NOCASE,
+    periods         INTEGER NOT NULL DEFAULT 2 CHECK (periods BETWEEN 1 AND 9),
+    period_minutes  INTEGER,
+    updated_at      TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+CREATE TABLE IF NOT EXISTS match_periods (
+    match_id       INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    period         INTEGER NOT NULL,
+    goals_for      INTEGER NOT NULL,
+    goals_against  INTEGER NOT NULL,
+    PRIMARY KEY (match_id, period)
+);
+
+ALTER TABLE matches ADD COLUMN overtime_for INTEGER;
+ALTER TABLE matches ADD COLUMN overtime_against INTEGER;
+ALTER TABLE matches ADD COLUMN shootout_for INTEGER;
+ALTER TABLE matches ADD COLUMN shootout_against INTEGER;
+
+-- +goose Down
+DROP TABLE IF EXISTS match_periods;
+DROP TABLE IF EXISTS leagues;
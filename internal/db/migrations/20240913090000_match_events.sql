@@ -0,0 +1,20 @@
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS match_events (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    match_id    INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL,
+    side        TEXT,
+    period      INTEGER,
+    clock       TEXT,
+    player      TEXT,
+    assist      TEXT,
+    penalty_minutes INTEGER,
+    note        TEXT,
+    created_at  TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+CREATE INDEX IF NOT EXISTS idx_match_events_match ON match_events(match_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_match_events_match;
+DROP TABLE IF EXISTS match_events;
@@ -0,0 +1,13 @@
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS board_state (
+    match_id          INTEGER PRIMARY KEY REFERENCES matches(id) ON DELETE CASCADE,
+    period            INTEGER NOT NULL DEFAULT 1,
+    clock_ms          INTEGER NOT NULL DEFAULT 0,
+    running_since_ms  INTEGER,
+    token_hash        TEXT,
+    updated_at        TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS board_state;
@@ -0,0 +1,22 @@
+
+-- +goose Up
+-- Per-user email subscriptions; a missing row means no emails
+CREATE TABLE IF NOT EXISTS notification_prefs (
+    user_id           INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    reminders         INTEGER NOT NULL DEFAULT 0,
+    schedule_changes  INTEGER NOT NULL DEFAULT 0,
+    results           INTEGER NOT NULL DEFAULT 0,
+    updated_at        TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- Admin overrides of the built-in email templates
+CREATE TABLE IF NOT EXISTS email_templates (
+    name        TEXT PRIMARY KEY, -- reminder|schedule_change|result
+    subject     TEXT NOT NULL,
+    body        TEXT NOT NULL,
+    updated_at  TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_templates;
+DROP TABLE IF EXISTS notification_prefs;
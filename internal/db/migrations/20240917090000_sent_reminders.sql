@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- One row per reminder mailed, so a restart never sends it twice. at_ms is the
+-- kick-off or gather time reminded about; moving the match allows a new reminder.
+CREATE TABLE IF NOT EXISTS sent_reminders (
+    match_id  INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    user_id   INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind      TEXT NOT NULL, -- start|gather
+    at_ms     INTEGER NOT NULL,
+    sent_at   TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+    PRIMARY KEY (match_id, user_id, kind, at_ms)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS sent_reminders;
@@ -0,0 +1,28 @@
+
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS jobs (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    type             TEXT NOT NULL,
+    payload          TEXT NOT NULL DEFAULT '{}', -- JSON
+    status           TEXT NOT NULL DEFAULT 'pending', -- pending|running|done|failed|cancelled
+    run_at_ms        INTEGER NOT NULL,
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    max_attempts     INTEGER NOT NULL DEFAULT 5,
+    last_error       TEXT,
+    result           TEXT, -- JSON returned by the handler
+    locked_until_ms  INTEGER, -- lease of a running job; expired leases are picked up again
+    created_at       TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+    updated_at       TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+    finished_at      TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(status, run_at_ms);
+
+-- Webhook deliveries waiting for a retry are handed over to the queue
+INSERT INTO jobs (type, payload, run_at_ms, max_attempts)
+SELECT 'webhooks.deliver', json_object('delivery_id', id), next_attempt_ms, 8
+FROM webhook_deliveries WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_jobs_due;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,9 @@
+
+-- +goose Up
+-- W3C trace context of the request that enqueued the job; the job's span links back to it
+ALTER TABLE jobs ADD COLUMN trace_context TEXT;
+
+-- +goose Down
+-- SQLite DROP COLUMN is not universally supported in older versions.
+-- No-op down migration.
+SELECT 1;
@@ -0,0 +1,26 @@
+
+-- +goose Up
+-- Who changed what. user_id has no foreign key so entries outlive deleted users.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    at_ms       INTEGER NOT NULL,
+    user_id     INTEGER, -- NULL for changes made by the system (e.g. an unattended job)
+    action      TEXT NOT NULL, -- e.g. match.update, matches.delete_all, user.set_admin
+    entity      TEXT NOT NULL, -- match|user
+    entity_id   INTEGER,
+    before      TEXT, -- JSON, only the fields that changed
+    after       TEXT, -- JSON, only the fields that changed
+    request_id  TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at_ms);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log(user_id);
+
+-- The user who enqueued a job, so changes the job makes are attributed to them
+ALTER TABLE jobs ADD COLUMN user_id INTEGER;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_log_user;
+DROP INDEX IF EXISTS idx_audit_log_entity;
+DROP INDEX IF EXISTS idx_audit_log_at;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,16 @@
+
+-- +goose Up
+-- Previous states of a match, one row per change, so a single match can be rolled back
+CREATE TABLE IF NOT EXISTS match_versions (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    match_id       INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    version        INTEGER NOT NULL, -- 1, 2, … per match
+    data           TEXT NOT NULL,    -- JSON: the matches row and its periods before the change
+    action         TEXT NOT NULL,    -- the change that replaced this state (match.update, match.restore, …)
+    user_id        INTEGER,          -- who made that change
+    created_at_ms  INTEGER NOT NULL,
+    UNIQUE (match_id, version)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS match_versions;
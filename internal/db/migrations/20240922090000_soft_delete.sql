@@ -0,0 +1,11 @@
+
+-- +goose Up
+-- Deleted matches stay in the trash until they are restored or purged
+ALTER TABLE matches ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_matches_deleted_at ON matches(deleted_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_matches_deleted_at;
+-- SQLite DROP COLUMN is not universally supported in older versions.
+-- No-op down migration.
+SELECT 1;
@@ -0,0 +1,11 @@
+
+-- +goose Up
+-- version is bumped on every write and backs the ETag of a match
+ALTER TABLE matches ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE matches ADD COLUMN updated_at TIMESTAMP;
+UPDATE matches SET updated_at = strftime('%Y-%m-%d %H:%M:%S', 'now') || '+00:00';
+
+-- +goose Down
+-- SQLite DROP COLUMN is not universally supported in older versions.
+-- No-op down migration.
+SELECT 1;
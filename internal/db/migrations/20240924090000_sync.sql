@@ -0,0 +1,29 @@
+
+-- +goose Up
+-- Offline clients sync by change token: every write to a match stamps it
+-- with the next value of sync_state.seq
+ALTER TABLE matches ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE matches ADD COLUMN change_seq INTEGER NOT NULL DEFAULT 0;
+UPDATE matches SET created_at = updated_at, change_seq = id;
+CREATE INDEX IF NOT EXISTS idx_matches_change_seq ON matches(change_seq);
+
+CREATE TABLE IF NOT EXISTS sync_state (
+    id          INTEGER PRIMARY KEY,
+    seq         INTEGER NOT NULL DEFAULT 0,
+    purged_seq  INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO sync_state (id, seq) SELECT 1, COALESCE(MAX(id), 0) FROM matches;
+
+CREATE TABLE IF NOT EXISTS sync_uploads (
+    client_id   TEXT PRIMARY KEY,
+    match_id    INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS sync_uploads;
+DROP TABLE IF EXISTS sync_state;
+DROP INDEX IF EXISTS idx_matches_change_seq;
+-- SQLite DROP COLUMN is not universally supported in older versions.
+-- No-op down migration.
+SELECT 1;
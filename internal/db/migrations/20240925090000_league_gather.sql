@@ -0,0 +1,9 @@
+
+-- +goose Up
+-- Minutes before kick-off the team meets when a match has no gather time
+ALTER TABLE leagues ADD COLUMN gather_minutes INTEGER;
+
+-- +goose Down
+-- SQLite DROP COLUMN is not universally supported in older versions.
+-- No-op down migration.
+SELECT 1;
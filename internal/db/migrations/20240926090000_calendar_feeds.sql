@@ -0,0 +1,20 @@
+
+-- +goose Up
+-- Private iCal subscriptions; the secret token in the URL is stored hashed
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name          TEXT NOT NULL,
+    token_hash    TEXT NOT NULL UNIQUE,
+    team          TEXT,
+    league        TEXT,
+    side          TEXT,
+    alarms        TEXT,
+    gather_alarm  INTEGER NOT NULL DEFAULT 1,
+    created_at    TIMESTAMP NOT NULL,
+    last_used_at  TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_calendar_feeds_user ON calendar_feeds(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS calendar_feeds;
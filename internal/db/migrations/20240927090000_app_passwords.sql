@@ -0,0 +1,17 @@
+
+-- +goose Up
+-- Generated passwords for clients that cannot use the login form, such as
+-- CalDAV calendar apps; only a hash of the secret is stored
+CREATE TABLE IF NOT EXISTS app_passwords (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id        INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name           TEXT NOT NULL,
+    secret_hash    TEXT NOT NULL UNIQUE,
+    can_write      INTEGER NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+    last_used_at   TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_app_passwords_user ON app_passwords(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS app_passwords;
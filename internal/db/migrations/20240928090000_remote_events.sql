@@ -0,0 +1,15 @@
+
+-- +goose Up
+-- Matches mirrored to an external CalDAV calendar. match_id has no foreign
+-- key: the row must outlive a purged match so its remote event is removed.
+CREATE TABLE IF NOT EXISTS remote_events (
+    match_id   INTEGER PRIMARY KEY,
+    uid        TEXT NOT NULL,
+    href       TEXT NOT NULL UNIQUE,
+    etag       TEXT NOT NULL,    -- remote ETag at the last sync
+    version    INTEGER NOT NULL, -- match version at the last sync
+    synced_at  TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS remote_events;
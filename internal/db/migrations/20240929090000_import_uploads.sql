@@ -0,0 +1,13 @@
+
+-- +goose Up
+-- Uploaded schedules waiting for the import job, so the request only stores
+-- the file and the job payload only names it.
+CREATE TABLE IF NOT EXISTS import_uploads (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    filename    TEXT NOT NULL,
+    data        BLOB NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS import_uploads;
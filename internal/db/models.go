@@ -4,6 +4,71 @@
 
 package db
 
+import (
+	"time"
+)
+
+type BoardState struct {
+	MatchID        int64
+	Period         int64
+	ClockMs        int64
+	RunningSinceMs *int64
+	TokenHash      *string
+	UpdatedAt      time.Time
+}
+
+type CalendarFeed struct {
+	ID          int64
+	UserID      int64
+	Name        string
+	TokenHash   string
+	Team        *string
+	League      *string
+	Side        *string
+	Alarms      *string
+	GatherAlarm int64
+	CreatedAt   time.Time
+	LastUsedAt  *time.Time
+}
+
+type ImportUpload struct {
+	ID        int64
+	Filename  string
+	Data      []byte
+	CreatedAt time.Time
+}
+
+type MatchEvent struct {
+	ID             int64
+	MatchID        int64
+	Kind           string
+	Side           *string
+	Period         *int64
+	Clock          *string
+	Player         *string
+	Assist         *string
+	PenaltyMinutes *int64
+	Note           *string
+	CreatedAt      time.Time
+}
+
+type MatchPeriod struct {
+	MatchID      int64
+	Period       int64
+	GoalsFor     int64
+	GoalsAgainst int64
+}
+
+type MatchVersion struct {
+	ID          int64
+	MatchID     int64
+	Version     int64
+	Data        string
+	Action      string
+	UserID      *int64
+	CreatedAtMs int64
+}
+
 type Match struct {
 	ID                int64
 	StartIso          *string
@@ -31,4 +96,38 @@ type Match struct {
 	PlayerNotes       *string
 	TopScorerTeam     *string
 	TopScorerOpponent *string
+	Status            string
+	StatusReason      *string
+	RescheduledTo     *int64
+	Sequence          int64
+	OvertimeFor       *int64
+	OvertimeAgainst   *int64
+	ShootoutFor       *int64
+	ShootoutAgainst   *int64
+	DeletedAt         *time.Time
+	Version           int64
+	UpdatedAt         *time.Time
+	CreatedAt         *time.Time
+	ChangeSeq         int64
+}
+
+type RemoteEvent struct {
+	MatchID  int64
+	Uid      string
+	Href     string
+	Etag     string
+	Version  int64
+	SyncedAt time.Time
+}
+
+type SyncState struct {
+	ID        int64
+	Seq       int64
+	PurgedSeq int64
+}
+
+type SyncUpload struct {
+	ClientID  string
+	MatchID   int64
+	CreatedAt time.Time
 }
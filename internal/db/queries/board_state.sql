@@ -0,0 +1,17 @@
+-- name: GetBoardState :one
+SELECT * FROM board_state
+WHERE match_id = ?;
+
+-- name: UpsertBoardState :one
+INSERT INTO board_state (
+  match_id, period, clock_ms, running_since_ms, token_hash
+) VALUES (
+  ?, ?, ?, ?, ?
+)
+ON CONFLICT(match_id) DO UPDATE SET
+  period = excluded.period,
+  clock_ms = excluded.clock_ms,
+  running_since_ms = excluded.running_since_ms,
+  token_hash = excluded.token_hash,
+  updated_at = CURRENT_TIMESTAMP
+RETURNING *;
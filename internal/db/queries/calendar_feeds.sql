@@ -0,0 +1,41 @@
+-- name: CreateCalendarFeed :one
+INSERT INTO calendar_feeds (
+  user_id, name, token_hash, team, league, side, alarms, gather_alarm, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListCalendarFeeds :many
+SELECT * FROM calendar_feeds
+WHERE user_id = ?
+ORDER BY id;
+
+-- name: GetCalendarFeedByToken :one
+SELECT * FROM calendar_feeds
+WHERE token_hash = ?;
+
+-- name: UpdateCalendarFeed :one
+UPDATE calendar_feeds
+SET
+  name = ?,
+  team = ?,
+  league = ?,
+  side = ?,
+  alarms = ?,
+  gather_alarm = ?
+WHERE id = ? AND user_id = ?
+RETURNING *;
+
+-- name: SetCalendarFeedToken :one
+UPDATE calendar_feeds SET token_hash = ?
+WHERE id = ? AND user_id = ?
+RETURNING *;
+
+-- name: DeleteCalendarFeed :execrows
+DELETE FROM calendar_feeds
+WHERE id = ? AND user_id = ?;
+
+-- name: TouchCalendarFeed :exec
+UPDATE calendar_feeds SET last_used_at = ?
+WHERE id = ?;
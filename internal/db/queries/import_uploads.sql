@@ -0,0 +1,12 @@
+-- name: CreateImportUpload :one
+INSERT INTO import_uploads (filename, data)
+VALUES (?, ?)
+RETURNING id;
+
+-- name: GetImportUpload :one
+SELECT * FROM import_uploads
+WHERE id = ?;
+
+-- name: DeleteImportUpload :exec
+DELETE FROM import_uploads
+WHERE id = ?;
@@ -0,0 +1,15 @@
+-- name: ListMatchEvents :many
+SELECT * FROM match_events
+WHERE match_id = ?
+ORDER BY id;
+
+-- name: CreateMatchEvent :one
+INSERT INTO match_events (
+  match_id, kind, side, period, clock, player, assist, penalty_minutes, note
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: DeleteMatchEvent :execrows
+DELETE FROM match_events WHERE id = ? AND match_id = ?;
@@ -0,0 +1,18 @@
+-- name: ListMatchPeriods :many
+SELECT * FROM match_periods
+WHERE match_id = ?
+ORDER BY period;
+
+-- name: ListAllMatchPeriods :many
+SELECT * FROM match_periods
+ORDER BY match_id, period;
+
+-- name: CreateMatchPeriod :exec
+INSERT INTO match_periods (
+  match_id, period, goals_for, goals_against
+) VALUES (
+  ?, ?, ?, ?
+);
+
+-- name: DeleteMatchPeriods :exec
+DELETE FROM match_periods WHERE match_id = ?;
@@ -0,0 +1,20 @@
+-- name: CreateMatchVersion :one
+INSERT INTO match_versions (
+  match_id, version, data, action, user_id, created_at_ms
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: LatestMatchVersion :one
+SELECT COALESCE(MAX(version), 0) FROM match_versions
+WHERE match_id = ?;
+
+-- name: ListMatchVersions :many
+SELECT * FROM match_versions
+WHERE match_id = ?
+ORDER BY version DESC;
+
+-- name: GetMatchVersion :one
+SELECT * FROM match_versions
+WHERE match_id = ? AND version = ?;
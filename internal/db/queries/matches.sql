@@ -4,17 +4,24 @@ INSERT INTO matches (
   league, team, opponent, home_team, away_team, venue, court, city,
   gather_time, gather_place, match_number, referees, notes,
   played, goals_for, goals_against, player_notes,
-  top_scorer_team, top_scorer_opponent
+  top_scorer_team, top_scorer_opponent,
+  status, status_reason,
+  overtime_for, overtime_against, shootout_for, shootout_against,
+  updated_at, created_at, change_seq
 ) VALUES (
-  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+  ?, ?,
+  ?, ?, ?, ?,
+  ?, ?, ?
 )
 RETURNING *;
 
 -- name: GetMatch :one
-SELECT * FROM matches WHERE id = ?;
+SELECT * FROM matches WHERE id = ? AND deleted_at IS NULL;
 
 -- name: ListMatches :many
 SELECT * FROM matches
+WHERE deleted_at IS NULL
 ORDER BY (start_iso IS NULL), start_iso, id;
 
 -- name: UpdateMatch :one
@@ -44,12 +51,61 @@ SET
   goals_against = ?,
   player_notes = ?,
   top_scorer_team = ?,
-  top_scorer_opponent = ?
+  top_scorer_opponent = ?,
+  status = ?,
+  status_reason = ?,
+  rescheduled_to = ?,
+  sequence = ?,
+  overtime_for = ?,
+  overtime_against = ?,
+  shootout_for = ?,
+  shootout_against = ?,
+  updated_at = ?,
+  change_seq = ?,
+  version = version + 1
 WHERE id = ?
 RETURNING *;
 
--- name: DeleteMatch :exec
-DELETE FROM matches WHERE id = ?;
+-- name: SoftDeleteMatch :execrows
+UPDATE matches SET deleted_at = ?, updated_at = ?, change_seq = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NULL;
 
--- name: DeleteAllMatches :exec
-DELETE FROM matches;
+-- name: SoftDeleteAllMatches :execrows
+UPDATE matches SET deleted_at = ?, updated_at = ?, change_seq = ?, version = version + 1
+WHERE deleted_at IS NULL;
+
+-- name: ListDeletedMatches :many
+SELECT * FROM matches
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: RestoreDeletedMatch :one
+UPDATE matches SET deleted_at = NULL, updated_at = ?, change_seq = ?, version = version + 1
+WHERE id = ? AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeMatch :execrows
+DELETE FROM matches WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: PurgeDeletedMatches :execrows
+DELETE FROM matches WHERE deleted_at IS NOT NULL AND deleted_at <= ?;
+
+-- name: SetMatchScore :one
+UPDATE matches SET
+  goals_for = ?,
+  goals_against = ?,
+  overtime_for = ?,
+  overtime_against = ?,
+  updated_at = ?,
+  change_seq = ?,
+  version = version + 1
+WHERE id = ?
+RETURNING *;
+
+-- name: GetMatchByNumber :one
+SELECT * FROM matches WHERE match_number = ? AND deleted_at IS NULL ORDER BY id LIMIT 1;
+
+-- name: ListMatchesChangedSince :many
+SELECT * FROM matches
+WHERE change_seq > ?
+ORDER BY change_seq, id;
@@ -0,0 +1,20 @@
+-- name: ListRemoteEvents :many
+SELECT * FROM remote_events
+ORDER BY match_id;
+
+-- name: UpsertRemoteEvent :exec
+INSERT INTO remote_events (
+  match_id, uid, href, etag, version, synced_at
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(match_id) DO UPDATE SET
+  uid = excluded.uid,
+  href = excluded.href,
+  etag = excluded.etag,
+  version = excluded.version,
+  synced_at = excluded.synced_at;
+
+-- name: DeleteRemoteEvent :exec
+DELETE FROM remote_events
+WHERE match_id = ?;
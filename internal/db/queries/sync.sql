@@ -0,0 +1,19 @@
+-- name: NextChangeSeq :one
+UPDATE sync_state SET seq = seq + 1 WHERE id = 1
+RETURNING seq;
+
+-- name: GetSyncState :one
+SELECT * FROM sync_state WHERE id = 1;
+
+-- name: MarkSyncPurged :exec
+UPDATE sync_state SET purged_seq = seq WHERE id = 1;
+
+-- name: GetSyncUpload :one
+SELECT * FROM sync_uploads WHERE client_id = ?;
+
+-- name: CreateSyncUpload :exec
+INSERT INTO sync_uploads (
+  client_id, match_id, created_at
+) VALUES (
+  ?, ?, ?
+);
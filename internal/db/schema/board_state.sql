@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS board_state (
+    match_id          INTEGER PRIMARY KEY REFERENCES matches(id) ON DELETE CASCADE,
+    period            INTEGER NOT NULL DEFAULT 1,
+    clock_ms          INTEGER NOT NULL DEFAULT 0, -- elapsed period time when the clock last stopped
+    running_since_ms  INTEGER,                    -- unix ms when the clock was started; NULL = stopped
+    token_hash        TEXT,                       -- sha256 of the scorekeeper's control token
+    updated_at        TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name          TEXT NOT NULL,
+    token_hash    TEXT NOT NULL UNIQUE, -- sha256 of the secret in the feed URL
+    team          TEXT,                 -- filters; NULL = any
+    league        TEXT,
+    side          TEXT,                 -- home|away
+    alarms        TEXT,                 -- minutes before kick-off, comma-separated, e.g. "1440,60"
+    gather_alarm  INTEGER NOT NULL DEFAULT 1, -- 0/1: alarm at the gather time
+    created_at    TIMESTAMP NOT NULL,
+    last_used_at  TIMESTAMP
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS import_uploads (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    filename    TEXT NOT NULL,
+    data        BLOB NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS match_events (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    match_id    INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL, -- goal|penalty|timeout|period_start|period_end|match_end
+    side        TEXT,          -- us|them
+    period      INTEGER,       -- 1-based; above the league's periods = overtime
+    clock       TEXT,          -- game clock, e.g. "12:34"
+    player      TEXT,
+    assist      TEXT,
+    penalty_minutes INTEGER,
+    note        TEXT,
+    created_at  TIMESTAMP NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
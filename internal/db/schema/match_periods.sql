@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS match_periods (
+    match_id       INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    period         INTEGER NOT NULL, -- 1-based
+    goals_for      INTEGER NOT NULL,
+    goals_against  INTEGER NOT NULL,
+    PRIMARY KEY (match_id, period)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS match_versions (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    match_id       INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    version        INTEGER NOT NULL, -- 1, 2, … per match
+    data           TEXT NOT NULL,    -- JSON: the matches row and its periods before the change
+    action         TEXT NOT NULL,    -- the change that replaced this state (match.update, match.restore, …)
+    user_id        INTEGER,          -- who made that change
+    created_at_ms  INTEGER NOT NULL,
+    UNIQUE (match_id, version)
+);
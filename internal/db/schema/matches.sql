@@ -24,5 +24,18 @@ CREATE TABLE IF NOT EXISTS matches (
     goals_against  INTEGER,
     player_notes   TEXT,
     top_scorer_team TEXT,
-    top_scorer_opponent TEXT
+    top_scorer_opponent TEXT,
+    status         TEXT NOT NULL DEFAULT 'scheduled', -- scheduled|postponed|cancelled|played|walkover
+    status_reason  TEXT,
+    rescheduled_to INTEGER REFERENCES matches(id) ON DELETE SET NULL,
+    sequence       INTEGER NOT NULL DEFAULT 0, -- iCal SEQUENCE, bumped on significant changes
+    overtime_for     INTEGER, -- goals in overtime (included in goals_for)
+    overtime_against INTEGER,
+    shootout_for     INTEGER, -- penalty shootout (not included in goals_for)
+    shootout_against INTEGER,
+    deleted_at     TIMESTAMP, -- set while the match is in the trash
+    version        INTEGER NOT NULL DEFAULT 1, -- bumped on every write; the ETag
+    updated_at     TIMESTAMP,
+    created_at     TIMESTAMP,
+    change_seq     INTEGER NOT NULL DEFAULT 0 -- sync_state.seq at the last write; tombstones keep theirs
 );
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS remote_events (
+    match_id   INTEGER PRIMARY KEY,
+    uid        TEXT NOT NULL,
+    href       TEXT NOT NULL UNIQUE,
+    etag       TEXT NOT NULL,    -- remote ETag at the last sync
+    version    INTEGER NOT NULL, -- match version at the last sync
+    synced_at  TIMESTAMP NOT NULL
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS sync_state (
+    id          INTEGER PRIMARY KEY,        -- always 1
+    seq         INTEGER NOT NULL DEFAULT 0, -- last change token handed out
+    purged_seq  INTEGER NOT NULL DEFAULT 0  -- token at the last purge; older tokens need a full sync
+);
+
+CREATE TABLE IF NOT EXISTS sync_uploads (
+    client_id   TEXT PRIMARY KEY,  -- chosen by the client, so a retried create is applied once
+    match_id    INTEGER NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    created_at  TIMESTAMP NOT NULL
+);
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func errStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// RegisterAdminRoutes mounts /api/admin/jobs behind the given admin middleware.
+func RegisterAdminRoutes(r *gin.Engine, q *Queue, admin gin.HandlerFunc) {
+	g := r.Group("/api/admin/jobs")
+	g.Use(admin)
+
+	g.GET("", func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		list, err := q.List(c.Request.Context(), Filter{Status: c.Query("status"), Type: c.Query("type"), Limit: limit})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		j, err := q.Get(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, j)
+	})
+
+	// Only failed or cancelled jobs can be retried
+	g.POST("/:id/retry", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err := q.Retry(c.Request.Context(), id); err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		j, _ := q.Get(c.Request.Context(), id)
+		c.JSON(http.StatusOK, j)
+	})
+
+	// Only pending jobs can be cancelled
+	g.POST("/:id/cancel", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err := q.Cancel(c.Request.Context(), id); err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		j, _ := q.Get(c.Request.Context(), id)
+		c.JSON(http.StatusOK, j)
+	})
+}
@@ -0,0 +1,558 @@
+// Package jobs is a small durable work queue backed by the jobs table.
+//
+// Work is enqueued as a type plus a JSON payload and picked up by worker
+// goroutines. A running job holds a lease (locked_until) that its worker
+// renews while the handler runs; if the process dies the lease runs out and
+// another worker picks the job up again, unless its attempts are used up, so
+// handlers must tolerate running more than once.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/audit"
+	"github.com/xaitan80/X-Matches/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/xaitan80/X-Matches/internal/jobs")
+
+// Job states.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	ErrNotFound = errors.New("job not found")
+	ErrConflict = errors.New("job cannot change state")
+
+	errLeaseLost = errors.New("job lease was lost to another worker")
+)
+
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	RunAt       time.Time       `json:"run_at"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+
+	traceContext string // propagation headers of the enqueuing span, as JSON
+	userID       *int64 // who enqueued it; the job's changes are audited as theirs
+}
+
+// Decode unmarshals the payload into v.
+func (j Job) Decode(v any) error { return json.Unmarshal(j.Payload, v) }
+
+// Handler runs a job. A non-nil result is stored as JSON on the job.
+type Handler func(ctx context.Context, job Job) (any, error)
+
+// permanentError stops retries.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the job fails immediately.
+func Permanent(err error) error { return permanentError{err} }
+
+// retryError asks for the next attempt at a given time.
+type retryError struct {
+	at  time.Time
+	err error
+}
+
+func (e retryError) Error() string { return e.err.Error() }
+func (e retryError) Unwrap() error { return e.err }
+
+// RetryAt retries the job at t instead of after the queue's backoff.
+func RetryAt(t time.Time, err error) error { return retryError{t, err} }
+
+// Option tweaks an enqueued job.
+type Option func(*options)
+
+type options struct {
+	runAt       time.Time
+	maxAttempts int
+}
+
+// At delays the job until t.
+func At(t time.Time) Option { return func(o *options) { o.runAt = t } }
+
+// MaxAttempts overrides the queue default for one job.
+func MaxAttempts(n int) Option { return func(o *options) { o.maxAttempts = n } }
+
+type Queue struct {
+	db  *sql.DB
+	now func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	MaxAttempts  int           // default attempts before a job fails
+	BaseBackoff  time.Duration // wait after the first failure; doubles per attempt
+	MaxBackoff   time.Duration
+	Lease        time.Duration // how long a running job is locked without a heartbeat
+	PollInterval time.Duration
+
+	wake chan struct{}
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func New(db *sql.DB) *Queue {
+	return &Queue{
+		db:           db,
+		now:          time.Now,
+		handlers:     map[string]Handler{},
+		MaxAttempts:  5,
+		BaseBackoff:  10 * time.Second,
+		MaxBackoff:   time.Hour,
+		Lease:        5 * time.Minute,
+		PollInterval: time.Second,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler for a job type.
+func (q *Queue) Handle(typ string, h Handler) {
+	q.mu.Lock()
+	q.handlers[typ] = h
+	q.mu.Unlock()
+}
+
+func (q *Queue) handler(typ string) Handler {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[typ]
+}
+
+// backoff returns the wait before retrying after the given number of attempts.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.BaseBackoff
+	for i := 1; i < attempts && d < q.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.MaxBackoff)
+}
+
+// Enqueue stores a job; payload is marshalled to JSON.
+func (q *Queue) Enqueue(ctx context.Context, typ string, payload any, opts ...Option) (int64, error) {
+	o := options{runAt: q.now(), maxAttempts: q.MaxAttempts}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	var traceContext sql.NullString
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		b, _ := json.Marshal(carrier)
+		traceContext = sql.NullString{String: string(b), Valid: true}
+	}
+	var user any
+	if uid, ok := audit.Actor(ctx); ok {
+		user = uid
+	}
+	var id int64
+	err = q.db.QueryRowContext(ctx,
+		`INSERT INTO jobs (type, payload, run_at_ms, max_attempts, trace_context, user_id) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+		typ, string(body), o.runAt.UnixMilli(), o.maxAttempts, traceContext, user,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	q.notify()
+	return id, nil
+}
+
+// notify wakes an idle worker.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+const jobColumns = `id, type, payload, status, run_at_ms, attempts, max_attempts, last_error, result,
+	locked_until_ms, created_at, updated_at, finished_at, trace_context, user_id`
+
+func scanJob(row interface{ Scan(...any) error }) (Job, error) {
+	var (
+		j               Job
+		payload         string
+		runAt           int64
+		lastErr, result sql.NullString
+		traceContext    sql.NullString
+		locked          sql.NullInt64
+		finished        sql.NullTime
+	)
+	err := row.Scan(&j.ID, &j.Type, &payload, &j.Status, &runAt, &j.Attempts, &j.MaxAttempts,
+		&lastErr, &result, &locked, &j.CreatedAt, &j.UpdatedAt, &finished, &traceContext, &j.userID)
+	if err != nil {
+		return Job{}, err
+	}
+	j.Payload = json.RawMessage(payload)
+	j.RunAt = time.UnixMilli(runAt).UTC()
+	j.LastError = lastErr.String
+	j.traceContext = traceContext.String
+	if result.Valid {
+		j.Result = json.RawMessage(result.String)
+	}
+	if locked.Valid {
+		t := time.UnixMilli(locked.Int64).UTC()
+		j.LockedUntil = &t
+	}
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+	return j, nil
+}
+
+func (q *Queue) Get(ctx context.Context, id int64) (Job, error) {
+	j, err := scanJob(q.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	return j, err
+}
+
+// Filter narrows List; empty fields match everything.
+type Filter struct {
+	Status string
+	Type   string
+	Limit  int
+}
+
+// List returns jobs, newest first.
+func (q *Queue) List(ctx context.Context, f Filter) ([]Job, error) {
+	var where []string
+	var args []any
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Type != "" {
+		where = append(where, "type = ?")
+		args = append(args, f.Type)
+	}
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, f.Limit)
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// Retry queues a failed or cancelled job again with fresh attempts.
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	return q.transition(ctx, id,
+		`UPDATE jobs SET status = 'pending', attempts = 0, run_at_ms = ?, locked_until_ms = NULL,
+		 finished_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND status IN ('failed', 'cancelled')`,
+		q.now().UnixMilli(), id)
+}
+
+// Cancel stops a job that has not started yet.
+func (q *Queue) Cancel(ctx context.Context, id int64) error {
+	return q.transition(ctx, id,
+		`UPDATE jobs SET status = 'cancelled', finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ? AND status = 'pending'`,
+		id)
+}
+
+func (q *Queue) transition(ctx context.Context, id int64, query string, args ...any) error {
+	res, err := q.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := q.Get(ctx, id); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	q.notify()
+	return nil
+}
+
+// claim locks the next due job, or returns sql.ErrNoRows. A job whose lease
+// ran out is taken up again only if it has attempts left; otherwise it fails,
+// so a job limited to one attempt never runs twice.
+func (q *Queue) claim(ctx context.Context) (Job, error) {
+	now := q.now().UnixMilli()
+	_, err := q.db.ExecContext(ctx,
+		`UPDATE jobs SET status = 'failed', last_error = 'lease expired on the last attempt', locked_until_ms = NULL,
+		 finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		 WHERE status = 'running' AND locked_until_ms < ? AND attempts >= max_attempts`,
+		now)
+	if err != nil {
+		return Job{}, err
+	}
+	return scanJob(q.db.QueryRowContext(ctx,
+		`UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_until_ms = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = (
+		   SELECT id FROM jobs
+		   WHERE (status = 'pending' AND run_at_ms <= ?) OR (status = 'running' AND locked_until_ms < ? AND attempts < max_attempts)
+		   ORDER BY run_at_ms, id LIMIT 1
+		 )
+		 RETURNING `+jobColumns,
+		now+q.Lease.Milliseconds(), now, now,
+	))
+}
+
+// heartbeat renews j's lease every third of Lease while its handler runs, so
+// a slow job such as a big import is not taken for abandoned. If another
+// worker has taken the job after all, cancel stops the handler. stop ends
+// the heartbeat and returns the lease this worker holds.
+func (q *Queue) heartbeat(ctx context.Context, j Job, cancel context.CancelFunc) (stop func() int64) {
+	held := j.LockedUntil.UnixMilli()
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(max(q.Lease/3, time.Millisecond))
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+			}
+			next := q.now().Add(q.Lease).UnixMilli()
+			res, err := q.db.ExecContext(ctx,
+				`UPDATE jobs SET locked_until_ms = ? WHERE id = ? AND status = 'running' AND locked_until_ms = ?`,
+				next, j.ID, held)
+			if err != nil {
+				slog.WarnContext(ctx, "jobs: renew lease", "err", err)
+				continue
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				slog.WarnContext(ctx, "jobs: lease lost")
+				cancel()
+				return
+			}
+			held = next
+		}
+	}()
+	return func() int64 {
+		close(done)
+		<-exited
+		return held
+	}
+}
+
+// RunNext claims and runs one due job. It reports false when nothing was due.
+func (q *Queue) RunNext(ctx context.Context) (bool, error) {
+	j, err := q.claim(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	ctx, span := q.startSpan(ctx, j)
+	defer span.End()
+	ctx = logging.With(logging.With(ctx, "job_id", j.ID), "job_type", j.Type)
+	if j.userID != nil {
+		ctx = audit.WithActor(logging.With(ctx, "user_id", *j.userID), *j.userID)
+	}
+	var result any
+	h := q.handler(j.Type)
+	if h == nil {
+		err = Permanent(fmt.Errorf("no handler for job type %q", j.Type))
+	} else {
+		hctx, cancel := context.WithCancel(ctx)
+		stop := q.heartbeat(ctx, j, cancel)
+		result, err = run(hctx, h, j)
+		t := time.UnixMilli(stop()).UTC()
+		j.LockedUntil = &t
+		cancel()
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return true, q.finish(ctx, j, result, err)
+}
+
+// startSpan starts a new trace for one attempt of j, linked to the span that
+// enqueued it. A link rather than a parent keeps retries hours later from
+// stretching the request's trace.
+func (q *Queue) startSpan(ctx context.Context, j Job) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int64("job.id", j.ID),
+			attribute.String("job.type", j.Type),
+			attribute.Int("job.attempt", j.Attempts),
+		),
+	}
+	if j.traceContext != "" {
+		var carrier propagation.MapCarrier
+		if json.Unmarshal([]byte(j.traceContext), &carrier) == nil {
+			parent := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+			if sc := trace.SpanContextFromContext(parent); sc.IsValid() {
+				opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+			}
+		}
+	}
+	return tracer.Start(ctx, "job "+j.Type, opts...)
+}
+
+// run calls h, turning a panic into an error so one bad job cannot stop a worker.
+func run(ctx context.Context, h Handler, j Job) (result any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h(ctx, j)
+}
+
+// finish records the outcome of j's attempt, as long as this worker still
+// holds the lease it claimed the job with.
+func (q *Queue) finish(ctx context.Context, j Job, result any, runErr error) error {
+	const held = ` WHERE id = ? AND status = 'running' AND locked_until_ms = ?`
+	lease := j.LockedUntil.UnixMilli()
+	var (
+		res sql.Result
+		err error
+	)
+	var perm permanentError
+	switch {
+	case runErr == nil:
+		var out any
+		if result != nil {
+			b, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			out = string(b)
+		}
+		res, err = q.db.ExecContext(ctx,
+			`UPDATE jobs SET status = 'done', result = ?, last_error = NULL, locked_until_ms = NULL,
+			 finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP`+held,
+			out, j.ID, lease)
+	case errors.As(runErr, &perm) || j.Attempts >= j.MaxAttempts:
+		res, err = q.db.ExecContext(ctx,
+			`UPDATE jobs SET status = 'failed', last_error = ?, locked_until_ms = NULL,
+			 finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP`+held,
+			runErr.Error(), j.ID, lease)
+	default:
+		next := q.now().Add(q.backoff(j.Attempts))
+		var retry retryError
+		if errors.As(runErr, &retry) {
+			next = retry.at
+		}
+		res, err = q.db.ExecContext(ctx,
+			`UPDATE jobs SET status = 'pending', last_error = ?, run_at_ms = ?, locked_until_ms = NULL,
+			 updated_at = CURRENT_TIMESTAMP`+held,
+			runErr.Error(), next.UnixMilli(), j.ID, lease)
+	}
+	if err != nil {
+		return err
+	}
+	// Taken over by another worker, which records its own outcome
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("job %d: %w", j.ID, errLeaseLost)
+	}
+	return nil
+}
+
+// Start launches n workers. Jobs run with a context that is not cancelled by
+// Stop, so a job that has started gets to finish.
+func (q *Queue) Start(n int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.stop = cancel
+	for range n {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+	t := time.NewTicker(q.PollInterval)
+	defer t.Stop()
+	for {
+		// Drain everything that is due before sleeping
+		for ctx.Err() == nil {
+			ran, err := q.RunNext(context.WithoutCancel(ctx))
+			if err != nil {
+				slog.Error("jobs: run", "err", err)
+				break
+			}
+			if !ran {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-t.C:
+		}
+	}
+}
+
+// Stop stops claiming new jobs and waits for running ones until ctx is done.
+// Jobs still running after that keep their lease and are retried after a restart.
+func (q *Queue) Stop(ctx context.Context) error {
+	if q.stop != nil {
+		q.stop()
+	}
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
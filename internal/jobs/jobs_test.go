@@ -0,0 +1,365 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	_ "modernc.org/sqlite"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+func newTestQueue(t *testing.T) (*Queue, *time.Time) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	q := New(db)
+	now := time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+func TestQueue_RunsJobAndStoresResult(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+	q.Handle("add", func(ctx context.Context, j Job) (any, error) {
+		var p struct{ A, B int }
+		if err := j.Decode(&p); err != nil {
+			return nil, err
+		}
+		return map[string]int{"sum": p.A + p.B}, nil
+	})
+
+	id, err := q.Enqueue(ctx, "add", map[string]int{"A": 2, "B": 3})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if ran, err := q.RunNext(ctx); !ran || err != nil {
+		t.Fatalf("expected a job to run: ran=%v err=%v", ran, err)
+	}
+	if ran, _ := q.RunNext(ctx); ran {
+		t.Fatal("expected the queue to be empty")
+	}
+	j, err := q.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if j.Status != StatusDone || j.Attempts != 1 || string(j.Result) != `{"sum":5}` || j.FinishedAt == nil {
+		t.Fatalf("unexpected job: %+v (result=%s)", j, j.Result)
+	}
+}
+
+func TestQueue_RetriesWithBackoffThenFails(t *testing.T) {
+	q, now := newTestQueue(t)
+	ctx := context.Background()
+	var calls atomic.Int32
+	q.Handle("flaky", func(ctx context.Context, j Job) (any, error) {
+		calls.Add(1)
+		return nil, errors.New("boom")
+	})
+
+	id, _ := q.Enqueue(ctx, "flaky", nil, MaxAttempts(3))
+	q.RunNext(ctx)
+	j, _ := q.Get(ctx, id)
+	if j.Status != StatusPending || j.LastError != "boom" || !j.RunAt.Equal(now.Add(10*time.Second)) {
+		t.Fatalf("expected retry in 10s: %+v", j)
+	}
+
+	// Not due yet
+	if ran, _ := q.RunNext(ctx); ran {
+		t.Fatal("expected nothing due")
+	}
+	*now = now.Add(10 * time.Second)
+	q.RunNext(ctx)
+	j, _ = q.Get(ctx, id)
+	if !j.RunAt.Equal(now.Add(20 * time.Second)) {
+		t.Fatalf("expected backoff to double: %+v", j)
+	}
+	*now = now.Add(20 * time.Second)
+	q.RunNext(ctx)
+	j, _ = q.Get(ctx, id)
+	if j.Status != StatusFailed || j.Attempts != 3 || calls.Load() != 3 {
+		t.Fatalf("expected failed after 3 attempts: %+v (calls=%d)", j, calls.Load())
+	}
+}
+
+func TestQueue_PermanentAndRetryAt(t *testing.T) {
+	q, now := newTestQueue(t)
+	ctx := context.Background()
+	q.Handle("bad", func(ctx context.Context, j Job) (any, error) {
+		return nil, Permanent(errors.New("bad payload"))
+	})
+	later := now.Add(time.Hour)
+	q.Handle("later", func(ctx context.Context, j Job) (any, error) {
+		return nil, RetryAt(later, errors.New("not yet"))
+	})
+	q.Handle("panics", func(ctx context.Context, j Job) (any, error) {
+		panic("oops")
+	})
+
+	bad, _ := q.Enqueue(ctx, "bad", nil)
+	q.RunNext(ctx)
+	if j, _ := q.Get(ctx, bad); j.Status != StatusFailed || j.Attempts != 1 {
+		t.Fatalf("expected permanent failure: %+v", j)
+	}
+
+	id, _ := q.Enqueue(ctx, "later", nil)
+	q.RunNext(ctx)
+	if j, _ := q.Get(ctx, id); j.Status != StatusPending || !j.RunAt.Equal(later) {
+		t.Fatalf("expected retry at the requested time: %+v", j)
+	}
+
+	p, _ := q.Enqueue(ctx, "panics", nil, MaxAttempts(1))
+	if _, err := q.RunNext(ctx); err != nil {
+		t.Fatalf("a panicking handler must not surface as a queue error: %v", err)
+	}
+	if j, _ := q.Get(ctx, p); j.Status != StatusFailed || j.LastError != "panic: oops" {
+		t.Fatalf("expected the panic to fail the job: %+v", j)
+	}
+
+	unknown, _ := q.Enqueue(ctx, "nobody", nil)
+	q.RunNext(ctx)
+	if j, _ := q.Get(ctx, unknown); j.Status != StatusFailed {
+		t.Fatalf("expected a job without handler to fail: %+v", j)
+	}
+}
+
+func TestQueue_ExpiredLeaseIsReclaimed(t *testing.T) {
+	q, now := newTestQueue(t)
+	ctx := context.Background()
+	id, _ := q.Enqueue(ctx, "slow", nil)
+
+	// Simulate a worker that died mid-job
+	if _, err := q.claim(ctx); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if _, err := q.claim(ctx); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("a leased job must not be claimed twice: %v", err)
+	}
+	*now = now.Add(q.Lease + time.Second)
+	q.Handle("slow", func(ctx context.Context, j Job) (any, error) { return nil, nil })
+	q.RunNext(ctx)
+	if j, _ := q.Get(ctx, id); j.Status != StatusDone || j.Attempts != 2 {
+		t.Fatalf("expected the job to be picked up again: %+v", j)
+	}
+}
+
+func TestQueue_ExpiredLeaseOnLastAttemptFails(t *testing.T) {
+	q, now := newTestQueue(t)
+	ctx := context.Background()
+	var runs atomic.Int32
+	q.Handle("import", func(ctx context.Context, j Job) (any, error) { runs.Add(1); return nil, nil })
+	id, _ := q.Enqueue(ctx, "import", nil, MaxAttempts(1))
+
+	// A worker that died, or stalled, on the only attempt
+	stale, err := q.claim(ctx)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	*now = now.Add(q.Lease + time.Second)
+	if ran, err := q.RunNext(ctx); ran || err != nil {
+		t.Fatalf("a job without attempts left must not run again: ran=%v err=%v", ran, err)
+	}
+	if j, _ := q.Get(ctx, id); j.Status != StatusFailed || runs.Load() != 0 {
+		t.Fatalf("expected the job to fail: %+v", j)
+	}
+	if err := q.finish(ctx, stale, nil, nil); !errors.Is(err, errLeaseLost) {
+		t.Fatalf("the stale worker must not record its outcome: %v", err)
+	}
+	if j, _ := q.Get(ctx, id); j.Status != StatusFailed {
+		t.Fatalf("the failure was overwritten: %+v", j)
+	}
+}
+
+func TestQueue_HeartbeatKeepsSlowJob(t *testing.T) {
+	q, _ := newTestQueue(t)
+	q.now = time.Now
+	q.Lease = 150 * time.Millisecond
+	ctx := context.Background()
+	var runs atomic.Int32
+	started := make(chan struct{})
+	q.Handle("slow", func(ctx context.Context, j Job) (any, error) {
+		if runs.Add(1) == 1 {
+			close(started)
+		}
+		select {
+		case <-time.After(4 * q.Lease):
+			return "ok", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	id, _ := q.Enqueue(ctx, "slow", nil)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := q.RunNext(ctx)
+		first <- err
+	}()
+	<-started
+	// A second worker polls while the first is still inside the handler
+	deadline := time.Now().Add(5 * q.Lease)
+	for time.Now().Before(deadline) {
+		if _, err := q.RunNext(ctx); err != nil {
+			t.Fatalf("second worker: %v", err)
+		}
+		time.Sleep(q.Lease / 5)
+	}
+	if err := <-first; err != nil {
+		t.Fatalf("first worker: %v", err)
+	}
+	if j, _ := q.Get(ctx, id); runs.Load() != 1 || j.Status != StatusDone || j.Attempts != 1 {
+		t.Fatalf("expected one run past the lease: runs=%d %+v", runs.Load(), j)
+	}
+}
+
+func TestQueue_RetryAndCancel(t *testing.T) {
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+	q.Handle("fail", func(ctx context.Context, j Job) (any, error) { return nil, Permanent(errors.New("no")) })
+
+	id, _ := q.Enqueue(ctx, "fail", nil)
+	if err := q.Retry(ctx, id); !errors.Is(err, ErrConflict) {
+		t.Fatalf("retrying a pending job must conflict: %v", err)
+	}
+	q.RunNext(ctx)
+	if err := q.Cancel(ctx, id); !errors.Is(err, ErrConflict) {
+		t.Fatalf("cancelling a failed job must conflict: %v", err)
+	}
+	if err := q.Retry(ctx, id); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if j, _ := q.Get(ctx, id); j.Status != StatusPending || j.Attempts != 0 {
+		t.Fatalf("expected a fresh pending job: %+v", j)
+	}
+	if err := q.Cancel(ctx, id); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if ran, _ := q.RunNext(ctx); ran {
+		t.Fatal("a cancelled job must not run")
+	}
+	if err := q.Cancel(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found: %v", err)
+	}
+
+	list, err := q.List(ctx, Filter{Status: StatusCancelled})
+	if err != nil || len(list) != 1 || list[0].ID != id {
+		t.Fatalf("expected the cancelled job in the list: %+v %v", list, err)
+	}
+}
+
+func TestQueue_JobSpanLinksToEnqueuer(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	q, _ := newTestQueue(t)
+	q.Handle("traced", func(ctx context.Context, j Job) (any, error) { return nil, errors.New("nope") })
+
+	ctx, req := otel.Tracer("test").Start(context.Background(), "request")
+	q.Enqueue(ctx, "traced", nil)
+	req.End()
+	q.RunNext(context.Background())
+
+	var job sdktrace.ReadOnlySpan
+	for _, s := range rec.Ended() {
+		if s.Name() == "job traced" {
+			job = s
+		}
+	}
+	if job == nil {
+		t.Fatalf("expected a job span, got %d spans", len(rec.Ended()))
+	}
+	if job.Parent().IsValid() {
+		t.Fatal("a job must start its own trace")
+	}
+	if len(job.Links()) != 1 || job.Links()[0].SpanContext.SpanID() != req.SpanContext().SpanID() {
+		t.Fatalf("expected a link to the enqueuing span: %+v", job.Links())
+	}
+	if job.Status().Description != "nope" {
+		t.Fatalf("expected the error on the span: %+v", job.Status())
+	}
+}
+
+func TestQueue_StartAndStop(t *testing.T) {
+	q, _ := newTestQueue(t)
+	q.now = time.Now
+	q.PollInterval = 10 * time.Millisecond
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	q.Handle("wait", func(ctx context.Context, j Job) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	id, _ := q.Enqueue(ctx, "wait", nil)
+	q.Start(2)
+	<-started
+
+	// Stop waits for the running job
+	stopped := make(chan error, 1)
+	go func() { stopped <- q.Stop(ctx) }()
+	select {
+	case <-stopped:
+		t.Fatal("stop returned while a job was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if j, _ := q.Get(ctx, id); j.Status != StatusDone {
+		t.Fatalf("expected the running job to finish: %+v", j)
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	q, _ := newTestQueue(t)
+	ctx := context.Background()
+	r := gin.New()
+	RegisterAdminRoutes(r, q, func(c *gin.Context) { c.Next() })
+
+	id, _ := q.Enqueue(ctx, "x", nil)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	if w := do(http.MethodGet, "/api/admin/jobs?status=pending"); w.Code != http.StatusOK {
+		t.Fatalf("list: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/admin/jobs/999/cancel"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	path := "/api/admin/jobs/" + strconv.FormatInt(id, 10)
+	if w := do(http.MethodPost, path+"/retry"); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for retrying a pending job, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, path+"/cancel"); w.Code != http.StatusOK {
+		t.Fatalf("cancel: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, path+"/retry"); w.Code != http.StatusOK {
+		t.Fatalf("retry: %d %s", w.Code, w.Body.String())
+	}
+}
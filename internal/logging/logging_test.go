@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func capture(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(New(&buf, "json", "debug"))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(l), &m); err != nil {
+			t.Fatalf("not json: %q", l)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestWith_AddsAndReplacesFields(t *testing.T) {
+	buf := capture(t)
+	ctx := With(context.Background(), "request_id", "abc")
+	ctx = With(ctx, "match_id", 1)
+	ctx = With(ctx, "match_id", 2)
+	slog.InfoContext(ctx, "hello")
+
+	got := lines(t, buf)[0]
+	if got["request_id"] != "abc" || got["match_id"] != float64(2) || got["msg"] != "hello" {
+		t.Fatalf("unexpected record %v", got)
+	}
+	if n := strings.Count(buf.String(), "match_id"); n != 1 {
+		t.Fatalf("expected match_id once, got %d times", n)
+	}
+}
+
+func TestContextHandler_AddsTraceIDs(t *testing.T) {
+	buf := capture(t)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	})
+	slog.InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "traced")
+	slog.InfoContext(context.Background(), "untraced")
+
+	got := lines(t, buf)
+	if got[0]["trace_id"] != sc.TraceID().String() || got[0]["span_id"] != sc.SpanID().String() {
+		t.Fatalf("expected trace ids: %v", got[0])
+	}
+	if _, ok := got[1]["trace_id"]; ok {
+		t.Fatalf("unexpected trace id without a span: %v", got[1])
+	}
+}
+
+func TestRequestID_HonoursOrSetsHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buf := capture(t)
+	r := gin.New()
+	r.Use(RequestID(), AccessLog())
+	r.GET("/x", func(c *gin.Context) {
+		// Later handlers such as auth add fields the access log picks up
+		c.Request = c.Request.WithContext(With(c.Request.Context(), "user_id", 7))
+		c.Status(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set(RequestIDHeader, "from-proxy-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(RequestIDHeader); got != "from-proxy-1" {
+		t.Fatalf("expected the caller's id to be kept, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	generated := w.Header().Get(RequestIDHeader)
+	if len(generated) != 24 {
+		t.Fatalf("expected a generated id, got %q", generated)
+	}
+
+	got := lines(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("expected one access log line per request, got %d", len(got))
+	}
+	first := got[0]
+	if first["request_id"] != "from-proxy-1" || first["user_id"] != float64(7) || first["route"] != "/x" ||
+		first["status"] != float64(http.StatusTeapot) || first["level"] != "WARN" {
+		t.Fatalf("unexpected access log %v", first)
+	}
+	if got[1]["request_id"] != generated {
+		t.Fatalf("expected the generated id in the log, got %v", got[1])
+	}
+}
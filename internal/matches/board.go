@@ -0,0 +1,328 @@
+package matches
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// ChangeBoard is published when the scoreboard clock or period changes.
+const ChangeBoard = "board.updated"
+
+var (
+	ErrInvalidAction = errors.New("invalid board action")
+	ErrBoardToken    = errors.New("invalid board token")
+)
+
+// boardNow is the clock used for the scoreboard; replaced in tests.
+var boardNow = time.Now
+
+// Board is what the public scoreboard shows for one match.
+type Board struct {
+	MatchID       int64 `json:"match_id"`
+	Period        int64 `json:"period"`
+	PeriodMinutes int   `json:"period_minutes"` // 0 = count up without a limit
+	ClockMs       int64 `json:"clock_ms"`       // elapsed time in the current period
+	Running       bool  `json:"running"`
+	ServerTimeMs  int64 `json:"server_time_ms"` // lets clients tick the clock locally
+	Match         Match `json:"match"`
+}
+
+// BoardAction is a control command from the scorekeeper.
+type BoardAction struct {
+	Action  string `json:"action"` // start|stop|set_clock|set_period|next_period|goal
+	ClockMs int64  `json:"clock_ms"`
+	Period  int64  `json:"period"`
+	Side    string `json:"side"`
+	Player  string `json:"player"`
+	Assist  string `json:"assist"`
+}
+
+// hashToken is how board and calendar feed tokens are stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// elapsed returns the clock reading of st at now.
+func elapsed(st dbpkg.BoardState, now time.Time) int64 {
+	ms := st.ClockMs
+	if st.RunningSinceMs != nil {
+		ms += now.UnixMilli() - *st.RunningSinceMs
+	}
+	return ms
+}
+
+func (r *Repository) boardState(ctx context.Context, q *dbpkg.Queries, matchID int64) (dbpkg.BoardState, error) {
+	st, err := q.GetBoardState(ctx, matchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dbpkg.BoardState{MatchID: matchID, Period: 1}, nil
+	}
+	return st, err
+}
+
+func (r *Repository) toBoard(ctx context.Context, row dbpkg.Match, st dbpkg.BoardState) Board {
+	now := boardNow()
+	periods, _ := loadPeriods(ctx, r.q, row.ID)
+	return Board{
+		MatchID:       row.ID,
+		Period:        st.Period,
+		PeriodMinutes: r.cfg.League(ctx, sval(row.League)).PeriodMinutes,
+		ClockMs:       elapsed(st, now),
+		Running:       st.RunningSinceMs != nil,
+		ServerTimeMs:  now.UnixMilli(),
+		Match:         r.apiMatch(ctx, row, periods),
+	}
+}
+
+// Board returns the scoreboard for a match.
+func (r *Repository) Board(ctx context.Context, matchID int64) (Board, error) {
+	row, err := r.q.GetMatch(ctx, matchID)
+	if err != nil {
+		return Board{}, err
+	}
+	st, err := r.boardState(ctx, r.q, matchID)
+	if err != nil {
+		return Board{}, err
+	}
+	return r.toBoard(ctx, row, st), nil
+}
+
+// TodayBoards returns scoreboards for matches starting today in cfg's timezone,
+// ordered by start time.
+func (r *Repository) TodayBoards(ctx context.Context, cfg settings.Settings) ([]Board, error) {
+	list, err := r.q.ListMatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loc := cfg.Location()
+	today := boardNow().In(loc).Format("2006-01-02")
+	type item struct {
+		row   dbpkg.Match
+		start time.Time
+	}
+	var todays []item
+	for _, m := range list {
+		start, _ := matchTimes(m, cfg)
+		if start.IsZero() || start.In(loc).Format("2006-01-02") != today {
+			continue
+		}
+		if Status(m.Status) == StatusCancelled || Status(m.Status) == StatusPostponed {
+			continue
+		}
+		todays = append(todays, item{m, start})
+	}
+	sort.Slice(todays, func(i, j int) bool { return todays[i].start.Before(todays[j].start) })
+
+	out := make([]Board, 0, len(todays))
+	for _, it := range todays {
+		st, err := r.boardState(ctx, r.q, it.row.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r.toBoard(ctx, it.row, st))
+	}
+	return out, nil
+}
+
+// NewBoardToken creates a control token for a match, replacing any earlier one.
+// Only its hash is stored.
+func (r *Repository) NewBoardToken(ctx context.Context, matchID int64) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		if _, err := q.GetMatch(ctx, matchID); err != nil {
+			return err
+		}
+		st, err := r.boardState(ctx, q, matchID)
+		if err != nil {
+			return err
+		}
+		h := hashToken(token)
+		st.TokenHash = &h
+		_, err = q.UpsertBoardState(ctx, upsertParams(st))
+		return err
+	})
+	return token, err
+}
+
+// CheckBoardToken reports whether token controls the board of matchID.
+func (r *Repository) CheckBoardToken(ctx context.Context, matchID int64, token string) bool {
+	if token == "" {
+		return false
+	}
+	st, err := r.q.GetBoardState(ctx, matchID)
+	if err != nil || st.TokenHash == nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(*st.TokenHash), []byte(hashToken(token))) == 1
+}
+
+func upsertParams(st dbpkg.BoardState) dbpkg.UpsertBoardStateParams {
+	return dbpkg.UpsertBoardStateParams{
+		MatchID:        st.MatchID,
+		Period:         st.Period,
+		ClockMs:        st.ClockMs,
+		RunningSinceMs: st.RunningSinceMs,
+		TokenHash:      st.TokenHash,
+	}
+}
+
+// formatClock renders elapsed milliseconds as mm:ss.
+func formatClock(ms int64) string {
+	s := ms / 1000
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
+
+// ControlBoard applies a scorekeeper action. Goals are logged as live events
+// stamped with the board's period and clock.
+func (r *Repository) ControlBoard(ctx context.Context, matchID int64, a BoardAction) (Board, error) {
+	if _, err := r.q.GetMatch(ctx, matchID); err != nil {
+		return Board{}, err
+	}
+	st, err := r.boardState(ctx, r.q, matchID)
+	if err != nil {
+		return Board{}, err
+	}
+	now := boardNow()
+	switch a.Action {
+	case "start":
+		if st.RunningSinceMs == nil {
+			ms := now.UnixMilli()
+			st.RunningSinceMs = &ms
+		}
+	case "stop":
+		st.ClockMs, st.RunningSinceMs = elapsed(st, now), nil
+	case "set_clock":
+		if a.ClockMs < 0 {
+			return Board{}, fmt.Errorf("%w: clock_ms must not be negative", ErrInvalidAction)
+		}
+		st.ClockMs = a.ClockMs
+		if st.RunningSinceMs != nil {
+			ms := now.UnixMilli()
+			st.RunningSinceMs = &ms
+		}
+	case "set_period":
+		if a.Period < 1 {
+			return Board{}, fmt.Errorf("%w: period must be at least 1", ErrInvalidAction)
+		}
+		st.Period = a.Period
+	case "next_period":
+		st.Period++
+		st.ClockMs, st.RunningSinceMs = 0, nil
+	case "goal":
+		_, _, err := r.AddEvent(ctx, matchID, Event{
+			Kind:   EventGoal,
+			Side:   Side(a.Side),
+			Period: st.Period,
+			Clock:  formatClock(elapsed(st, now)),
+			Player: a.Player,
+			Assist: a.Assist,
+		})
+		if err != nil {
+			return Board{}, err
+		}
+	default:
+		return Board{}, fmt.Errorf("%w: %q", ErrInvalidAction, a.Action)
+	}
+	if a.Action != "goal" {
+		if _, err := r.q.UpsertBoardState(ctx, upsertParams(st)); err != nil {
+			return Board{}, err
+		}
+	}
+	b, err := r.Board(ctx, matchID)
+	if err != nil {
+		return Board{}, err
+	}
+	r.hub.Publish(ctx, Change{Type: ChangeBoard, MatchID: matchID, Board: &b})
+	return b, nil
+}
+
+// ----- Routes -----
+
+// boardToken reads the control token from "Authorization: Bearer" or ?token=.
+func boardToken(c *gin.Context) string {
+	if h := c.GetHeader("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	return c.Query("token")
+}
+
+func registerBoardRoutes(api *gin.RouterGroup, repo *Repository, cfg *settings.Service, protect gin.HandlerFunc) {
+	// Public, read-only scoreboard data
+	api.GET("/board/today", func(c *gin.Context) {
+		list, err := repo.TodayBoards(c.Request.Context(), cfg.Current(c.Request.Context()))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	api.GET("/board/:match_id", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("match_id"), 10, 64)
+		b, err := repo.Board(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(boardErrStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, b)
+	})
+
+	// A logged-in user hands out a control token to the scorekeeper's device
+	api.POST("/board/:match_id/token", attachProtect(protect, func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("match_id"), 10, 64)
+		token, err := repo.NewBoardToken(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(boardErrStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"token":       token,
+			"control_url": fmt.Sprintf("/board/%d/control?token=%s", id, token),
+		})
+	}))
+
+	api.POST("/board/:match_id/control", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("match_id"), 10, 64)
+		if !repo.CheckBoardToken(c.Request.Context(), id, boardToken(c)) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrBoardToken.Error()})
+			return
+		}
+		var a BoardAction
+		if err := c.BindJSON(&a); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+			return
+		}
+		b, err := repo.ControlBoard(c.Request.Context(), id, a)
+		if err != nil {
+			c.JSON(boardErrStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, b)
+	})
+}
+
+func boardErrStatus(err error) int {
+	if errors.Is(err, ErrInvalidAction) {
+		return http.StatusBadRequest
+	}
+	return eventErrStatus(err)
+}
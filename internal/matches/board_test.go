@@ -0,0 +1,116 @@
+package matches
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestBoard_ClockAndGoals(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)
+	boardNow = func() time.Time { return now }
+	t.Cleanup(func() { boardNow = time.Now })
+
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	b, err := repo.Board(ctx, m.ID)
+	if err != nil {
+		t.Fatalf("board: %v", err)
+	}
+	assertEq(t, b.Period, int64(1))
+	assertEq(t, b.Running, false)
+
+	if _, err := repo.ControlBoard(ctx, m.ID, BoardAction{Action: "start"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	now = now.Add(90 * time.Second)
+	b, _ = repo.ControlBoard(ctx, m.ID, BoardAction{Action: "goal", Side: "us", Player: "Svensson"})
+	assertEq(t, b.Match.GoalsFor, int64(1))
+	assertEq(t, b.ClockMs, int64(90_000))
+	events, _ := repo.Events(ctx, m.ID)
+	assertEq(t, events[0].Clock, "01:30")
+	assertEq(t, events[0].Period, int64(1))
+
+	now = now.Add(30 * time.Second)
+	b, _ = repo.ControlBoard(ctx, m.ID, BoardAction{Action: "stop"})
+	assertEq(t, b.ClockMs, int64(120_000))
+	now = now.Add(time.Minute)
+	b, _ = repo.Board(ctx, m.ID)
+	assertEq(t, b.ClockMs, int64(120_000)) // stopped
+
+	b, _ = repo.ControlBoard(ctx, m.ID, BoardAction{Action: "next_period"})
+	assertEq(t, b.Period, int64(2))
+	assertEq(t, b.ClockMs, int64(0))
+
+	if _, err := repo.ControlBoard(ctx, m.ID, BoardAction{Action: "explode"}); !errors.Is(err, ErrInvalidAction) {
+		t.Fatalf("expected ErrInvalidAction, got %v", err)
+	}
+}
+
+func TestBoard_TodayUsesConfiguredTimezone(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	// 23:30 UTC on the 19th is already the 20th in Stockholm
+	boardNow = func() time.Time { return time.Date(2025, 9, 19, 23, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { boardNow = time.Now })
+
+	late, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "16:00", Team: "A", Opponent: "C"})
+	early, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "10:00", Team: "A", Opponent: "B"})
+	repo.Create(ctx, Match{DateRaw: "2025-09-21", TimeRaw: "10:00", Team: "A", Opponent: "D"})
+	cancelled, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "12:00", Team: "A", Opponent: "E"})
+	repo.Update(ctx, cancelled.ID, Match{Status: StatusCancelled})
+
+	list, err := repo.TodayBoards(ctx, settings.Defaults())
+	if err != nil {
+		t.Fatalf("today: %v", err)
+	}
+	assertEq(t, len(list), 2)
+	assertEq(t, list[0].MatchID, early.ID)
+	assertEq(t, list[1].MatchID, late.ID)
+}
+
+func TestBoard_ControlRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	other, _ := repo.Create(ctx, Match{Team: "A", Opponent: "C"})
+	r := gin.New()
+	RegisterRoutes(r, repo, nil, nil)
+
+	control := func(id int64, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/board/"+strconv.FormatInt(id, 10)+"/control", strings.NewReader(`{"action":"start"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assertEq(t, control(m.ID, ""), http.StatusUnauthorized)
+	token, err := repo.NewBoardToken(ctx, m.ID)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	assertEq(t, control(m.ID, "wrong"), http.StatusUnauthorized)
+	assertEq(t, control(other.ID, token), http.StatusUnauthorized)
+	assertEq(t, control(m.ID, token), http.StatusOK)
+
+	// A new token replaces the old one
+	if _, err := repo.NewBoardToken(ctx, m.ID); err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	assertEq(t, control(m.ID, token), http.StatusUnauthorized)
+}
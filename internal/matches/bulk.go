@@ -0,0 +1,261 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+// Bulk operation kinds.
+const (
+	BulkCreate = "create"
+	BulkPatch  = "patch"
+	BulkDelete = "delete"
+)
+
+// MaxBulkOps caps the operations in one bulk request.
+const MaxBulkOps = 500
+
+var (
+	ErrInvalidBulk = errors.New("invalid bulk operation")
+	errBulkFailed  = errors.New("bulk operation failed")
+)
+
+// BulkOp is one operation in a bulk request. patch and delete target either
+// one match by id, optionally at version, or every match the filter selects.
+type BulkOp struct {
+	Op      string      `json:"op"`
+	ID      int64       `json:"id,omitempty"`
+	Version int64       `json:"version,omitempty"` // 0 = any
+	Filter  *BulkFilter `json:"filter,omitempty"`
+	Match   *Match      `json:"match,omitempty"` // create
+	Patch   MatchPatch  `json:"patch"`           // patch
+}
+
+// BulkFilter selects matches for a bulk patch or delete. Set criteria must
+// all hold; text is compared case-insensitively. An empty filter is refused
+// so a missing field cannot hit the whole schedule.
+type BulkFilter struct {
+	IDs      []int64 `json:"ids,omitempty"`
+	Venue    *string `json:"venue,omitempty"`
+	City     *string `json:"city,omitempty"`
+	League   *string `json:"league,omitempty"`
+	Team     *string `json:"team,omitempty"`
+	Opponent *string `json:"opponent,omitempty"`
+	Status   Status  `json:"status,omitempty"`
+	From     string  `json:"from,omitempty"` // YYYY-MM-DD, inclusive
+	To       string  `json:"to,omitempty"`   // YYYY-MM-DD, inclusive
+}
+
+func (f *BulkFilter) empty() bool {
+	return len(f.IDs) == 0 && f.Venue == nil && f.City == nil && f.League == nil && f.Team == nil &&
+		f.Opponent == nil && f.Status == "" && f.From == "" && f.To == ""
+}
+
+func (f *BulkFilter) matches(m dbpkg.Match) bool {
+	text := func(want *string, got *string) bool {
+		return want == nil || strings.EqualFold(strings.TrimSpace(*want), strings.TrimSpace(sval(got)))
+	}
+	if len(f.IDs) > 0 {
+		found := false
+		for _, id := range f.IDs {
+			found = found || id == m.ID
+		}
+		if !found {
+			return false
+		}
+	}
+	if !text(f.Venue, m.Venue) || !text(f.City, m.City) || !text(f.League, m.League) ||
+		!text(f.Team, m.Team) || !text(f.Opponent, m.Opponent) {
+		return false
+	}
+	if f.Status != "" && Status(m.Status) != f.Status {
+		return false
+	}
+	if f.From != "" || f.To != "" {
+		day := matchDay(m)
+		if day == "" || (f.From != "" && day < f.From) || (f.To != "" && day > f.To) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchDay is the match date as YYYY-MM-DD, or "" if it has none.
+func matchDay(m dbpkg.Match) string {
+	if s := sval(m.StartIso); len(s) >= 10 {
+		return s[:10]
+	}
+	if s := sval(m.DateRaw); len(s) >= 10 {
+		return s[:10]
+	}
+	return ""
+}
+
+// BulkResult reports one operation: the matches it created, changed or
+// deleted, or why it failed.
+type BulkResult struct {
+	Index int     `json:"index"`
+	Op    string  `json:"op"`
+	IDs   []int64 `json:"ids"`
+	Error string  `json:"error,omitempty"`
+	err   error
+}
+
+// Bulk runs ops in one transaction. If any operation fails nothing is kept;
+// the error is that of the first failing operation, whose result says why.
+// Changes are published only once everything is committed.
+func (r *Repository) Bulk(ctx context.Context, ops []BulkOp) ([]BulkResult, error) {
+	if len(ops) == 0 || len(ops) > MaxBulkOps {
+		return nil, fmt.Errorf("%w: expected 1 to %d operations, got %d", ErrInvalidBulk, MaxBulkOps, len(ops))
+	}
+	results := make([]BulkResult, 0, len(ops))
+	var publish []func()
+	var failed error
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		for i, op := range ops {
+			res := BulkResult{Index: i, Op: op.Op, IDs: []int64{}}
+			pub, err := r.bulkOne(ctx, q, op, &res)
+			if err != nil {
+				res.err, res.Error = err, err.Error()
+				results = append(results, res)
+				failed = err
+				return errBulkFailed
+			}
+			results = append(results, res)
+			publish = append(publish, pub...)
+		}
+		return nil
+	})
+	if failed != nil {
+		// The results end with the operation that failed
+		return results, failed
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, pub := range publish {
+		pub()
+	}
+	return results, nil
+}
+
+// bulkOne runs a single operation through q, filling in res.IDs, and returns
+// the changes to publish after commit.
+func (r *Repository) bulkOne(ctx context.Context, q *dbpkg.Queries, op BulkOp, res *BulkResult) ([]func(), error) {
+	var pub []func()
+	switch op.Op {
+	case BulkCreate:
+		if op.Match == nil {
+			return nil, fmt.Errorf("%w: create needs a match", ErrInvalidBulk)
+		}
+		row, err := r.createAudited(ctx, q, *op.Match)
+		if err != nil {
+			return nil, err
+		}
+		res.IDs = append(res.IDs, row.ID)
+		return append(pub, func() { r.publish(ctx, ChangeCreated, row) }), nil
+
+	case BulkPatch, BulkDelete:
+		ids, version, err := r.bulkTargets(ctx, q, op)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if op.Op == BulkPatch {
+				before, row, err := r.patchAudited(ctx, q, id, version, op.Patch)
+				if err != nil {
+					return nil, fmt.Errorf("match %d: %w", id, err)
+				}
+				pub = append(pub, func() { r.publishPatched(ctx, before, row) })
+			} else {
+				deleted, err := r.deleteAudited(ctx, q, id, version)
+				if err != nil {
+					return nil, fmt.Errorf("match %d: %w", id, err)
+				}
+				if !deleted {
+					return nil, fmt.Errorf("match %d: %w", id, sql.ErrNoRows)
+				}
+				pub = append(pub, func() { r.publishDeleted(ctx, id) })
+			}
+			res.IDs = append(res.IDs, id)
+		}
+		return pub, nil
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidBulk, op.Op)
+}
+
+// bulkTargets resolves the matches a patch or delete applies to, and the
+// version they must be at.
+func (r *Repository) bulkTargets(ctx context.Context, q *dbpkg.Queries, op BulkOp) ([]int64, int64, error) {
+	switch {
+	case op.ID != 0 && op.Filter != nil:
+		return nil, 0, fmt.Errorf("%w: give either id or filter", ErrInvalidBulk)
+	case op.ID != 0:
+		return []int64{op.ID}, op.Version, nil
+	case op.Filter == nil || op.Filter.empty():
+		return nil, 0, fmt.Errorf("%w: %s needs an id or a non-empty filter", ErrInvalidBulk, op.Op)
+	}
+	rows, err := q.ListMatches(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var ids []int64
+	for _, m := range rows {
+		if op.Filter.matches(m) {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids, AnyVersion, nil
+}
+
+// ----- Routes -----
+
+type bulkReq struct {
+	Operations []BulkOp `json:"operations"`
+}
+
+// bulkItemStatus is the HTTP status that describes one bulk result.
+func bulkItemStatus(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	}
+	return errStatus(err)
+}
+
+func registerBulkRoutes(api *gin.RouterGroup, repo *Repository, protect gin.HandlerFunc) {
+	api.POST("/matches/bulk", attachProtect(protect, func(c *gin.Context) {
+		var req bulkReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+			return
+		}
+		results, err := repo.Bulk(c.Request.Context(), req.Operations)
+		if results == nil && err != nil {
+			c.JSON(bulkItemStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		type item struct {
+			BulkResult
+			Status int `json:"status"`
+		}
+		items := make([]item, 0, len(results))
+		for _, res := range results {
+			items = append(items, item{BulkResult: res, Status: bulkItemStatus(res.err)})
+		}
+		if err != nil {
+			c.JSON(bulkItemStatus(err), gin.H{"applied": false, "error": err.Error(), "results": items})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"applied": true, "results": items})
+	}))
+}
@@ -0,0 +1,126 @@
+package matches
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestRepository_BulkMovesVenueAndCreates(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	a, _ := repo.Create(ctx, Match{DateRaw: "2025-10-04", Team: "A", Opponent: "B", Venue: "Hallen A"})
+	b, _ := repo.Create(ctx, Match{DateRaw: "2025-10-11", Team: "A", Opponent: "C", Venue: "hallen a"})
+	c, _ := repo.Create(ctx, Match{DateRaw: "2025-10-18", Team: "A", Opponent: "D", Venue: "Hallen B"})
+	late, _ := repo.Create(ctx, Match{DateRaw: "2025-12-06", Team: "A", Opponent: "E", Venue: "Hallen A"})
+
+	var changes []Change
+	repo.Hub().Listen(func(_ context.Context, ch Change) { changes = append(changes, ch) })
+
+	venue := "Hallen A"
+	results, err := repo.Bulk(ctx, []BulkOp{
+		{Op: BulkPatch, Filter: &BulkFilter{Venue: &venue, To: "2025-11-30"}, Patch: MatchPatch{Venue: Val("Nya hallen"), League: Val("F16 Syd")}},
+		{Op: BulkDelete, ID: c.ID, Version: c.Version},
+		{Op: BulkCreate, Match: &Match{DateRaw: "2025-10-25", Team: "A", Opponent: "F"}},
+	})
+	if err != nil {
+		t.Fatalf("bulk: %v %+v", err, results)
+	}
+	assertEq(t, len(results), 3)
+	assertEq(t, len(results[0].IDs), 2)
+	for _, id := range []int64{a.ID, b.ID} {
+		row, _ := repo.Get(ctx, id)
+		assertEq(t, sval(row.Venue), "Nya hallen")
+		assertEq(t, sval(row.League), "F16 Syd")
+	}
+	row, _ := repo.Get(ctx, late.ID)
+	assertEq(t, sval(row.Venue), "Hallen A")
+	if _, err := repo.Get(ctx, c.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected the deleted match gone, got %v", err)
+	}
+	assertEq(t, len(results[2].IDs), 1)
+	// Two updates, one delete and one create, published after commit
+	assertEq(t, len(changes), 4)
+}
+
+func TestRepository_BulkRollsBackOnFailure(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	var changes []Change
+	repo.Hub().Listen(func(_ context.Context, ch Change) { changes = append(changes, ch) })
+
+	results, err := repo.Bulk(ctx, []BulkOp{
+		{Op: BulkPatch, ID: m.ID, Patch: MatchPatch{Notes: Val("kept?")}},
+		{Op: BulkCreate, Match: &Match{Team: "A", Opponent: "C"}},
+		{Op: BulkPatch, ID: m.ID, Version: 1, Patch: MatchPatch{Notes: Val("stale")}},
+		{Op: BulkDelete, ID: m.ID},
+	})
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected a version mismatch, got %v", err)
+	}
+	if len(results) != 3 || results[2].Error == "" || results[0].Error != "" {
+		t.Fatalf("expected results up to the failing operation: %+v", results)
+	}
+	row, _ := repo.Get(ctx, m.ID)
+	if row.Notes != nil || row.Version != 1 {
+		t.Fatalf("expected nothing kept: %+v", row)
+	}
+	if list, _ := repo.List(ctx); len(list) != 1 {
+		t.Fatalf("expected the create rolled back, got %d matches", len(list))
+	}
+	assertEq(t, len(changes), 0)
+
+	for _, ops := range [][]BulkOp{
+		nil,
+		{{Op: BulkDelete, Filter: &BulkFilter{}}},
+		{{Op: BulkPatch}},
+		{{Op: "move"}},
+	} {
+		if _, err := repo.Bulk(ctx, ops); !errors.Is(err, ErrInvalidBulk) {
+			t.Fatalf("%+v: expected an invalid bulk error, got %v", ops, err)
+		}
+	}
+}
+
+func TestBulkRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+	repo.Create(context.Background(), Match{Team: "A", Opponent: "B", League: "F15"})
+
+	do := func(body string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/matches/bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		var out map[string]any
+		json.Unmarshal(w.Body.Bytes(), &out)
+		return w.Code, out
+	}
+	code, out := do(`{"operations": [{"op": "patch", "filter": {"league": "f15"}, "patch": {"league": "F16", "notes": null}}]}`)
+	if code != http.StatusOK || out["applied"] != true {
+		t.Fatalf("bulk: %d %v", code, out)
+	}
+	row, _ := repo.Get(context.Background(), 1)
+	assertEq(t, sval(row.League), "F16")
+
+	code, out = do(`{"operations": [{"op": "delete", "id": 99}]}`)
+	results, _ := out["results"].([]any)
+	if code != http.StatusNotFound || out["applied"] != false || len(results) != 1 ||
+		results[0].(map[string]any)["status"] != float64(http.StatusNotFound) {
+		t.Fatalf("expected a 404 for the missing match: %d %v", code, out)
+	}
+	if code, _ := do(`{"operations": []}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for no operations, got %d", code)
+	}
+}
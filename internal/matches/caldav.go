@@ -0,0 +1,802 @@
+package matches
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/auth"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// The schedule as a CalDAV (RFC 4791) calendar: one collection with a
+// resource per match. Clients sign in with an app password, notice changes
+// through the collection's ctag and sync token and fetch only the changed
+// events, by ETag or with a sync-collection report (RFC 6578). An app
+// password with write access may move, edit and delete matches; new matches
+// are added in the app.
+const (
+	caldavPrincipal  = "/caldav/principal/"
+	caldavHome       = "/caldav/calendars/"
+	caldavCollection = "/caldav/calendars/matches/"
+	caldavRealm      = "X-Matches"
+	syncTokenPrefix  = "urn:x-matches:sync:"
+)
+
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+// davPrefix is the prefix each known namespace is declared with.
+var davPrefix = map[string]string{nsDAV: "D", nsCalDAV: "C", nsCS: "CS"}
+
+type davKind int
+
+const (
+	davRoot davKind = iota
+	davPrincipal
+	davHome
+	davCollection
+	davEvent
+)
+
+// davResource is what a CalDAV path names.
+type davResource struct {
+	kind  davKind
+	href  string
+	id    int64
+	match dbpkg.Match // davEvent
+}
+
+// parseDAVPath resolves the path below /caldav.
+func parseDAVPath(p string) (davResource, bool) {
+	p = "/" + strings.Trim(p, "/")
+	switch p {
+	case "/":
+		return davResource{kind: davRoot, href: "/caldav/"}, true
+	case "/principal":
+		return davResource{kind: davPrincipal, href: caldavPrincipal}, true
+	case "/calendars":
+		return davResource{kind: davHome, href: caldavHome}, true
+	case "/calendars/matches":
+		return davResource{kind: davCollection, href: caldavCollection}, true
+	}
+	name, ok := strings.CutPrefix(p, "/calendars/matches/")
+	if !ok {
+		return davResource{}, false
+	}
+	id, err := strconv.ParseInt(strings.TrimSuffix(name, ".ics"), 10, 64)
+	if err != nil || !strings.HasSuffix(name, ".ics") || id <= 0 {
+		return davResource{}, false
+	}
+	return davResource{kind: davEvent, href: eventHref(id), id: id}, true
+}
+
+func eventHref(id int64) string {
+	return caldavCollection + strconv.FormatInt(id, 10) + ".ics"
+}
+
+func eventETag(m dbpkg.Match) string {
+	return `"` + strconv.FormatInt(m.Version, 10) + `"`
+}
+
+// ----- XML -----
+
+// xmlNode is a parsed request body element.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+func (n *xmlNode) is(space, local string) bool {
+	return n != nil && n.XMLName.Space == space && n.XMLName.Local == local
+}
+
+// child returns the first direct child called space:local, or nil.
+func (n *xmlNode) child(space, local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for i := range n.Children {
+		if n.Children[i].is(space, local) {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// readXML parses a request body; an empty body gives nil.
+func readXML(c *gin.Context) (*xmlNode, error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	var n xmlNode
+	if err := xml.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// requestedProps lists the properties asked for in n's prop element; nil
+// means all of them.
+func requestedProps(n *xmlNode) []xml.Name {
+	prop := n.child(nsDAV, "prop")
+	if prop == nil {
+		return nil
+	}
+	out := make([]xml.Name, 0, len(prop.Children))
+	for _, p := range prop.Children {
+		out = append(out, p.XMLName)
+	}
+	return out
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// davElement renders an element with inner XML.
+func davElement(name xml.Name, inner string) string {
+	tag, decl := name.Local, ""
+	if prefix, ok := davPrefix[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		decl = ` xmlns="` + xmlEscape(name.Space) + `"`
+	}
+	if inner == "" {
+		return "<" + tag + decl + "/>"
+	}
+	return "<" + tag + decl + ">" + inner + "</" + tag + ">"
+}
+
+func davStatus(code int) string {
+	return fmt.Sprintf("<D:status>HTTP/1.1 %d %s</D:status>", code, http.StatusText(code))
+}
+
+// davResponse is one response in a multistatus body.
+type davResponse struct {
+	href    string
+	status  int      // for a response without properties, such as a 404 in a sync report
+	props   []string // rendered properties
+	missing []xml.Name
+}
+
+func writeMultistatus(c *gin.Context, resps []davResponse, syncToken string) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" xmlns:CS="http://calendarserver.org/ns/">`)
+	for _, r := range resps {
+		b.WriteString("<D:response><D:href>" + xmlEscape(r.href) + "</D:href>")
+		if r.status != 0 {
+			b.WriteString(davStatus(r.status))
+		}
+		if len(r.props) > 0 {
+			b.WriteString("<D:propstat><D:prop>" + strings.Join(r.props, "") + "</D:prop>" + davStatus(http.StatusOK) + "</D:propstat>")
+		}
+		if len(r.missing) > 0 {
+			b.WriteString("<D:propstat><D:prop>")
+			for _, name := range r.missing {
+				b.WriteString(davElement(name, ""))
+			}
+			b.WriteString("</D:prop>" + davStatus(http.StatusNotFound) + "</D:propstat>")
+		}
+		b.WriteString("</D:response>")
+	}
+	if syncToken != "" {
+		b.WriteString("<D:sync-token>" + xmlEscape(syncToken) + "</D:sync-token>")
+	}
+	b.WriteString("</D:multistatus>")
+	c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(b.String()))
+}
+
+// davError answers with a precondition element (RFC 4918 16).
+func davError(c *gin.Context, code int, condition xml.Name) {
+	body := xml.Header + `<D:error xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">` + davElement(condition, "") + `</D:error>`
+	c.Data(code, "application/xml; charset=utf-8", []byte(body))
+}
+
+// ----- Properties -----
+
+// caldavRequest carries what rendering a request's properties needs.
+type caldavRequest struct {
+	c        *gin.Context
+	cfg      settings.Settings
+	user     string // the signed-in email
+	canWrite bool
+	token    string // the collection's sync token
+	opt      icalOptions
+}
+
+// allProps are the properties returned for allprop; calendar-data only
+// when asked for.
+var allProps = []xml.Name{
+	{Space: nsDAV, Local: "resourcetype"},
+	{Space: nsDAV, Local: "displayname"},
+	{Space: nsDAV, Local: "current-user-principal"},
+	{Space: nsDAV, Local: "getetag"},
+	{Space: nsDAV, Local: "getcontenttype"},
+	{Space: nsDAV, Local: "getlastmodified"},
+	{Space: nsDAV, Local: "sync-token"},
+	{Space: nsCS, Local: "getctag"},
+}
+
+func (rq *caldavRequest) prop(res davResource, name xml.Name) (string, bool) {
+	href := func(h string) string { return "<D:href>" + xmlEscape(h) + "</D:href>" }
+	privilege := func(p string) string { return "<D:privilege><D:" + p + "/></D:privilege>" }
+	coll := res.kind != davEvent
+	switch name.Space + " " + name.Local {
+	case nsDAV + " resourcetype":
+		switch res.kind {
+		case davPrincipal:
+			return "<D:collection/><D:principal/>", true
+		case davCollection:
+			return "<D:collection/><C:calendar/>", true
+		case davEvent:
+			return "", true
+		}
+		return "<D:collection/>", true
+	case nsDAV + " displayname":
+		switch res.kind {
+		case davPrincipal:
+			return xmlEscape(rq.user), true
+		case davCollection:
+			if rq.cfg.ClubName != "" {
+				return xmlEscape(rq.cfg.ClubName), true
+			}
+			return defaultFeedName, true
+		case davEvent:
+			return xmlEscape(matchSummary(res.match)), true
+		}
+		return "", false
+	case nsDAV + " current-user-principal", nsDAV + " principal-URL", nsDAV + " owner":
+		return href(caldavPrincipal), true
+	case nsCalDAV + " calendar-home-set":
+		return href(caldavHome), coll
+	case nsCalDAV + " calendar-user-address-set":
+		return href("mailto:" + rq.user), res.kind == davPrincipal
+	case nsDAV + " current-user-privilege-set":
+		out := privilege("read") + privilege("read-current-user-privilege-set")
+		if rq.canWrite && (res.kind == davCollection || res.kind == davEvent) {
+			out += privilege("write-content") + privilege("unbind")
+		}
+		return out, true
+	case nsDAV + " supported-report-set":
+		if res.kind != davCollection {
+			return "", false
+		}
+		var out string
+		for _, r := range []string{"<C:calendar-multiget/>", "<C:calendar-query/>", "<D:sync-collection/>"} {
+			out += "<D:supported-report><D:report>" + r + "</D:report></D:supported-report>"
+		}
+		return out, true
+	case nsCalDAV + " supported-calendar-component-set":
+		return `<C:comp name="VEVENT"/>`, res.kind == davCollection
+	case nsDAV + " sync-token":
+		return xmlEscape(rq.token), res.kind == davCollection
+	case nsCS + " getctag":
+		return xmlEscape(strings.TrimPrefix(rq.token, syncTokenPrefix)), res.kind == davCollection
+	case nsDAV + " getetag":
+		return xmlEscape(eventETag(res.match)), res.kind == davEvent
+	case nsDAV + " getcontenttype":
+		return "text/calendar; charset=utf-8; component=VEVENT", res.kind == davEvent
+	case nsDAV + " getlastmodified":
+		if res.kind != davEvent || res.match.UpdatedAt == nil {
+			return "", false
+		}
+		return res.match.UpdatedAt.UTC().Format(http.TimeFormat), true
+	case nsCalDAV + " calendar-data":
+		if res.kind != davEvent {
+			return "", false
+		}
+		return xmlEscape(rq.eventData(res.match)), true
+	}
+	return "", false
+}
+
+// eventData is the calendar object resource for one match.
+func (rq *caldavRequest) eventData(m dbpkg.Match) string {
+	return calendarObject(m, rq.cfg, rq.opt)
+}
+
+// calendarObject renders m as a calendar holding its one event, with the
+// CRLF line endings CalDAV servers expect.
+func calendarObject(m dbpkg.Match, cfg settings.Settings, opt icalOptions) string {
+	var b strings.Builder
+	writeICal(&b, []dbpkg.Match{m}, cfg, opt)
+	return strings.ReplaceAll(b.String(), "\n", "\r\n")
+}
+
+// CalendarObject renders m as a calendar holding its one event, for a
+// CalDAV server.
+func (r *Repository) CalendarObject(ctx context.Context, m dbpkg.Match) string {
+	cfg := r.cfg.Current(ctx)
+	return calendarObject(m, cfg, icalOptions{gather: r.gatherResolver(ctx, cfg)})
+}
+
+// ApplyEvent applies the edits a calendar made to m's event, as a patch on
+// the version m is at. It reports false, and changes nothing, if the event
+// still says what the match does.
+func (r *Repository) ApplyEvent(ctx context.Context, m dbpkg.Match, ev CalendarEvent) (dbpkg.Match, bool, error) {
+	p := eventPatch(m, ev, r.cfg.Current(ctx))
+	if reflect.DeepEqual(p, MatchPatch{}) {
+		return m, false, nil
+	}
+	row, err := r.PatchAt(ctx, m.ID, m.Version, p)
+	return row, err == nil, err
+}
+
+// response renders the requested properties of res; nil names means all.
+func (rq *caldavRequest) response(res davResource, names []xml.Name) davResponse {
+	out := davResponse{href: res.href}
+	all := names == nil
+	if all {
+		names = allProps
+	}
+	for _, name := range names {
+		if v, ok := rq.prop(res, name); ok {
+			out.props = append(out.props, davElement(name, v))
+		} else if !all {
+			out.missing = append(out.missing, name)
+		}
+	}
+	return out
+}
+
+// ----- Mapping events back onto matches -----
+
+// eventPatch maps the edits a calendar made to a match's event onto the
+// match: time, place, notes, teams and status. Whatever still renders as it
+// did is left alone, so an event sent back unchanged changes nothing.
+func eventPatch(cur dbpkg.Match, ev CalendarEvent, cfg settings.Settings) MatchPatch {
+	var p MatchPatch
+	str := func(s string) Field[string] {
+		if s == "" {
+			return Null[string]()
+		}
+		return Val(s)
+	}
+	loc := cfg.Location()
+
+	start, end := matchTimes(cur, cfg)
+	newStart := start
+	if !ev.Start.IsZero() && !ev.Start.Equal(start) {
+		newStart = ev.Start.In(loc)
+		p.DateRaw = Val(newStart.Format("2006-01-02"))
+		p.TimeRaw = Val(newStart.Format("15:04"))
+	}
+	if !ev.End.IsZero() && (!ev.End.Equal(end) || p.DateRaw.Set) {
+		endRaw := ev.End.In(loc).Format("15:04")
+		// An end the match duration after the start is the default, not an end time
+		if sval(cur.EndTimeRaw) == "" && ev.End.Equal(newStart.Add(cfg.MatchDuration())) {
+			endRaw = ""
+		}
+		if endRaw != sval(cur.EndTimeRaw) {
+			p.EndTimeRaw = str(endRaw)
+		}
+	}
+
+	if ev.Location != matchLocation(cur) {
+		city := sval(cur.City)
+		if venue, ok := strings.CutSuffix(ev.Location, ", "+city); ok && city != "" {
+			p.Venue = str(venue)
+		} else {
+			p.Venue, p.City = str(ev.Location), Null[string]()
+		}
+	}
+
+	if ev.Description != matchDescription(cur) {
+		notes := ev.Description
+		if reason := sval(cur.StatusReason); reason != "" && Status(cur.Status) != StatusScheduled {
+			notes = strings.TrimSuffix(strings.TrimSuffix(notes, reason), "\n")
+		}
+		p.Notes = str(notes)
+	}
+
+	if ev.Summary != "" && ev.Summary != matchSummary(cur) {
+		if home, away, ok := strings.Cut(ev.Summary, " vs "); ok {
+			p.HomeTeam, p.AwayTeam = str(strings.TrimSpace(home)), str(strings.TrimSpace(away))
+		} else if team, opp, ok := strings.Cut(ev.Summary, " – "); ok {
+			p.Team, p.Opponent = str(strings.TrimSpace(team)), str(strings.TrimSpace(opp))
+		}
+	}
+
+	if ev.Status != "" && ev.Status != icalStatus(Status(cur.Status)) {
+		switch ev.Status {
+		case "CANCELLED":
+			p.Status = Val(StatusCancelled)
+		case "TENTATIVE":
+			p.Status = Val(StatusPostponed)
+		case "CONFIRMED":
+			p.Status = Val(StatusScheduled)
+		}
+	}
+	return p
+}
+
+// ifMatchVersion reads the version a CalDAV write is based on from its
+// If-Match header; without one the write applies to any version.
+func ifMatchVersion(c *gin.Context) (int64, bool) {
+	h := strings.TrimSpace(c.GetHeader("If-Match"))
+	if h == "" || h == "*" {
+		return AnyVersion, true
+	}
+	v, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(h, "W/"), `"`), 10, 64)
+	return v, err == nil
+}
+
+// ----- Handlers -----
+
+type caldavHandler struct {
+	repo *Repository
+	cfg  *settings.Service
+}
+
+// RegisterCalDAV mounts the CalDAV server at /caldav/ behind app passwords,
+// with /.well-known/caldav pointing there.
+func RegisterCalDAV(r *gin.Engine, repo *Repository, cfg *settings.Service, users *auth.Repository) {
+	h := &caldavHandler{repo: repo, cfg: cfg}
+	required := auth.AppPasswordRequired(users, caldavRealm)
+
+	wellKnown := func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, "/caldav/") }
+	for _, m := range []string{http.MethodGet, "PROPFIND"} {
+		r.Handle(m, "/.well-known/caldav", wellKnown)
+	}
+
+	// Clients probe with OPTIONS before they have credentials
+	r.OPTIONS("/caldav/*path", h.options)
+	for _, m := range []string{http.MethodGet, http.MethodHead, "PROPFIND", "REPORT", http.MethodPut, http.MethodDelete, "PROPPATCH", "MKCALENDAR", http.MethodPost} {
+		r.Handle(m, "/caldav/*path", required, h.serve)
+	}
+}
+
+func (h *caldavHandler) options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", "OPTIONS, GET, HEAD, PROPFIND, REPORT, PUT, DELETE")
+	c.Status(http.StatusOK)
+}
+
+func (h *caldavHandler) serve(c *gin.Context) {
+	res, ok := parseDAVPath(c.Param("path"))
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	ctx := c.Request.Context()
+	if res.kind == davEvent {
+		m, err := h.repo.Get(ctx, res.id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err != nil || m.DeletedAt != nil {
+			if c.Request.Method == http.MethodPut {
+				// Matches are created in the app, where they get their id
+				c.String(http.StatusForbidden, "new matches cannot be created over CalDAV")
+				return
+			}
+			c.Status(http.StatusNotFound)
+			return
+		}
+		res.match = m
+	}
+
+	rq, err := h.request(c)
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("DAV", "1, 3, calendar-access")
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(rq, res)
+	case "PROPFIND":
+		h.propfind(rq, res)
+	case "REPORT":
+		h.report(rq, res)
+	case http.MethodPut:
+		h.put(rq, res)
+	case http.MethodDelete:
+		h.delete(rq, res)
+	default:
+		c.Status(http.StatusForbidden)
+	}
+}
+
+func (h *caldavHandler) request(c *gin.Context) (*caldavRequest, error) {
+	ctx := c.Request.Context()
+	state, err := h.repo.q.GetSyncState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	club := h.cfg.Current(ctx)
+	p, _ := auth.CurrentAppPassword(c)
+	user, _, _ := c.Request.BasicAuth()
+	return &caldavRequest{
+		c:        c,
+		cfg:      club,
+		user:     user,
+		canWrite: p.CanWrite,
+		token:    syncTokenPrefix + strconv.FormatInt(state.Seq, 10),
+		opt: icalOptions{
+			gather:   h.repo.gatherResolver(ctx, club),
+			matchURL: func(id int64) string { return fmt.Sprintf("%s/board/%d", baseURL(c), id) },
+		},
+	}, nil
+}
+
+func (h *caldavHandler) get(rq *caldavRequest, res davResource) {
+	c := rq.c
+	switch res.kind {
+	case davEvent:
+		if notModified(c, eventETag(res.match)) {
+			return
+		}
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(rq.eventData(res.match)))
+	case davCollection:
+		list, err := h.repo.List(c.Request.Context())
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		writeICal(c.Writer, list, rq.cfg, rq.opt)
+	default:
+		c.Status(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *caldavHandler) events(ctx context.Context) ([]davResource, error) {
+	list, err := h.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]davResource, 0, len(list))
+	for _, m := range list {
+		out = append(out, davResource{kind: davEvent, href: eventHref(m.ID), id: m.ID, match: m})
+	}
+	return out, nil
+}
+
+func (h *caldavHandler) propfind(rq *caldavRequest, res davResource) {
+	c := rq.c
+	body, err := readXML(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "bad xml")
+		return
+	}
+	names := requestedProps(body)
+
+	targets := []davResource{res}
+	if c.GetHeader("Depth") != "0" {
+		switch res.kind {
+		case davRoot:
+			targets = append(targets, davResource{kind: davPrincipal, href: caldavPrincipal}, davResource{kind: davHome, href: caldavHome})
+		case davHome:
+			targets = append(targets, davResource{kind: davCollection, href: caldavCollection})
+		case davCollection:
+			events, err := h.events(c.Request.Context())
+			if err != nil {
+				c.String(http.StatusInternalServerError, err.Error())
+				return
+			}
+			targets = append(targets, events...)
+		}
+	}
+	resps := make([]davResponse, 0, len(targets))
+	for _, t := range targets {
+		resps = append(resps, rq.response(t, names))
+	}
+	writeMultistatus(c, resps, "")
+}
+
+func (h *caldavHandler) report(rq *caldavRequest, res davResource) {
+	c := rq.c
+	body, err := readXML(c)
+	if err != nil || body == nil {
+		c.String(http.StatusBadRequest, "bad xml")
+		return
+	}
+	if res.kind != davCollection {
+		davError(c, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "supported-report"})
+		return
+	}
+	names := requestedProps(body)
+	ctx := c.Request.Context()
+
+	switch {
+	case body.is(nsCalDAV, "calendar-multiget"):
+		var resps []davResponse
+		for _, n := range body.Children {
+			if !n.is(nsDAV, "href") {
+				continue
+			}
+			href := strings.TrimSpace(n.Text)
+			path := href
+			if u, err := url.Parse(href); err == nil {
+				path = u.Path
+			}
+			t, ok := parseDAVPath(strings.TrimPrefix(path, "/caldav"))
+			if !ok || t.kind != davEvent {
+				resps = append(resps, davResponse{href: href, status: http.StatusNotFound})
+				continue
+			}
+			m, err := h.repo.Get(ctx, t.id)
+			if err != nil || m.DeletedAt != nil {
+				resps = append(resps, davResponse{href: href, status: http.StatusNotFound})
+				continue
+			}
+			t.match = m
+			resps = append(resps, rq.response(t, names))
+		}
+		writeMultistatus(c, resps, "")
+
+	case body.is(nsCalDAV, "calendar-query"):
+		// Only a time range on the events is understood; other filters match everything
+		var from, to time.Time
+		if f := body.child(nsCalDAV, "filter").child(nsCalDAV, "comp-filter").child(nsCalDAV, "comp-filter"); f != nil {
+			if tr := f.child(nsCalDAV, "time-range"); tr != nil {
+				from, _ = time.Parse(icalStamp, tr.attr("start"))
+				to, _ = time.Parse(icalStamp, tr.attr("end"))
+			}
+		}
+		events, err := h.events(ctx)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		resps := []davResponse{}
+		for _, t := range events {
+			if !from.IsZero() || !to.IsZero() {
+				start, end := matchTimes(t.match, rq.cfg)
+				if start.IsZero() || (!to.IsZero() && !start.Before(to)) || (!from.IsZero() && !end.After(from)) {
+					continue
+				}
+			}
+			resps = append(resps, rq.response(t, names))
+		}
+		writeMultistatus(c, resps, "")
+
+	case body.is(nsDAV, "sync-collection"):
+		h.syncCollection(rq, body, names)
+
+	default:
+		davError(c, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "supported-report"})
+	}
+}
+
+// syncCollection answers a sync-collection report with the events changed
+// after the client's token, and 404 for those deleted since.
+func (h *caldavHandler) syncCollection(rq *caldavRequest, body *xmlNode, names []xml.Name) {
+	c := rq.c
+	ctx := c.Request.Context()
+	var from int64
+	if tok := strings.TrimSpace(body.child(nsDAV, "sync-token").Text); tok != "" {
+		n, err := strconv.ParseInt(strings.TrimPrefix(tok, syncTokenPrefix), 10, 64)
+		if err != nil || !strings.HasPrefix(tok, syncTokenPrefix) || n <= 0 {
+			davError(c, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "valid-sync-token"})
+			return
+		}
+		from = n
+	}
+	var rows []dbpkg.Match
+	var state dbpkg.SyncState
+	var full bool
+	err := h.repo.inTx(ctx, func(q *dbpkg.Queries) error {
+		var err error
+		rows, state, full, err = changedRows(ctx, q, from)
+		return err
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if full && from != 0 {
+		// Too old, or from before a restore: the client starts over
+		davError(c, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "valid-sync-token"})
+		return
+	}
+	resps := []davResponse{}
+	for _, m := range rows {
+		if m.DeletedAt != nil {
+			if !full {
+				resps = append(resps, davResponse{href: eventHref(m.ID), status: http.StatusNotFound})
+			}
+			continue
+		}
+		resps = append(resps, rq.response(davResource{kind: davEvent, href: eventHref(m.ID), id: m.ID, match: m}, names))
+	}
+	writeMultistatus(c, resps, syncTokenPrefix+strconv.FormatInt(state.Seq, 10))
+}
+
+func (h *caldavHandler) put(rq *caldavRequest, res davResource) {
+	c := rq.c
+	if res.kind != davEvent {
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+	if !rq.canWrite {
+		davError(c, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "need-privileges"})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	events, err := ParseCalendarEvents(string(body), rq.cfg.Location())
+	if err != nil || len(events) == 0 {
+		davError(c, http.StatusBadRequest, xml.Name{Space: nsCalDAV, Local: "valid-calendar-data"})
+		return
+	}
+	ev := events[0]
+	if ev.UID != "" && ev.UID != EventUID(res.id) {
+		davError(c, http.StatusConflict, xml.Name{Space: nsCalDAV, Local: "no-uid-conflict"})
+		return
+	}
+	row, err := h.repo.PatchAt(c.Request.Context(), res.id, version, eventPatch(res.match, ev, rq.cfg))
+	if err != nil {
+		c.String(errStatus(err), err.Error())
+		return
+	}
+	c.Header("ETag", eventETag(row))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *caldavHandler) delete(rq *caldavRequest, res davResource) {
+	c := rq.c
+	if res.kind != davEvent {
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+	if !rq.canWrite {
+		davError(c, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "need-privileges"})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+	if err := h.repo.DeleteAt(c.Request.Context(), res.id, version); err != nil {
+		c.String(errStatus(err), err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,189 @@
+package matches
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/auth"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestParseCalendarEvents(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	data := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:match-7@x-matches\r\n" +
+		"DTSTART;TZID=Europe/Stockholm:20250920T143000\r\nDURATION:PT1H30M\r\n" +
+		"SUMMARY:IK Sund vs Lu\r\n gi\r\nLOCATION:Hallen\\, Lund\r\nSTATUS:CANCELLED\r\n" +
+		"BEGIN:VALARM\r\nDESCRIPTION:not the event\r\nEND:VALARM\r\nDESCRIPTION:Ta med\\nvattenflaska\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+	events, err := ParseCalendarEvents(data, time.UTC)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("parse: %v %+v", err, events)
+	}
+	ev := events[0]
+	want := CalendarEvent{
+		UID:         "match-7@x-matches",
+		Summary:     "IK Sund vs Lugi",
+		Location:    "Hallen, Lund",
+		Description: "Ta med\nvattenflaska",
+		Status:      "CANCELLED",
+		Start:       time.Date(2025, 9, 20, 14, 30, 0, 0, loc),
+		End:         time.Date(2025, 9, 20, 16, 0, 0, 0, loc),
+	}
+	if ev.UID != want.UID || ev.Summary != want.Summary || ev.Location != want.Location ||
+		ev.Description != want.Description || ev.Status != want.Status || !ev.Start.Equal(want.Start) || !ev.End.Equal(want.End) {
+		t.Fatalf("got %+v, want %+v", ev, want)
+	}
+}
+
+func TestEventPatch(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	cfg := settings.Defaults()
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", HomeTeam: "A", AwayTeam: "B", Venue: "Hallen", City: "Lund", Notes: "Vita tröjor"})
+
+	var b strings.Builder
+	writeICal(&b, []dbpkg.Match{m}, cfg, icalOptions{})
+	events, _ := ParseCalendarEvents(b.String(), cfg.Location())
+	if p := eventPatch(m, events[0], cfg); !reflect.DeepEqual(p, MatchPatch{}) {
+		t.Fatalf("an unchanged event must not patch anything: %+v", p)
+	}
+
+	ev := events[0]
+	ev.Start = ev.Start.Add(24 * time.Hour)
+	ev.End = ev.End.Add(24 * time.Hour)
+	ev.Location = "Sporthallen, Lund"
+	p := eventPatch(m, ev, cfg)
+	if p.DateRaw.Value != "2025-09-21" || p.TimeRaw.Value != "14:30" || p.EndTimeRaw.Set {
+		t.Fatalf("moving the event should move the match only: %+v", p)
+	}
+	if p.Venue.Value != "Sporthallen" || p.City.Set {
+		t.Fatalf("the city should be kept: %+v", p)
+	}
+}
+
+func TestCalDAV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	ctx := context.Background()
+	users := auth.NewRepository(db)
+	u, _ := users.CreateUser(ctx, "coach@example.com", "x")
+	_, readOnly, _ := users.CreateAppPassword(ctx, u.ID, "Telefon", false)
+	_, readWrite, _ := users.CreateAppPassword(ctx, u.ID, "Dator", true)
+	r := gin.New()
+	RegisterCalDAV(r, repo, repo.cfg, users)
+
+	a, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", HomeTeam: "A", AwayTeam: "B"})
+	b, _ := repo.Create(ctx, Match{DateRaw: "2025-09-27", TimeRaw: "10:00", HomeTeam: "A", AwayTeam: "C"})
+	hrefA, hrefB := eventHref(a.ID), eventHref(b.ID)
+
+	do := func(method, path, secret, body string, headers ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if secret != "" {
+			req.SetBasicAuth("coach@example.com", secret)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	contains := func(w *httptest.ResponseRecorder, want ...string) {
+		t.Helper()
+		for _, s := range want {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("response missing %q:\n%s", s, w.Body.String())
+			}
+		}
+	}
+
+	if w := do(http.MethodOptions, "/caldav/", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Header().Get("DAV"), "calendar-access") {
+		t.Fatalf("options: %d %v", w.Code, w.Header())
+	}
+	if w := do("PROPFIND", "/caldav/", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without an app password, got %d", w.Code)
+	}
+
+	// Discovery: principal, then home, then the calendar
+	w := do("PROPFIND", "/caldav/", readOnly, `<propfind xmlns="DAV:"><prop><current-user-principal/></prop></propfind>`, "Depth", "0")
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("propfind: %d", w.Code)
+	}
+	contains(w, "<D:current-user-principal><D:href>/caldav/principal/</D:href>")
+	w = do("PROPFIND", "/caldav/principal/", readOnly, `<propfind xmlns="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><prop><C:calendar-home-set/><displayname/></prop></propfind>`, "Depth", "0")
+	contains(w, "<C:calendar-home-set><D:href>/caldav/calendars/</D:href>", "<D:displayname>coach@example.com</D:displayname>")
+	w = do("PROPFIND", "/caldav/calendars/", readOnly, `<propfind xmlns="DAV:"><prop><resourcetype/><x:color xmlns:x="urn:example"/></prop></propfind>`, "Depth", "1")
+	contains(w, "<D:href>"+caldavCollection+"</D:href>", "<C:calendar/>", `<color xmlns="urn:example"/>`, "404 Not Found")
+
+	w = do("PROPFIND", caldavCollection, readOnly, `<propfind xmlns="DAV:" xmlns:CS="http://calendarserver.org/ns/"><prop><CS:getctag/><getetag/></prop></propfind>`, "Depth", "1")
+	contains(w, "<CS:getctag>", hrefA, hrefB, "<D:getetag>&#34;1&#34;</D:getetag>")
+
+	// Initial sync, then a change and a deletion
+	syncReport := func(token string) *httptest.ResponseRecorder {
+		return do("REPORT", caldavCollection, readOnly, `<sync-collection xmlns="DAV:"><sync-token>`+token+`</sync-token><prop><getetag/></prop></sync-collection>`)
+	}
+	w = syncReport("")
+	contains(w, hrefA, hrefB)
+	token := w.Body.String()[strings.Index(w.Body.String(), syncTokenPrefix):]
+	token = token[:strings.Index(token, "<")]
+
+	if _, err := repo.Update(ctx, a.ID, Match{Notes: "Ny tid?"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := repo.Delete(ctx, b.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	w = syncReport(token)
+	contains(w, "<D:href>"+hrefA+"</D:href><D:propstat><D:prop><D:getetag>&#34;2&#34;", "<D:href>"+hrefB+"</D:href><D:status>HTTP/1.1 404 Not Found")
+	if w := syncReport(syncTokenPrefix + "999"); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "valid-sync-token") {
+		t.Fatalf("expected valid-sync-token error, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = do("REPORT", caldavCollection, readOnly, `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/><C:calendar-data/></D:prop><D:href>`+hrefA+`</D:href><D:href>`+hrefB+`</D:href></C:calendar-multiget>`)
+	contains(w, "UID:match-"+strconv.FormatInt(a.ID, 10)+"@x-matches", "DESCRIPTION:Ny tid?", "<D:href>"+hrefB+"</D:href><D:status>HTTP/1.1 404")
+
+	w = do("REPORT", caldavCollection, readOnly, `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/></D:prop>`+
+		`<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT"><C:time-range start="20251001T000000Z" end="20251101T000000Z"/></C:comp-filter></C:comp-filter></C:filter></C:calendar-query>`)
+	if strings.Contains(w.Body.String(), hrefA) {
+		t.Fatalf("time range should exclude the match: %s", w.Body.String())
+	}
+
+	// Moving the match from a calendar
+	w = do(http.MethodGet, hrefA, readOnly, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("get: %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	moved := strings.Replace(w.Body.String(), "DTSTART:20250920T123000Z", "DTSTART:20250920T130000Z", 1)
+	moved = strings.Replace(moved, "DTEND:20250920T133000Z", "DTEND:20250920T140000Z", 1)
+	if w := do(http.MethodPut, hrefA, readOnly, moved, "If-Match", `"2"`); w.Code != http.StatusForbidden {
+		t.Fatalf("a read-only app password must not write, got %d", w.Code)
+	}
+	if w := do(http.MethodPut, hrefA, readWrite, moved, "If-Match", `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a stale ETag, got %d", w.Code)
+	}
+	if w := do(http.MethodPut, hrefA, readWrite, moved, "If-Match", `"2"`); w.Code != http.StatusNoContent || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("put: %d %s", w.Code, w.Body.String())
+	}
+	got, _ := repo.Get(ctx, a.ID)
+	if sval(got.TimeRaw) != "15:00" || sval(got.EndTimeRaw) != "" || got.EndIso != nil || sval(got.Notes) != "Ny tid?" {
+		t.Fatalf("unexpected match after put: time=%q end=%q/%q notes=%q", sval(got.TimeRaw), sval(got.EndTimeRaw), sval(got.EndIso), sval(got.Notes))
+	}
+	if w := do(http.MethodPut, eventHref(999), readWrite, moved); w.Code != http.StatusForbidden {
+		t.Fatalf("creating over CalDAV should be refused, got %d", w.Code)
+	}
+
+	if w := do(http.MethodDelete, hrefA, readWrite, "", "If-Match", `"3"`); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := do(http.MethodGet, hrefA, readOnly, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", w.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package matches
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+// ErrVersionMismatch means the match changed after the client read it.
+var ErrVersionMismatch = errors.New("match was changed by someone else")
+
+// AnyVersion skips the version check in PatchAt and DeleteAt.
+const AnyVersion int64 = 0
+
+// matchETag is the entity tag of a match at version.
+func matchETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// listETag fingerprints a list of matches by id and version, which changes
+// with every write, creation and deletion. extra covers anything else the
+// response depends on, such as settings.
+func listETag(list []dbpkg.Match, extra ...any) string {
+	h := sha256.New()
+	for _, m := range list {
+		h.Write([]byte(strconv.FormatInt(m.ID, 10) + ":" + strconv.FormatInt(m.Version, 10) + "\n"))
+	}
+	for _, x := range extra {
+		b, _ := json.Marshal(x)
+		h.Write(b)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// notModified sets etag on the response and answers 304 if the request's
+// If-None-Match already has it.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch checks the If-Match header of a write to a match now at version cur
+// and returns the version the write must still find. It answers 428 without
+// the header and 412 if no tag matches.
+func ifMatch(c *gin.Context, cur int64) (int64, bool) {
+	h := strings.TrimSpace(c.GetHeader("If-Match"))
+	if h == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+		return 0, false
+	}
+	if h == "*" {
+		return AnyVersion, true
+	}
+	for _, tag := range strings.Split(h, ",") {
+		if strings.TrimSpace(tag) == matchETag(cur) {
+			return cur, true
+		}
+	}
+	c.Header("ETag", matchETag(cur))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": ErrVersionMismatch.Error(), "version": cur})
+	return 0, false
+}
@@ -0,0 +1,103 @@
+package matches
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestRepository_PatchAtRejectsStaleVersion(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	assertEq(t, m.Version, int64(1))
+	if m.UpdatedAt == nil {
+		t.Fatalf("expected updated_at on create")
+	}
+
+	row, err := repo.PatchAt(ctx, m.ID, 1, MatchPatch{Notes: Val("Buss 12:00")})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	assertEq(t, row.Version, int64(2))
+
+	// The second coach still holds version 1
+	if _, err := repo.PatchAt(ctx, m.ID, 1, MatchPatch{Notes: Val("Samling 13:00")}); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected a version mismatch, got %v", err)
+	}
+	if err := repo.DeleteAt(ctx, m.ID, 1); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected a version mismatch on delete, got %v", err)
+	}
+	cur, _ := repo.Get(ctx, m.ID)
+	assertEq(t, sval(cur.Notes), "Buss 12:00")
+}
+
+func TestETagRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+	repo.Create(context.Background(), Match{Team: "A", Opponent: "B"})
+
+	do := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/matches/1", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("get: %d etag=%q", w.Code, etag)
+	}
+	if w := do(http.MethodGet, "/api/matches/1", "", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+
+	if w := do(http.MethodPatch, "/api/matches/1", `{"notes": "x"}`); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 without If-Match, got %d", w.Code)
+	}
+	w = do(http.MethodPatch, "/api/matches/1", `{"notes": "x"}`, "If-Match", etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("patch: %d %s etag=%q", w.Code, w.Body.String(), w.Header().Get("ETag"))
+	}
+	// The other coach's edit is based on the old version
+	w = do(http.MethodPatch, "/api/matches/1", `{"notes": "y"}`, "If-Match", etag)
+	if w.Code != http.StatusPreconditionFailed || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected 412 with the current tag, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := do(http.MethodDelete, "/api/matches/1", "", "If-Match", etag); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 on a stale delete, got %d", w.Code)
+	}
+
+	// Lists and exports answer 304 until something changes
+	for _, path := range []string{"/api/matches", "/api/matches.csv", "/api/matches.ics"} {
+		w := do(http.MethodGet, path, "")
+		tag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || tag == "" {
+			t.Fatalf("%s: %d etag=%q", path, w.Code, tag)
+		}
+		if w := do(http.MethodGet, path, "", "If-None-Match", tag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Fatalf("%s: expected an empty 304, got %d", path, w.Code)
+		}
+		repo.Create(context.Background(), Match{Team: "A", Opponent: "C"})
+		if w := do(http.MethodGet, path, "", "If-None-Match", tag); w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200 after a change, got %d", path, w.Code)
+		}
+	}
+
+	if w := do(http.MethodDelete, "/api/matches/1", "", "If-Match", `"2"`); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+}
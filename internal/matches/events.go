@@ -0,0 +1,381 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/audit"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+// EventKind is the type of a live match event.
+type EventKind string
+
+const (
+	EventGoal        EventKind = "goal"
+	EventPenalty     EventKind = "penalty"
+	EventTimeout     EventKind = "timeout"
+	EventPeriodStart EventKind = "period_start"
+	EventPeriodEnd   EventKind = "period_end"
+	EventMatchEnd    EventKind = "match_end"
+)
+
+// Side tells which team an event belongs to.
+type Side string
+
+const (
+	SideUs   Side = "us"
+	SideThem Side = "them"
+)
+
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Event is one entry in a match timeline.
+type Event struct {
+	ID             int64     `json:"id"`
+	MatchID        int64     `json:"match_id"`
+	Kind           EventKind `json:"kind"`
+	Side           Side      `json:"side,omitempty"`
+	Period         int64     `json:"period,omitempty"`
+	Clock          string    `json:"clock,omitempty"`
+	Player         string    `json:"player,omitempty"`
+	Assist         string    `json:"assist,omitempty"`
+	PenaltyMinutes int64     `json:"penalty_minutes,omitempty"`
+	Note           string    `json:"note,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Score          Score     `json:"score"` // running score after this event
+}
+
+func (e Event) validate() error {
+	switch e.Kind {
+	case EventGoal, EventPenalty:
+		if e.Side != SideUs && e.Side != SideThem {
+			return fmt.Errorf("%w: %s requires side us or them", ErrInvalidEvent, e.Kind)
+		}
+	case EventTimeout:
+		if e.Side != "" && e.Side != SideUs && e.Side != SideThem {
+			return fmt.Errorf("%w: side must be us or them", ErrInvalidEvent)
+		}
+	case EventPeriodStart, EventPeriodEnd, EventMatchEnd:
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidEvent, e.Kind)
+	}
+	if e.Period < 0 || e.PenaltyMinutes < 0 {
+		return fmt.Errorf("%w: period and penalty_minutes must not be negative", ErrInvalidEvent)
+	}
+	return nil
+}
+
+// toAPIEvents maps rows to the API, filling in the running score.
+func toAPIEvents(rows []dbpkg.MatchEvent) []Event {
+	out := make([]Event, 0, len(rows))
+	var score Score
+	for _, e := range rows {
+		if e.Kind == string(EventGoal) {
+			if sval(e.Side) == string(SideUs) {
+				score.For++
+			} else {
+				score.Against++
+			}
+		}
+		out = append(out, Event{
+			ID:             e.ID,
+			MatchID:        e.MatchID,
+			Kind:           EventKind(e.Kind),
+			Side:           Side(sval(e.Side)),
+			Period:         ival(e.Period),
+			Clock:          sval(e.Clock),
+			Player:         sval(e.Player),
+			Assist:         sval(e.Assist),
+			PenaltyMinutes: ival(e.PenaltyMinutes),
+			Note:           sval(e.Note),
+			CreatedAt:      e.CreatedAt,
+			Score:          score,
+		})
+	}
+	return out
+}
+
+// deriveScore computes the final score from goal events. Period scores are
+// only derived when every goal has a period; goals after the league's last
+// period count as overtime.
+func deriveScore(events []dbpkg.MatchEvent, wantPeriods int) (total Score, periods []Score, overtime *Score) {
+	anyPeriod, allGoals, playedOT := false, true, false
+	for _, e := range events {
+		p := ival(e.Period)
+		anyPeriod = anyPeriod || p > 0
+		playedOT = playedOT || p > int64(wantPeriods)
+		if e.Kind == string(EventGoal) && p == 0 {
+			allGoals = false
+		}
+	}
+	byPeriod := anyPeriod && allGoals
+	if byPeriod {
+		periods = make([]Score, wantPeriods)
+		if playedOT {
+			overtime = &Score{}
+		}
+	}
+	for _, e := range events {
+		if e.Kind != string(EventGoal) {
+			continue
+		}
+		var into *Score
+		if byPeriod {
+			if p := ival(e.Period); p > int64(wantPeriods) {
+				into = overtime
+			} else {
+				into = &periods[p-1]
+			}
+		}
+		if sval(e.Side) == string(SideUs) {
+			total.For++
+			if into != nil {
+				into.For++
+			}
+		} else {
+			total.Against++
+			if into != nil {
+				into.Against++
+			}
+		}
+	}
+	return total, periods, overtime
+}
+
+// Events returns the timeline of a match in the order it was logged.
+func (r *Repository) Events(ctx context.Context, matchID int64) ([]Event, error) {
+	rows, err := r.q.ListMatchEvents(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	return toAPIEvents(rows), nil
+}
+
+// AddEvent logs an event and writes the score derived from the timeline back to
+// the match. A match_end event also marks the match as played.
+func (r *Repository) AddEvent(ctx context.Context, matchID int64, e Event) (dbpkg.MatchEvent, dbpkg.Match, error) {
+	if err := e.validate(); err != nil {
+		return dbpkg.MatchEvent{}, dbpkg.Match{}, err
+	}
+	var ev dbpkg.MatchEvent
+	var row dbpkg.Match
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		before, err := q.GetMatch(ctx, matchID)
+		if err != nil {
+			return err
+		}
+		prev, err := r.auditForm(ctx, q, before)
+		if err != nil {
+			return err
+		}
+		if err := r.saveVersion(ctx, q, before, AuditEventAdd); err != nil {
+			return err
+		}
+		ev, err = q.CreateMatchEvent(ctx, dbpkg.CreateMatchEventParams{
+			MatchID:        matchID,
+			Kind:           string(e.Kind),
+			Side:           pstr(string(e.Side)),
+			Period:         pI64ZeroNil(e.Period),
+			Clock:          pstr(e.Clock),
+			Player:         pstr(e.Player),
+			Assist:         pstr(e.Assist),
+			PenaltyMinutes: pI64ZeroNil(e.PenaltyMinutes),
+			Note:           pstr(e.Note),
+		})
+		if err != nil {
+			return err
+		}
+		if row, err = r.applyEvents(ctx, q, matchID); err != nil {
+			return err
+		}
+		if e.Kind == EventMatchEnd && !Status(row.Status).IsPlayed() {
+			if row, err = r.update(ctx, q, matchID, MatchPatch{Status: Val(StatusPlayed)}); err != nil {
+				return err
+			}
+		}
+		next, err := r.auditForm(ctx, q, row)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, AuditEventAdd, audit.EntityMatch, matchID, prev, next)
+	})
+	if err == nil {
+		r.publishEvent(ctx, row, ev.ID)
+		if e.Kind == EventMatchEnd {
+			r.publish(ctx, ChangeResult, row)
+		}
+	}
+	return ev, row, err
+}
+
+// DeleteEvent removes a logged event (e.g. a goal registered by mistake) and
+// recomputes the score.
+func (r *Repository) DeleteEvent(ctx context.Context, matchID, eventID int64) (dbpkg.Match, error) {
+	var row dbpkg.Match
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		before, err := q.GetMatch(ctx, matchID)
+		if err != nil {
+			return err
+		}
+		prev, err := r.auditForm(ctx, q, before)
+		if err != nil {
+			return err
+		}
+		if err := r.saveVersion(ctx, q, before, AuditEventDelete); err != nil {
+			return err
+		}
+		n, err := q.DeleteMatchEvent(ctx, dbpkg.DeleteMatchEventParams{ID: eventID, MatchID: matchID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+		if row, err = r.applyEvents(ctx, q, matchID); err != nil {
+			return err
+		}
+		next, err := r.auditForm(ctx, q, row)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, AuditEventDelete, audit.EntityMatch, matchID, prev, next)
+	})
+	if err == nil {
+		r.publishEvent(ctx, row, 0)
+	}
+	return row, err
+}
+
+// publishEvent sends a live change with the updated match and, when eventID is
+// set, the logged event with its running score.
+func (r *Repository) publishEvent(ctx context.Context, row dbpkg.Match, eventID int64) {
+	periods, _ := loadPeriods(ctx, r.q, row.ID)
+	m := toAPIWithPeriods(row, periods)
+	c := Change{Type: ChangeLiveEvent, MatchID: row.ID, Match: &m}
+	if eventID != 0 {
+		if list, err := r.Events(ctx, row.ID); err == nil {
+			e := findEvent(list, eventID)
+			c.Event = &e
+		}
+	}
+	r.hub.Publish(ctx, c)
+}
+
+// applyEvents writes the score derived from the timeline to the match row.
+func (r *Repository) applyEvents(ctx context.Context, q *dbpkg.Queries, matchID int64) (dbpkg.Match, error) {
+	cur, err := q.GetMatch(ctx, matchID)
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	events, err := q.ListMatchEvents(ctx, matchID)
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	total, periods, overtime := deriveScore(events, r.cfg.League(ctx, sval(cur.League)).Periods)
+	// The timeline is the source of truth once logging has started
+	if err := replacePeriods(ctx, q, matchID, periods); err != nil {
+		return dbpkg.Match{}, err
+	}
+	seq, err := q.NextChangeSeq(ctx)
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	return q.SetMatchScore(ctx, dbpkg.SetMatchScoreParams{
+		GoalsFor:        &total.For,
+		GoalsAgainst:    &total.Against,
+		OvertimeFor:     scoreFor(overtime),
+		OvertimeAgainst: scoreAgainst(overtime),
+		UpdatedAt:       stamp(),
+		ChangeSeq:       seq,
+		ID:              matchID,
+	})
+}
+
+// ----- Routes -----
+
+type eventReq struct {
+	Kind           string `json:"kind"`
+	Side           string `json:"side"`
+	Period         int64  `json:"period"`
+	Clock          string `json:"clock"`
+	Player         string `json:"player"`
+	Assist         string `json:"assist"`
+	PenaltyMinutes int64  `json:"penalty_minutes"`
+	Note           string `json:"note"`
+}
+
+func registerEventRoutes(api *gin.RouterGroup, repo *Repository, protect gin.HandlerFunc) {
+	// Timeline for a match (public, like the rest of the read routes)
+	api.GET("/matches/:id/events", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		list, err := repo.Events(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	api.POST("/matches/:id/events", attachProtect(protect, func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		var req eventReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+			return
+		}
+		ev, row, err := repo.AddEvent(c.Request.Context(), id, Event{
+			Kind:           EventKind(strings.TrimSpace(req.Kind)),
+			Side:           Side(strings.TrimSpace(req.Side)),
+			Period:         req.Period,
+			Clock:          strings.TrimSpace(req.Clock),
+			Player:         strings.TrimSpace(req.Player),
+			Assist:         strings.TrimSpace(req.Assist),
+			PenaltyMinutes: req.PenaltyMinutes,
+			Note:           strings.TrimSpace(req.Note),
+		})
+		if err != nil {
+			c.JSON(eventErrStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		events, _ := repo.Events(c.Request.Context(), id)
+		c.JSON(http.StatusCreated, gin.H{"event": findEvent(events, ev.ID), "match": withPeriods(c, repo, row)})
+	}))
+
+	api.DELETE("/matches/:id/events/:event_id", attachProtect(protect, func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		eventID, _ := strconv.ParseInt(c.Param("event_id"), 10, 64)
+		row, err := repo.DeleteEvent(c.Request.Context(), id, eventID)
+		if err != nil {
+			c.JSON(eventErrStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"match": withPeriods(c, repo, row)})
+	}))
+}
+
+func eventErrStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidEvent):
+		return http.StatusBadRequest
+	}
+	return errStatus(err)
+}
+
+func findEvent(list []Event, id int64) Event {
+	for _, e := range list {
+		if e.ID == id {
+			return e
+		}
+	}
+	return Event{ID: id}
+}
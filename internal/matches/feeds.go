@@ -0,0 +1,438 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/auth"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// ErrInvalidFeed means a calendar feed failed validation.
+var ErrInvalidFeed = errors.New("invalid calendar feed")
+
+const (
+	// MaxFeedAlarms caps the reminders on one feed.
+	MaxFeedAlarms = 5
+	// maxFeedAlarmMinutes is a week.
+	maxFeedAlarmMinutes = 7 * 24 * 60
+	defaultFeedName     = "Matcher"
+)
+
+// DefaultFeedAlarms are the reminders, in minutes before kick-off, of a feed
+// created without any.
+var DefaultFeedAlarms = []int64{24 * 60, 60}
+
+// FeedFilter selects the matches in a calendar feed. Text is compared
+// case-insensitively; an empty field matches everything.
+type FeedFilter struct {
+	Team   string `json:"team"` // our team, home team or away team
+	League string `json:"league"`
+	Side   string `json:"side"` // home|away, seen from Team or else the club's team
+}
+
+// Feed is a private iCal subscription owned by a user. The secret token is
+// only known when the feed is created or its token regenerated; URL is set
+// then and empty otherwise.
+type Feed struct {
+	ID int64 `json:"id"`
+	FeedFilter
+	Name        string     `json:"name"`
+	Alarms      []int64    `json:"alarms"`       // minutes before kick-off
+	GatherAlarm bool       `json:"gather_alarm"` // remind at the gather time too
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	URL         string     `json:"url,omitempty"`
+}
+
+func (f *Feed) normalize() error {
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		f.Name = defaultFeedName
+	}
+	if len(f.Name) > 100 {
+		return fmt.Errorf("%w: name is too long", ErrInvalidFeed)
+	}
+	f.Team = strings.TrimSpace(f.Team)
+	f.League = strings.TrimSpace(f.League)
+	f.Side = strings.ToLower(strings.TrimSpace(f.Side))
+	if f.Side != "" && f.Side != "home" && f.Side != "away" {
+		return fmt.Errorf("%w: side must be home or away", ErrInvalidFeed)
+	}
+	if len(f.Alarms) > MaxFeedAlarms {
+		return fmt.Errorf("%w: at most %d alarms", ErrInvalidFeed, MaxFeedAlarms)
+	}
+	for _, m := range f.Alarms {
+		if m < 0 || m > maxFeedAlarmMinutes {
+			return fmt.Errorf("%w: alarms must be 0 to %d minutes before kick-off", ErrInvalidFeed, maxFeedAlarmMinutes)
+		}
+	}
+	return nil
+}
+
+func (f Feed) alarmDurations() []time.Duration {
+	out := make([]time.Duration, 0, len(f.Alarms))
+	for _, m := range f.Alarms {
+		out = append(out, time.Duration(m)*time.Minute)
+	}
+	return out
+}
+
+// matches reports whether m belongs in the feed. ourTeam stands in for the
+// side filter's team when the feed names none.
+func (f FeedFilter) matches(m dbpkg.Match, ourTeam string) bool {
+	eq := func(a, b string) bool { return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) }
+	if f.League != "" && !eq(f.League, sval(m.League)) {
+		return false
+	}
+	if f.Team != "" && !eq(f.Team, sval(m.Team)) && !eq(f.Team, sval(m.HomeTeam)) && !eq(f.Team, sval(m.AwayTeam)) {
+		return false
+	}
+	if f.Side == "" {
+		return true
+	}
+	team := f.Team
+	if team == "" {
+		team = ourTeam
+	}
+	if team == "" {
+		return false
+	}
+	if f.Side == "home" {
+		return eq(team, sval(m.HomeTeam))
+	}
+	return eq(team, sval(m.AwayTeam))
+}
+
+func formatAlarms(alarms []int64) *string {
+	parts := make([]string, 0, len(alarms))
+	for _, m := range alarms {
+		parts = append(parts, strconv.FormatInt(m, 10))
+	}
+	s := strings.Join(parts, ",")
+	return &s
+}
+
+func parseAlarms(s *string) []int64 {
+	out := []int64{}
+	for _, p := range strings.Split(sval(s), ",") {
+		if m, err := strconv.ParseInt(strings.TrimSpace(p), 10, 64); err == nil {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func toFeed(row dbpkg.CalendarFeed) Feed {
+	return Feed{
+		ID: row.ID,
+		FeedFilter: FeedFilter{
+			Team:   sval(row.Team),
+			League: sval(row.League),
+			Side:   sval(row.Side),
+		},
+		Name:        row.Name,
+		Alarms:      parseAlarms(row.Alarms),
+		GatherAlarm: row.GatherAlarm != 0,
+		CreatedAt:   row.CreatedAt,
+		LastUsedAt:  row.LastUsedAt,
+	}
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Feeds lists the calendar feeds of a user.
+func (r *Repository) Feeds(ctx context.Context, userID int64) ([]Feed, error) {
+	rows, err := r.q.ListCalendarFeeds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Feed, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toFeed(row))
+	}
+	return out, nil
+}
+
+// CreateFeed adds a feed for a user and returns it with its secret token.
+// Nil alarms get DefaultFeedAlarms; an empty list means none.
+func (r *Repository) CreateFeed(ctx context.Context, userID int64, f Feed) (Feed, string, error) {
+	if f.Alarms == nil {
+		f.Alarms = DefaultFeedAlarms
+	}
+	if err := f.normalize(); err != nil {
+		return Feed{}, "", err
+	}
+	token, err := auth.NewToken()
+	if err != nil {
+		return Feed{}, "", err
+	}
+	row, err := r.q.CreateCalendarFeed(ctx, dbpkg.CreateCalendarFeedParams{
+		UserID:      userID,
+		Name:        f.Name,
+		TokenHash:   hashToken(token),
+		Team:        pstr(f.Team),
+		League:      pstr(f.League),
+		Side:        pstr(f.Side),
+		Alarms:      formatAlarms(f.Alarms),
+		GatherAlarm: boolInt(f.GatherAlarm),
+		CreatedAt:   *stamp(),
+	})
+	if err != nil {
+		return Feed{}, "", err
+	}
+	return toFeed(row), token, nil
+}
+
+// UpdateFeed replaces the name, filter and reminders of a user's feed. The
+// token, and so the URL, stays the same.
+func (r *Repository) UpdateFeed(ctx context.Context, userID, id int64, f Feed) (Feed, error) {
+	if f.Alarms == nil {
+		f.Alarms = []int64{}
+	}
+	if err := f.normalize(); err != nil {
+		return Feed{}, err
+	}
+	row, err := r.q.UpdateCalendarFeed(ctx, dbpkg.UpdateCalendarFeedParams{
+		Name:        f.Name,
+		Team:        pstr(f.Team),
+		League:      pstr(f.League),
+		Side:        pstr(f.Side),
+		Alarms:      formatAlarms(f.Alarms),
+		GatherAlarm: boolInt(f.GatherAlarm),
+		ID:          id,
+		UserID:      userID,
+	})
+	if err != nil {
+		return Feed{}, err
+	}
+	return toFeed(row), nil
+}
+
+// RegenerateFeed gives a user's feed a new token; the old URL stops working.
+func (r *Repository) RegenerateFeed(ctx context.Context, userID, id int64) (Feed, string, error) {
+	token, err := auth.NewToken()
+	if err != nil {
+		return Feed{}, "", err
+	}
+	row, err := r.q.SetCalendarFeedToken(ctx, dbpkg.SetCalendarFeedTokenParams{TokenHash: hashToken(token), ID: id, UserID: userID})
+	if err != nil {
+		return Feed{}, "", err
+	}
+	return toFeed(row), token, nil
+}
+
+// DeleteFeed revokes a user's feed. It returns sql.ErrNoRows if the user has
+// no such feed.
+func (r *Repository) DeleteFeed(ctx context.Context, userID, id int64) error {
+	n, err := r.q.DeleteCalendarFeed(ctx, dbpkg.DeleteCalendarFeedParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FeedByToken looks up a feed by its secret token and records the use.
+func (r *Repository) FeedByToken(ctx context.Context, token string) (Feed, error) {
+	row, err := r.q.GetCalendarFeedByToken(ctx, hashToken(token))
+	if err != nil {
+		return Feed{}, err
+	}
+	if err := r.q.TouchCalendarFeed(ctx, dbpkg.TouchCalendarFeedParams{LastUsedAt: stamp(), ID: row.ID}); err != nil {
+		return Feed{}, err
+	}
+	return toFeed(row), nil
+}
+
+// FeedMatches returns the matches the feed selects.
+func (r *Repository) FeedMatches(ctx context.Context, f Feed, cfg settings.Settings) ([]dbpkg.Match, error) {
+	list, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dbpkg.Match, 0, len(list))
+	for _, m := range list {
+		if f.FeedFilter.matches(m, cfg.OurTeam) {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// ----- Routes -----
+
+// baseURL is the scheme and host the request was made to.
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func feedURL(c *gin.Context, token string) string {
+	return baseURL(c) + "/ical/" + token + ".ics"
+}
+
+// RegisterFeedRoutes mounts the signed-in user's calendar feeds at
+// /api/auth/me/feeds and the feeds themselves at /ical/<token>.ics.
+func RegisterFeedRoutes(r *gin.Engine, repo *Repository, cfg *settings.Service, users *auth.Repository) {
+	me := r.Group("/api/auth/me")
+
+	// user resolves the signed-in user, answering 401 if there is none.
+	user := func(c *gin.Context) (auth.User, bool) {
+		u, ok := auth.CurrentUser(c, users)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		}
+		return u, ok
+	}
+	feedErr := func(c *gin.Context, err error) {
+		switch {
+		case errors.Is(err, ErrInvalidFeed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	}
+
+	me.GET("/feeds", func(c *gin.Context) {
+		u, ok := user(c)
+		if !ok {
+			return
+		}
+		list, err := repo.Feeds(c.Request.Context(), u.ID)
+		if err != nil {
+			feedErr(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	me.POST("/feeds", func(c *gin.Context) {
+		u, ok := user(c)
+		if !ok {
+			return
+		}
+		f := Feed{GatherAlarm: true}
+		if err := c.BindJSON(&f); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+			return
+		}
+		f, token, err := repo.CreateFeed(c.Request.Context(), u.ID, f)
+		if err != nil {
+			feedErr(c, err)
+			return
+		}
+		f.URL = feedURL(c, token)
+		c.JSON(http.StatusCreated, f)
+	})
+
+	me.PUT("/feeds/:id", func(c *gin.Context) {
+		u, ok := user(c)
+		if !ok {
+			return
+		}
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		var f Feed
+		if err := c.BindJSON(&f); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+			return
+		}
+		f, err := repo.UpdateFeed(c.Request.Context(), u.ID, id, f)
+		if err != nil {
+			feedErr(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, f)
+	})
+
+	me.POST("/feeds/:id/token", func(c *gin.Context) {
+		u, ok := user(c)
+		if !ok {
+			return
+		}
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		f, token, err := repo.RegenerateFeed(c.Request.Context(), u.ID, id)
+		if err != nil {
+			feedErr(c, err)
+			return
+		}
+		f.URL = feedURL(c, token)
+		c.JSON(http.StatusOK, f)
+	})
+
+	me.DELETE("/feeds/:id", func(c *gin.Context) {
+		u, ok := user(c)
+		if !ok {
+			return
+		}
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err := repo.DeleteFeed(c.Request.Context(), u.ID, id); err != nil {
+			feedErr(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// The feed itself; the token is the only credential, as calendar apps
+	// cannot log in
+	r.GET("/ical/:file", func(c *gin.Context) {
+		token, ok := strings.CutSuffix(c.Param("file"), ".ics")
+		if !ok || token == "" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		ctx := c.Request.Context()
+		f, err := repo.FeedByToken(ctx, token)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		club := cfg.Current(ctx)
+		list, err := repo.FeedMatches(ctx, f, club)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		leagues, _ := cfg.Leagues(ctx)
+		f.LastUsedAt = nil
+		if notModified(c, listETag(list, club, leagues, f)) {
+			return
+		}
+
+		opt := icalOptions{
+			name:     f.Name,
+			alarms:   f.alarmDurations(),
+			matchURL: func(id int64) string { return fmt.Sprintf("%s/board/%d", baseURL(c), id) },
+		}
+		if f.GatherAlarm {
+			opt.gather = repo.gatherResolver(ctx, club)
+		}
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		c.Header("Cache-Control", "private, no-cache")
+		writeICal(c.Writer, list, club, opt)
+	})
+}
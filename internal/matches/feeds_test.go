@@ -0,0 +1,182 @@
+package matches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/auth"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+func TestFeedFilter(t *testing.T) {
+	home := dbpkg.Match{HomeTeam: pstr("IK Sund"), AwayTeam: pstr("Lugi"), League: pstr("P13")}
+	away := dbpkg.Match{HomeTeam: pstr("Lugi"), AwayTeam: pstr("IK Sund"), League: pstr("P15")}
+	cases := []struct {
+		name     string
+		f        FeedFilter
+		ourTeam  string
+		wantHome bool
+		wantAway bool
+	}{
+		{"everything", FeedFilter{}, "", true, true},
+		{"league", FeedFilter{League: "p13"}, "", true, false},
+		{"team", FeedFilter{Team: "lugi"}, "", true, true},
+		{"home games of the team", FeedFilter{Team: "Lugi", Side: "home"}, "", false, true},
+		{"away games of the club", FeedFilter{Side: "away"}, "IK Sund", false, true},
+		{"side without a team", FeedFilter{Side: "home"}, "", false, false},
+	}
+	for _, tc := range cases {
+		if got := tc.f.matches(home, tc.ourTeam); got != tc.wantHome {
+			t.Errorf("%s: home match got %v", tc.name, got)
+		}
+		if got := tc.f.matches(away, tc.ourTeam); got != tc.wantAway {
+			t.Errorf("%s: away match got %v", tc.name, got)
+		}
+	}
+}
+
+func TestRepository_Feeds(t *testing.T) {
+	repo, db := newTestRepo(t)
+	ctx := context.Background()
+	users := auth.NewRepository(db)
+	u, err := users.CreateUser(ctx, "parent@example.com", "x")
+	if err != nil {
+		t.Fatalf("user: %v", err)
+	}
+
+	f, token, err := repo.CreateFeed(ctx, u.ID, Feed{Name: " Lugi ", FeedFilter: FeedFilter{Team: "Lugi"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if f.Name != "Lugi" || len(f.Alarms) != len(DefaultFeedAlarms) || token == "" {
+		t.Fatalf("unexpected feed %+v token=%q", f, token)
+	}
+	got, err := repo.FeedByToken(ctx, token)
+	if err != nil || got.ID != f.ID {
+		t.Fatalf("by token: %+v %v", got, err)
+	}
+
+	_, fresh, err := repo.RegenerateFeed(ctx, u.ID, f.ID)
+	if err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if _, err := repo.FeedByToken(ctx, token); err == nil {
+		t.Fatal("old token should stop working")
+	}
+	if _, err := repo.FeedByToken(ctx, fresh); err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+
+	if _, err := repo.UpdateFeed(ctx, u.ID, f.ID, Feed{Alarms: []int64{-1}}); !errors.Is(err, ErrInvalidFeed) {
+		t.Fatalf("expected ErrInvalidFeed, got %v", err)
+	}
+	if _, err := repo.UpdateFeed(ctx, u.ID, f.ID, Feed{FeedFilter: FeedFilter{Side: "borta"}}); !errors.Is(err, ErrInvalidFeed) {
+		t.Fatalf("expected ErrInvalidFeed for side, got %v", err)
+	}
+	if err := repo.DeleteFeed(ctx, u.ID+1, f.ID); err == nil {
+		t.Fatal("another user must not revoke the feed")
+	}
+	if err := repo.DeleteFeed(ctx, u.ID, f.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.FeedByToken(ctx, fresh); err == nil {
+		t.Fatal("revoked feed should be gone")
+	}
+}
+
+func TestFeedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	ctx := context.Background()
+	users := auth.NewRepository(db)
+	u, _ := users.CreateUser(ctx, "parent@example.com", "x")
+	sess, err := users.CreateSession(ctx, u.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("session: %v", err)
+	}
+	r := gin.New()
+	RegisterFeedRoutes(r, repo, repo.cfg, users)
+
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", HomeTeam: "IK Sund", AwayTeam: "Lugi", GatherTime: "13:45"})
+	repo.Create(ctx, Match{DateRaw: "2025-09-27", TimeRaw: "10:00", HomeTeam: "Lugi", AwayTeam: "H43"})
+
+	do := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Host = "matcher.example.com"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Cookie", auth.CookieName+"="+sess.Token)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/auth/me/feeds", `{"team": "IK Sund", "side": "home", "alarms": [30]}`, "X-Forwarded-Proto", "https")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var f Feed
+	_ = json.Unmarshal(w.Body.Bytes(), &f)
+	if !strings.HasPrefix(f.URL, "https://matcher.example.com/ical/") || !f.GatherAlarm {
+		t.Fatalf("unexpected feed: %+v", f)
+	}
+	path := strings.TrimPrefix(f.URL, "https://matcher.example.com")
+
+	w = do(http.MethodGet, path, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("feed: %d %s", w.Code, w.Body.String())
+	}
+	ics := w.Body.String()
+	for _, want := range []string{
+		"X-WR-CALNAME:Matcher",
+		"UID:match-" + strconv.FormatInt(m.ID, 10) + "@x-matches",
+		"LAST-MODIFIED:",
+		"SEQUENCE:0",
+		"STATUS:CONFIRMED",
+		"URL:http://matcher.example.com/board/" + strconv.FormatInt(m.ID, 10),
+		"TRIGGER:-PT30M",
+		"TRIGGER;VALUE=DATE-TIME:20250920T114500Z",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("feed missing %q:\n%s", want, ics)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 1 {
+		t.Fatalf("expected only the home match:\n%s", ics)
+	}
+	if w := do(http.MethodGet, path, "", "If-None-Match", w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+
+	w = do(http.MethodGet, "/api/auth/me/feeds", "")
+	var list []Feed
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].URL != "" || list[0].LastUsedAt == nil {
+		t.Fatalf("list should hide the token and show the last use: %s", w.Body.String())
+	}
+
+	id := strconv.FormatInt(f.ID, 10)
+	if w := do(http.MethodPost, "/api/auth/me/feeds/"+id+"/token", ""); w.Code != http.StatusOK {
+		t.Fatalf("regenerate: %d", w.Code)
+	}
+	if w := do(http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("old URL should be gone, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/auth/me/feeds/"+id, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/auth/me/feeds/"+id, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("second delete: %d", w.Code)
+	}
+}
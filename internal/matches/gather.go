@@ -0,0 +1,68 @@
+package matches
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// gatherAt resolves when the team meets for m: gather_time, either "HH:MM" on
+// the match date or a full RFC 3339 timestamp, or else offset before start.
+// It is zero when neither is known.
+func gatherAt(m dbpkg.Match, start time.Time, loc *time.Location, offset time.Duration) time.Time {
+	raw := strings.TrimSpace(sval(m.GatherTime))
+	if raw == "" {
+		if offset <= 0 || start.IsZero() {
+			return time.Time{}
+		}
+		return start.Add(-offset)
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t
+	}
+	day := sval(m.DateRaw)
+	if day == "" && !start.IsZero() {
+		day = start.In(loc).Format("2006-01-02")
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04", day+" "+raw, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// gatherResolver returns gatherAt for matches under cfg, with the gather
+// offset of each match's league.
+func (r *Repository) gatherResolver(ctx context.Context, cfg settings.Settings) func(dbpkg.Match) time.Time {
+	return func(m dbpkg.Match) time.Time {
+		start, _ := matchTimes(m, cfg)
+		return gatherAt(m, start, cfg.Location(), r.cfg.League(ctx, sval(m.League)).GatherOffset())
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// apiMatch is the API form of row with its periods and resolved gather time.
+func (r *Repository) apiMatch(ctx context.Context, row dbpkg.Match, periods []Score) Match {
+	m := toAPIWithPeriods(row, periods)
+	m.GatherAt = timePtr(r.gatherResolver(ctx, r.cfg.Current(ctx))(row))
+	return m
+}
+
+// apiList is apiMatch for a list of matches.
+func (r *Repository) apiList(ctx context.Context, list []dbpkg.Match, periods map[int64][]Score) []Match {
+	gather := r.gatherResolver(ctx, r.cfg.Current(ctx))
+	out := toAPIList(list, periods)
+	for i, row := range list {
+		out[i].GatherAt = timePtr(gather(row))
+	}
+	return out
+}
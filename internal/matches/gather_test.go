@@ -0,0 +1,109 @@
+package matches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestGatherAt(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	start := time.Date(2025, 9, 20, 14, 30, 0, 0, loc)
+	cases := []struct {
+		name   string
+		gather string
+		offset time.Duration
+		want   time.Time
+	}{
+		{"clock time on the match date", "13:30", 45 * time.Minute, time.Date(2025, 9, 20, 13, 30, 0, 0, loc)},
+		{"timestamp", "2025-09-20T11:00:00Z", 0, time.Date(2025, 9, 20, 11, 0, 0, 0, time.UTC)},
+		{"league offset", "", 45 * time.Minute, time.Date(2025, 9, 20, 13, 45, 0, 0, loc)},
+		{"none", "", 0, time.Time{}},
+		{"unreadable", "kvart över", 45 * time.Minute, time.Time{}},
+	}
+	for _, tc := range cases {
+		row := dbpkg.Match{DateRaw: pstr("2025-09-20"), GatherTime: pstr(tc.gather)}
+		got := gatherAt(row, start, loc, tc.offset)
+		if !got.Equal(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGatherTimeEndToEnd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, _ := newTestRepo(t)
+	cfg := repo.cfg
+	ctx := context.Background()
+	if _, err := cfg.PutLeague(ctx, settings.LeagueRules{Name: "F16 Syd", Periods: 2, GatherMinutes: 45}); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	RegisterRoutes(r, repo, cfg, nil)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "*")
+		r.ServeHTTP(w, req)
+		return w
+	}
+	w := do(http.MethodPost, "/api/matches", `{"date_raw": "2025-09-20", "time_raw": "14:30", "league": "F16 Syd", "team": "A", "opponent": "B", "gather_time": "13:15", "gather_place": "Entrén"}`)
+	var m Match
+	json.Unmarshal(w.Body.Bytes(), &m)
+	if w.Code != http.StatusCreated || m.GatherTime != "13:15" || m.GatherPlace != "Entrén" ||
+		m.GatherAt == nil || !m.GatherAt.Equal(time.Date(2025, 9, 20, 11, 15, 0, 0, time.UTC)) {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+
+	// Without a gather time the league's offset applies
+	w = do(http.MethodPatch, "/api/matches/1", `{"gather_time": null}`)
+	json.Unmarshal(w.Body.Bytes(), &m)
+	if m.GatherTime != "" || m.GatherAt == nil || !m.GatherAt.Equal(time.Date(2025, 9, 20, 11, 45, 0, 0, time.UTC)) {
+		t.Fatalf("expected the league default: %s", w.Body.String())
+	}
+	repo.Create(ctx, Match{DateRaw: "2025-09-27", TimeRaw: "10:00", League: "Division 3", Team: "A", Opponent: "C"})
+	w = do(http.MethodGet, "/api/matches", "")
+	var list []Match
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list) != 2 || list[0].GatherAt == nil || list[1].GatherAt != nil {
+		t.Fatalf("unexpected gather times in list: %s", w.Body.String())
+	}
+
+	w = do(http.MethodGet, "/api/matches.csv", "")
+	if body := w.Body.String(); !strings.Contains(body, "gather_time,gather_place") || !strings.Contains(body, ",Entrén,") {
+		t.Fatalf("csv missing gather columns:\n%s", body)
+	}
+
+	w = do(http.MethodGet, "/api/matches.ics", "")
+	ics := w.Body.String()
+	for _, want := range []string{"BEGIN:VALARM", "TRIGGER;VALUE=DATE-TIME:20250920T114500Z", "DESCRIPTION:Samling 13:45\\, Entrén", "END:VALARM"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ical missing %q:\n%s", want, ics)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VALARM") != 1 {
+		t.Errorf("expected one alarm, the other match has no gather time:\n%s", ics)
+	}
+}
+
+func TestParseCSV_GatherColumns(t *testing.T) {
+	csv := "Datum;Tid;Hemmalag;Bortalag;Samlingstid;Samlingsplats\r\n" +
+		"2025-11-08;14:30;H43 Lund HF;IK Sund;13:30;Entrén\r\n"
+	rows, err := parseCSV(strings.NewReader(csv), "H43 Lund HF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEq(t, rows[0].GatherTime, "13:30")
+	assertEq(t, rows[0].GatherPlace, "Entrén")
+}
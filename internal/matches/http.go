@@ -1,8 +1,13 @@
 package matches
 
 import (
+	"crypto/rand"
+	"database/sql"
 	"encoding/csv"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +15,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/logging"
+	"github.com/xaitan80/X-Matches/internal/settings"
 )
 
 // ----- Helpers för mapping -----
@@ -32,6 +40,14 @@ func bval(p *int64) bool {
 	return p != nil && *p != 0
 }
 
+// optInt formats a nullable integer, leaving NULL as an empty string.
+func optInt(p *int64) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatInt(*p, 10)
+}
+
 func toAPI(m dbpkg.Match) Match {
 	return Match{
 		ID:                m.ID,
@@ -49,6 +65,8 @@ func toAPI(m dbpkg.Match) Match {
 		Venue:             sval(m.Venue),
 		Court:             sval(m.Court),
 		City:              sval(m.City),
+		GatherTime:        sval(m.GatherTime),
+		GatherPlace:       sval(m.GatherPlace),
 		MatchNumber:       sval(m.MatchNumber),
 		Referees:          sval(m.Referees),
 		Notes:             sval(m.Notes),
@@ -58,17 +76,52 @@ func toAPI(m dbpkg.Match) Match {
 		PlayerNotes:       sval(m.PlayerNotes),
 		TopScorerTeam:     sval(m.TopScorerTeam),
 		TopScorerOpponent: sval(m.TopScorerOpponent),
+		Status:            Status(m.Status),
+		StatusReason:      sval(m.StatusReason),
+		RescheduledTo:     m.RescheduledTo,
+		Sequence:          m.Sequence,
+		Overtime:          scorePtr(m.OvertimeFor, m.OvertimeAgainst),
+		Shootout:          scorePtr(m.ShootoutFor, m.ShootoutAgainst),
+		Version:           m.Version,
+		UpdatedAt:         m.UpdatedAt,
+		CreatedAt:         m.CreatedAt,
 	}
 }
 
-func toAPIList(list []dbpkg.Match) []Match {
+// toAPIWithPeriods maps m and attaches its period scores.
+func toAPIWithPeriods(m dbpkg.Match, periods []Score) Match {
+	out := toAPI(m)
+	out.Periods = periods
+	if out.Periods == nil {
+		out.Periods = []Score{}
+	}
+	return out
+}
+
+func toAPIList(list []dbpkg.Match, periods map[int64][]Score) []Match {
 	out := make([]Match, 0, len(list))
 	for _, m := range list {
-		out = append(out, toAPI(m))
+		out = append(out, toAPIWithPeriods(m, periods[m.ID]))
 	}
 	return out
 }
 
+// formatScores renders period scores as "2-1;1-1".
+func formatScores(list []Score) string {
+	parts := make([]string, 0, len(list))
+	for _, s := range list {
+		parts = append(parts, formatScore(&s))
+	}
+	return strings.Join(parts, ";")
+}
+
+func formatScore(s *Score) string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", s.For, s.Against)
+}
+
 // ----- Request payload -----
 
 type createOrUpdateReq struct {
@@ -84,6 +137,8 @@ type createOrUpdateReq struct {
 	Venue             *string `json:"venue"`
 	Court             *string `json:"court"`
 	City              *string `json:"city"`
+	GatherTime        *string `json:"gather_time"`
+	GatherPlace       *string `json:"gather_place"`
 	MatchNumber       *string `json:"match_number"`
 	Referees          *string `json:"referees"`
 	Notes             *string `json:"notes"`
@@ -95,6 +150,12 @@ type createOrUpdateReq struct {
 	EndISO            *string `json:"end_iso"`
 	TopScorerTeam     *string `json:"top_scorer_team"`
 	TopScorerOpponent *string `json:"top_scorer_opponent"`
+	Status            *string `json:"status"`
+	StatusReason      *string `json:"status_reason"`
+	RescheduledTo     *int64  `json:"rescheduled_to"`
+	Periods           []Score `json:"periods"`
+	Overtime          *Score  `json:"overtime"`
+	Shootout          *Score  `json:"shootout"`
 }
 
 func toDomain(req createOrUpdateReq) Match {
@@ -131,6 +192,8 @@ func toDomain(req createOrUpdateReq) Match {
 		Venue:             val(req.Venue),
 		Court:             val(req.Court),
 		City:              val(req.City),
+		GatherTime:        val(req.GatherTime),
+		GatherPlace:       val(req.GatherPlace),
 		MatchNumber:       val(req.MatchNumber),
 		Referees:          val(req.Referees),
 		Notes:             val(req.Notes),
@@ -140,13 +203,115 @@ func toDomain(req createOrUpdateReq) Match {
 		PlayerNotes:       val(req.PlayerNotes),
 		TopScorerTeam:     val(req.TopScorerTeam),
 		TopScorerOpponent: val(req.TopScorerOpponent),
+		Status:            Status(val(req.Status)),
+		StatusReason:      val(req.StatusReason),
+		RescheduledTo:     req.RescheduledTo,
+		Periods:           req.Periods,
+		Overtime:          req.Overtime,
+		Shootout:          req.Shootout,
+	}
+}
+
+// toReplacement maps a PUT body to a patch that sets every field, so fields
+// left out are cleared. Without a status the match is scheduled, or keeps a
+// finished state when played is true.
+func toReplacement(req createOrUpdateReq) MatchPatch {
+	str := func(p *string) Field[string] {
+		if p == nil {
+			return Null[string]()
+		}
+		return Val(*p)
+	}
+	i64 := func(p *int64) Field[int64] {
+		if p == nil {
+			return Null[int64]()
+		}
+		return Val(*p)
+	}
+	score := func(s *Score) Field[Score] {
+		if s == nil {
+			return Null[Score]()
+		}
+		return Val(*s)
+	}
+	played := req.Played != nil && *req.Played
+	p := MatchPatch{
+		DateRaw:           str(req.DateRaw),
+		TimeRaw:           str(req.TimeRaw),
+		EndTimeRaw:        str(req.EndTimeRaw),
+		Weekday:           str(req.Weekday),
+		League:            str(req.League),
+		Team:              str(req.Team),
+		Opponent:          str(req.Opponent),
+		HomeTeam:          str(req.HomeTeam),
+		AwayTeam:          str(req.AwayTeam),
+		Venue:             str(req.Venue),
+		Court:             str(req.Court),
+		City:              str(req.City),
+		GatherTime:        str(req.GatherTime),
+		GatherPlace:       str(req.GatherPlace),
+		MatchNumber:       str(req.MatchNumber),
+		Referees:          str(req.Referees),
+		Notes:             str(req.Notes),
+		Played:            Val(played),
+		GoalsFor:          i64(req.GoalsFor),
+		GoalsAgainst:      i64(req.GoalsAgainst),
+		PlayerNotes:       str(req.PlayerNotes),
+		TopScorerTeam:     str(req.TopScorerTeam),
+		TopScorerOpponent: str(req.TopScorerOpponent),
+		StatusReason:      str(req.StatusReason),
+		RescheduledTo:     i64(req.RescheduledTo),
+		Periods:           Val(req.Periods),
+		Overtime:          score(req.Overtime),
+		Shootout:          score(req.Shootout),
+	}
+	switch {
+	case req.Status != nil:
+		p.Status = Val(Status(*req.Status))
+	case !played:
+		p.Status = Val(StatusScheduled)
+	}
+	return p
+}
+
+// logMatchID adds the match in the route (:id or :match_id) to the request's log fields.
+func logMatchID(c *gin.Context) {
+	v := c.Param("id")
+	if v == "" {
+		v = c.Param("match_id")
+	}
+	if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "match_id", id))
+	}
+	c.Next()
+}
+
+// newBatchID names an import that runs inline, for correlating its log lines.
+func newBatchID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// errStatus maps repository errors to HTTP status codes.
+func errStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidStatus), errors.Is(err, ErrRescheduledTarget), errors.Is(err, ErrInvalidScore),
+		errors.Is(err, ErrInvalidBulk), errors.Is(err, ErrInvalidSyncToken):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrStatusTransition):
+		return http.StatusConflict
+	case errors.Is(err, ErrVersionMismatch):
+		return http.StatusPreconditionFailed
 	}
+	return http.StatusInternalServerError
 }
 
 // ----- Routes -----
 
-func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
+func RegisterRoutes(r *gin.Engine, repo *Repository, cfg *settings.Service, protect gin.HandlerFunc) {
 	api := r.Group("/api")
+	api.Use(logMatchID)
 	{
 		// Import matches from CSV or XLSX (protected)
 		api.POST("/matches/import", attachProtect(protect, func(c *gin.Context) {
@@ -160,24 +325,69 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 				return
 			}
 
+			// Fall back to the configured team when the caller doesn't name one
 			ourTeam := c.Query("our_team")
+			if ourTeam == "" {
+				ourTeam = cfg.Current(c.Request.Context()).OurTeam
+			}
 
-			rows, err := parseImport(fh, ourTeam)
+			if err := checkImportType(fh.Filename); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			data, err := readUpload(fh)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
 
-			imported := 0
-			var errs []string
-			for idx, m := range rows {
-				if _, err := repo.Create(c.Request.Context(), m); err != nil {
-					errs = append(errs, fmt.Sprintf("row %d: %v", idx+2, err))
-				} else {
-					imported++
+			// The file is read and stored by a worker; the client polls status_url
+			if repo.jobs != nil {
+				id, err := repo.QueueImport(c.Request.Context(), fh.Filename, data, ourTeam)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
 				}
+				slog.InfoContext(logging.With(c.Request.Context(), "import_batch", strconv.FormatInt(id, 10)),
+					"import queued", "file", fh.Filename, "size", len(data))
+				c.JSON(http.StatusAccepted, gin.H{"job_id": id, "status_url": fmt.Sprintf("/api/matches/import/%d", id)})
+				return
 			}
-			c.JSON(http.StatusOK, gin.H{"imported": imported, "failed": len(errs), "errors": errs})
+
+			rows, err := parseImport(c.Request.Context(), fh.Filename, data, ourTeam)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			res := repo.Import(logging.With(c.Request.Context(), "import_batch", newBatchID()), rows)
+			repo.Hub().Publish(c.Request.Context(), Change{Type: ChangeImported, Import: &res})
+			c.JSON(http.StatusOK, gin.H{"imported": res.Imported, "updated": res.Updated, "failed": res.Failed, "errors": res.Errors, "changes": res.Changes})
+		}))
+
+		// Status of a queued import
+		api.GET("/matches/import/:job_id", attachProtect(protect, func(c *gin.Context) {
+			id, _ := strconv.ParseInt(c.Param("job_id"), 10, 64)
+			if repo.jobs == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				return
+			}
+			j, err := repo.jobs.Get(c.Request.Context(), id)
+			if errors.Is(err, jobs.ErrNotFound) || (err == nil && j.Type != JobImport) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			out := gin.H{"job_id": j.ID, "status": j.Status}
+			if j.LastError != "" {
+				out["error"] = j.LastError
+			}
+			if len(j.Result) > 0 {
+				out["result"] = j.Result
+			}
+			c.JSON(http.StatusOK, out)
 		}))
 
 		// Delete all matches (dangerous)
@@ -197,92 +407,16 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 				c.String(http.StatusInternalServerError, err.Error())
 				return
 			}
+			club := cfg.Current(c.Request.Context())
+			// Gather times fall back to the league rules
+			leagues, _ := cfg.Leagues(c.Request.Context())
+			if notModified(c, listETag(list, club, leagues)) {
+				return
+			}
 
 			c.Header("Content-Type", "text/calendar; charset=utf-8")
 			c.Header("Content-Disposition", "attachment; filename=matches.ics")
-
-			w := c.Writer
-			fmt.Fprintln(w, "BEGIN:VCALENDAR")
-			fmt.Fprintln(w, "VERSION:2.0")
-			fmt.Fprintln(w, "PRODID:-//x-matches//EN")
-			fmt.Fprintln(w, "CALSCALE:GREGORIAN")
-
-			now := time.Now().UTC().Format("20060102T150405Z")
-			loc, _ := time.LoadLocation("Europe/Stockholm")
-
-			for _, m := range list {
-				// Compute start/end times
-				var dtStart, dtEnd string
-				// Prefer ISO fields
-				if m.StartIso != nil && *m.StartIso != "" {
-					if t, err := time.Parse(time.RFC3339, *m.StartIso); err == nil {
-						dtStart = t.UTC().Format("20060102T150405Z")
-					}
-				}
-				if m.EndIso != nil && *m.EndIso != "" {
-					if t, err := time.Parse(time.RFC3339, *m.EndIso); err == nil {
-						dtEnd = t.UTC().Format("20060102T150405Z")
-					}
-				}
-				// Fallback from raw date/time in local timezone
-				if dtStart == "" && m.DateRaw != nil {
-					tr := "00:00"
-					if m.TimeRaw != nil && *m.TimeRaw != "" {
-						tr = *m.TimeRaw
-					}
-					if t, err := time.ParseInLocation("2006-01-02 15:04", *m.DateRaw+" "+tr, loc); err == nil {
-						dtStart = t.UTC().Format("20060102T150405Z")
-					}
-				}
-				if dtEnd == "" && m.DateRaw != nil && m.EndTimeRaw != nil && *m.EndTimeRaw != "" {
-					if t, err := time.ParseInLocation("2006-01-02 15:04", *m.DateRaw+" "+*m.EndTimeRaw, loc); err == nil {
-						dtEnd = t.UTC().Format("20060102T150405Z")
-					}
-				}
-
-				// Summary and location
-				team := sval(m.Team)
-				opp := sval(m.Opponent)
-				home := sval(m.HomeTeam)
-				away := sval(m.AwayTeam)
-				var summary string
-				if home != "" || away != "" {
-					summary = fmt.Sprintf("%s vs %s", home, away)
-				} else if team != "" || opp != "" {
-					summary = fmt.Sprintf("%s – %s", team, opp)
-				} else {
-					summary = "Match"
-				}
-				locStr := sval(m.Venue)
-				if city := sval(m.City); city != "" {
-					if locStr != "" {
-						locStr += ", "
-					}
-					locStr += city
-				}
-
-				fmt.Fprintln(w, "BEGIN:VEVENT")
-				fmt.Fprintf(w, "UID:match-%d@x-matches\n", m.ID)
-				fmt.Fprintf(w, "DTSTAMP:%s\n", now)
-				if dtStart != "" {
-					fmt.Fprintf(w, "DTSTART:%s\n", dtStart)
-				}
-				if dtEnd != "" {
-					fmt.Fprintf(w, "DTEND:%s\n", dtEnd)
-				}
-				// Escape commas and semicolons per RFC
-				esc := func(s string) string { return strings.NewReplacer(",", "\\,", ";", "\\;", "\n", "\\n").Replace(s) }
-				fmt.Fprintf(w, "SUMMARY:%s\n", esc(summary))
-				if locStr != "" {
-					fmt.Fprintf(w, "LOCATION:%s\n", esc(locStr))
-				}
-				if n := sval(m.Notes); n != "" {
-					fmt.Fprintf(w, "DESCRIPTION:%s\n", esc(n))
-				}
-				fmt.Fprintln(w, "END:VEVENT")
-			}
-
-			fmt.Fprintln(w, "END:VCALENDAR")
+			writeICal(c.Writer, list, club, icalOptions{gather: repo.gatherResolver(c.Request.Context(), club)})
 		})
 
 		// CSV export of all matches
@@ -292,6 +426,14 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 				c.String(http.StatusInternalServerError, err.Error())
 				return
 			}
+			if notModified(c, listETag(list)) {
+				return
+			}
+			periods, err := repo.AllPeriods(c.Request.Context())
+			if err != nil {
+				c.String(http.StatusInternalServerError, err.Error())
+				return
+			}
 
 			filename := fmt.Sprintf("matches_%s.csv", time.Now().Format("2006-01-02"))
 			c.Header("Content-Type", "text/csv; charset=utf-8")
@@ -304,10 +446,13 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 				"date_raw", "time_raw", "end_time_raw", "weekday",
 				"league", "team", "opponent", "home_team", "away_team",
 				"venue", "court", "city",
+				"gather_time", "gather_place",
 				"match_number", "referees", "notes",
 				"played", "goals_for", "goals_against", "player_notes",
 				"top_scorer_team", "top_scorer_opponent",
 				"start_iso", "end_iso",
+				"status", "status_reason", "rescheduled_to",
+				"periods", "overtime", "shootout",
 			})
 			// Rows
 			for _, m := range list {
@@ -316,6 +461,7 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 					sval(m.DateRaw), sval(m.TimeRaw), sval(m.EndTimeRaw), sval(m.Weekday),
 					sval(m.League), sval(m.Team), sval(m.Opponent), sval(m.HomeTeam), sval(m.AwayTeam),
 					sval(m.Venue), sval(m.Court), sval(m.City),
+					sval(m.GatherTime), sval(m.GatherPlace),
 					sval(m.MatchNumber), sval(m.Referees), sval(m.Notes),
 					strconv.FormatBool(bval(m.Played)),
 					strconv.FormatInt(ival(m.GoalsFor), 10),
@@ -323,6 +469,10 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 					sval(m.PlayerNotes),
 					sval(m.TopScorerTeam), sval(m.TopScorerOpponent),
 					sval(m.StartIso), sval(m.EndIso),
+					m.Status, sval(m.StatusReason), optInt(m.RescheduledTo),
+					formatScores(periods[m.ID]),
+					formatScore(scorePtr(m.OvertimeFor, m.OvertimeAgainst)),
+					formatScore(scorePtr(m.ShootoutFor, m.ShootoutAgainst)),
 				})
 			}
 			w.Flush()
@@ -338,7 +488,26 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(http.StatusOK, toAPIList(list))
+			leagues, _ := cfg.Leagues(c.Request.Context())
+			if notModified(c, listETag(list, cfg.Current(c.Request.Context()).Timezone, leagues)) {
+				return
+			}
+			periods, err := repo.AllPeriods(c.Request.Context())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, repo.apiList(c.Request.Context(), list, periods))
+		})
+
+		// Standings table computed from played matches, optionally for one league
+		api.GET("/standings", func(c *gin.Context) {
+			list, err := repo.List(c.Request.Context())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, computeStandings(list, c.Query("league"), cfg.Current(c.Request.Context())))
 		})
 
 		api.GET("/matches/:id", func(c *gin.Context) {
@@ -348,7 +517,10 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 				return
 			}
-			c.JSON(http.StatusOK, toAPI(m))
+			if notModified(c, matchETag(m.Version)) {
+				return
+			}
+			c.JSON(http.StatusOK, withPeriods(c, repo, m))
 		})
 
 		api.POST("/matches", attachProtect(protect, func(c *gin.Context) {
@@ -359,36 +531,104 @@ func RegisterRoutes(r *gin.Engine, repo *Repository, protect gin.HandlerFunc) {
 			}
 			row, err := repo.Create(c.Request.Context(), toDomain(req))
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.JSON(errStatus(err), gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(http.StatusCreated, toAPI(row))
+			c.JSON(http.StatusCreated, withPeriods(c, repo, row))
 		}))
 
+		// Merge patch (RFC 7396): absent fields are kept, null clears
 		api.PATCH("/matches/:id", attachProtect(protect, func(c *gin.Context) {
 			id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+			version, ok := currentVersion(c, repo, id)
+			if !ok {
+				return
+			}
+			var p MatchPatch
+			if err := c.BindJSON(&p); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+				return
+			}
+			row, err := repo.PatchAt(c.Request.Context(), id, version, p)
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(errStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.Header("ETag", matchETag(row.Version))
+			c.JSON(http.StatusOK, withPeriods(c, repo, row))
+		}))
+
+		// Full replacement: fields left out are cleared
+		api.PUT("/matches/:id", attachProtect(protect, func(c *gin.Context) {
+			id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+			version, ok := currentVersion(c, repo, id)
+			if !ok {
+				return
+			}
 			var req createOrUpdateReq
 			if err := c.BindJSON(&req); err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
 				return
 			}
-			row, err := repo.Update(c.Request.Context(), id, toDomain(req))
+			row, err := repo.PatchAt(c.Request.Context(), id, version, toReplacement(req))
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				return
+			}
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.JSON(errStatus(err), gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(http.StatusOK, toAPI(row))
+			c.Header("ETag", matchETag(row.Version))
+			c.JSON(http.StatusOK, withPeriods(c, repo, row))
 		}))
 
 		api.DELETE("/matches/:id", attachProtect(protect, func(c *gin.Context) {
 			id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
-			if err := repo.Delete(c.Request.Context(), id); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			version, ok := currentVersion(c, repo, id)
+			if !ok {
+				return
+			}
+			if err := repo.DeleteAt(c.Request.Context(), id, version); err != nil {
+				c.JSON(errStatus(err), gin.H{"error": err.Error()})
 				return
 			}
 			c.Status(http.StatusNoContent)
 		}))
+
+		registerEventRoutes(api, repo, protect)
+		registerVersionRoutes(api, repo, protect)
+		registerTrashRoutes(api, repo, protect)
+		registerBulkRoutes(api, repo, protect)
+		registerSyncRoutes(api, repo, protect)
+		registerStreamRoutes(api, repo.Hub())
+		registerBoardRoutes(api, repo, cfg, protect)
+	}
+}
+
+// currentVersion looks up match id and checks the request's If-Match against
+// it, answering 404, 428 or 412 when the write must not go ahead.
+func currentVersion(c *gin.Context, repo *Repository, id int64) (int64, bool) {
+	cur, err := repo.Get(c.Request.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
 	}
+	return ifMatch(c, cur.Version)
+}
+
+// withPeriods maps m for the API with its period scores loaded.
+func withPeriods(c *gin.Context, repo *Repository, m dbpkg.Match) Match {
+	periods, _ := repo.Periods(c.Request.Context(), m.ID)
+	return repo.apiMatch(c.Request.Context(), m, periods)
 }
 
 // attachProtect conditionally wraps handlers with the given protect middleware for mutating routes.
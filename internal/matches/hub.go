@@ -0,0 +1,121 @@
+package matches
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Change types published by the repository.
+const (
+	ChangeCreated    = "match.created"
+	ChangeUpdated    = "match.updated"
+	ChangeDeleted    = "match.deleted"
+	ChangeAllDeleted = "matches.deleted"
+	ChangeLiveEvent  = "match.event"
+	ChangeResult     = "match.result"
+	ChangeImported   = "import.completed"
+)
+
+// Change describes a committed mutation. Match holds the state after the change
+// and is nil for deletions; Event is set for live timeline changes and Board
+// for scoreboard clock changes. MatchIDs lists the matches a wiped schedule held.
+type Change struct {
+	Type     string        `json:"type"`
+	MatchID  int64         `json:"match_id,omitempty"`
+	MatchIDs []int64       `json:"match_ids,omitempty"`
+	Match    *Match        `json:"match,omitempty"`
+	Event    *Event        `json:"event,omitempty"`
+	Board    *Board        `json:"board,omitempty"`
+	Import   *ImportResult `json:"import,omitempty"`
+}
+
+// ImportResult summarises a finished import. Imported counts new matches and
+// Updated those matched by match number.
+type ImportResult struct {
+	Imported int              `json:"imported"`
+	Updated  int              `json:"updated"`
+	Failed   int              `json:"failed"`
+	Errors   []string         `json:"errors,omitempty"`
+	Changes  []ScheduleChange `json:"changes,omitempty"`
+	Duration time.Duration    `json:"-"` // time spent storing the rows
+}
+
+// Hub fans out changes to subscribers. Slow channel subscribers miss changes
+// rather than blocking the writer; listeners are called synchronously and see
+// every change.
+type Hub struct {
+	mu        sync.Mutex
+	next      int
+	subs      map[int]chan Change
+	listeners []func(context.Context, Change)
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewHub() *Hub { return &Hub{subs: map[int]chan Change{}, done: make(chan struct{})} }
+
+// Close tells long-lived subscribers such as event streams to hang up, so the
+// HTTP server can finish shutting down. Publishing keeps working.
+func (h *Hub) Close() { h.closeOnce.Do(func() { close(h.done) }) }
+
+// Done is closed by Close.
+func (h *Hub) Done() <-chan struct{} { return h.done }
+
+// Subscribe returns a channel of changes and a function that unsubscribes and closes it.
+func (h *Hub) Subscribe() (<-chan Change, func()) {
+	ch := make(chan Change, 32)
+	h.mu.Lock()
+	id := h.next
+	h.next++
+	h.subs[id] = ch
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, id)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Listen registers fn to be called for every change, in publish order. fn runs
+// on the publisher's goroutine and should be quick (e.g. queue work). Its ctx
+// is the publisher's without the cancellation, so the trace, request id and
+// actor carry over even when the client has already gone.
+func (h *Hub) Listen(fn func(context.Context, Change)) {
+	h.mu.Lock()
+	h.listeners = append(h.listeners, fn)
+	h.mu.Unlock()
+}
+
+// Publish delivers c to every subscriber without blocking.
+func (h *Hub) Publish(ctx context.Context, c Change) {
+	h.mu.Lock()
+	listeners := h.listeners
+	h.mu.Unlock()
+	ctx = context.WithoutCancel(ctx)
+	for _, fn := range listeners {
+		fn(ctx, c)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subs {
+		select {
+		case ch <- c:
+		default:
+		}
+	}
+}
+
+// Subscribers reports the number of connected subscribers.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
@@ -0,0 +1,378 @@
+package matches
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+const icalStamp = "20060102T150405Z"
+
+// icalEscape escapes commas, semicolons and newlines per RFC 5545.
+func icalEscape(s string) string {
+	return strings.NewReplacer(",", "\\,", ";", "\\;", "\n", "\\n").Replace(s)
+}
+
+// matchTimes resolves start and end for a match. ISO fields win; otherwise the raw
+// date/time is read in cfg's timezone. A missing end is derived from the default
+// match duration.
+func matchTimes(m dbpkg.Match, cfg settings.Settings) (start, end time.Time) {
+	loc := cfg.Location()
+	if m.StartIso != nil && *m.StartIso != "" {
+		if t, err := time.Parse(time.RFC3339, *m.StartIso); err == nil {
+			start = t
+		}
+	}
+	if m.EndIso != nil && *m.EndIso != "" {
+		if t, err := time.Parse(time.RFC3339, *m.EndIso); err == nil {
+			end = t
+		}
+	}
+	// Fallback from raw date/time in local timezone
+	if start.IsZero() && m.DateRaw != nil {
+		tr := "00:00"
+		if m.TimeRaw != nil && *m.TimeRaw != "" {
+			tr = *m.TimeRaw
+		}
+		if t, err := time.ParseInLocation("2006-01-02 15:04", *m.DateRaw+" "+tr, loc); err == nil {
+			start = t
+		}
+	}
+	if end.IsZero() && m.DateRaw != nil && m.EndTimeRaw != nil && *m.EndTimeRaw != "" {
+		if t, err := time.ParseInLocation("2006-01-02 15:04", *m.DateRaw+" "+*m.EndTimeRaw, loc); err == nil {
+			end = t
+		}
+	}
+	if end.IsZero() && !start.IsZero() {
+		end = start.Add(cfg.MatchDuration())
+	}
+	return start, end
+}
+
+func matchSummary(m dbpkg.Match) string {
+	team := sval(m.Team)
+	opp := sval(m.Opponent)
+	home := sval(m.HomeTeam)
+	away := sval(m.AwayTeam)
+	if home != "" || away != "" {
+		return fmt.Sprintf("%s vs %s", home, away)
+	}
+	if team != "" || opp != "" {
+		return fmt.Sprintf("%s – %s", team, opp)
+	}
+	return "Match"
+}
+
+func matchLocation(m dbpkg.Match) string {
+	locStr := sval(m.Venue)
+	if city := sval(m.City); city != "" {
+		if locStr != "" {
+			locStr += ", "
+		}
+		locStr += city
+	}
+	return locStr
+}
+
+// matchDescription combines notes with the reason for a postponement or cancellation.
+func matchDescription(m dbpkg.Match) string {
+	desc := sval(m.Notes)
+	if reason := sval(m.StatusReason); reason != "" && Status(m.Status) != StatusScheduled {
+		if desc != "" {
+			desc += "\n"
+		}
+		desc += reason
+	}
+	return desc
+}
+
+// icalStatus maps a match status to the VEVENT STATUS property.
+func icalStatus(s Status) string {
+	switch s {
+	case StatusCancelled:
+		return "CANCELLED"
+	case StatusPostponed:
+		return "TENTATIVE"
+	}
+	return "CONFIRMED"
+}
+
+// gatherAlarm describes the gather time and place for a VALARM.
+func gatherAlarm(m dbpkg.Match, at time.Time, loc *time.Location) string {
+	desc := "Samling " + at.In(loc).Format("15:04")
+	if place := sval(m.GatherPlace); place != "" {
+		desc += ", " + place
+	}
+	return desc
+}
+
+// EventUID is the iCal UID of a match's event, the same in every calendar.
+func EventUID(id int64) string {
+	return fmt.Sprintf("match-%d@x-matches", id)
+}
+
+// MatchIDFromUID returns the match an event UID belongs to.
+func MatchIDFromUID(uid string) (int64, bool) {
+	s, ok := strings.CutPrefix(uid, "match-")
+	if !ok {
+		return 0, false
+	}
+	if s, ok = strings.CutSuffix(s, "@x-matches"); !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	return id, err == nil && id > 0
+}
+
+// icalOptions tunes a calendar rendered by writeICal.
+type icalOptions struct {
+	name     string                      // X-WR-CALNAME; the club name if empty
+	gather   func(dbpkg.Match) time.Time // when the team meets; nil for no gather alarm
+	alarms   []time.Duration             // reminders before kick-off
+	matchURL func(id int64) string       // link back to a match; nil for none
+}
+
+// writeICal renders list as a VCALENDAR. Scheduled matches get an alarm at
+// the gather time and at each of opt.alarms before kick-off.
+func writeICal(w io.Writer, list []dbpkg.Match, cfg settings.Settings, opt icalOptions) {
+	fmt.Fprintln(w, "BEGIN:VCALENDAR")
+	fmt.Fprintln(w, "VERSION:2.0")
+	fmt.Fprintln(w, "PRODID:-//x-matches//EN")
+	fmt.Fprintln(w, "CALSCALE:GREGORIAN")
+	name := opt.name
+	if name == "" {
+		name = cfg.ClubName
+	}
+	if name != "" {
+		fmt.Fprintf(w, "X-WR-CALNAME:%s\n", icalEscape(name))
+	}
+	fmt.Fprintf(w, "X-WR-TIMEZONE:%s\n", cfg.Timezone)
+
+	now := time.Now().UTC()
+	for _, m := range list {
+		writeVEvent(w, m, cfg, opt, now)
+	}
+
+	fmt.Fprintln(w, "END:VCALENDAR")
+}
+
+// writeVEvent renders m as a VEVENT stamped at now.
+func writeVEvent(w io.Writer, m dbpkg.Match, cfg settings.Settings, opt icalOptions, now time.Time) {
+	start, end := matchTimes(m, cfg)
+
+	fmt.Fprintln(w, "BEGIN:VEVENT")
+	fmt.Fprintf(w, "UID:%s\n", EventUID(m.ID))
+	fmt.Fprintf(w, "DTSTAMP:%s\n", now.UTC().Format(icalStamp))
+	if m.UpdatedAt != nil {
+		fmt.Fprintf(w, "LAST-MODIFIED:%s\n", m.UpdatedAt.UTC().Format(icalStamp))
+	}
+	fmt.Fprintf(w, "SEQUENCE:%d\n", m.Sequence)
+	fmt.Fprintf(w, "STATUS:%s\n", icalStatus(Status(m.Status)))
+	if !start.IsZero() {
+		fmt.Fprintf(w, "DTSTART:%s\n", start.UTC().Format(icalStamp))
+	}
+	if !end.IsZero() {
+		fmt.Fprintf(w, "DTEND:%s\n", end.UTC().Format(icalStamp))
+	}
+	fmt.Fprintf(w, "SUMMARY:%s\n", icalEscape(matchSummary(m)))
+	if locStr := matchLocation(m); locStr != "" {
+		fmt.Fprintf(w, "LOCATION:%s\n", icalEscape(locStr))
+	}
+	if desc := matchDescription(m); desc != "" {
+		fmt.Fprintf(w, "DESCRIPTION:%s\n", icalEscape(desc))
+	}
+	if opt.matchURL != nil {
+		fmt.Fprintf(w, "URL:%s\n", opt.matchURL(m.ID))
+	}
+	if Status(m.Status) == StatusScheduled {
+		if opt.gather != nil {
+			if at := opt.gather(m); !at.IsZero() {
+				fmt.Fprintln(w, "BEGIN:VALARM")
+				fmt.Fprintln(w, "ACTION:DISPLAY")
+				fmt.Fprintf(w, "TRIGGER;VALUE=DATE-TIME:%s\n", at.UTC().Format(icalStamp))
+				fmt.Fprintf(w, "DESCRIPTION:%s\n", icalEscape(gatherAlarm(m, at, cfg.Location())))
+				fmt.Fprintln(w, "END:VALARM")
+			}
+		}
+		if !start.IsZero() {
+			for _, before := range opt.alarms {
+				fmt.Fprintln(w, "BEGIN:VALARM")
+				fmt.Fprintln(w, "ACTION:DISPLAY")
+				fmt.Fprintf(w, "TRIGGER:-PT%dM\n", int64(before/time.Minute))
+				fmt.Fprintf(w, "DESCRIPTION:%s\n", icalEscape(matchSummary(m)+" "+start.In(cfg.Location()).Format("15:04")))
+				fmt.Fprintln(w, "END:VALARM")
+			}
+		}
+	}
+	fmt.Fprintln(w, "END:VEVENT")
+}
+
+// CalendarEvent is the part of a VEVENT that maps onto a match.
+type CalendarEvent struct {
+	UID          string
+	Summary      string
+	Location     string
+	Description  string
+	Status       string
+	Sequence     int64
+	Start, End   time.Time
+	LastModified time.Time
+}
+
+// icalLines splits a calendar into content lines, unfolding continuation
+// lines (RFC 5545 3.1).
+func icalLines(data string) []string {
+	var out []string
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(out) > 0 {
+			out[len(out)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// icalProp splits a content line into its upper-cased name, parameters and
+// value.
+func icalProp(line string) (name string, params map[string]string, value string) {
+	// The value starts at the first colon outside a quoted parameter
+	quoted, colon := false, -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+	parts := strings.Split(line[:colon], ";")
+	params = map[string]string{}
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// icalUnescape reverses icalEscape.
+func icalUnescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseICalTime reads a DATE or DATE-TIME value. Times without a zone are
+// read in their TZID, or in loc.
+func parseICalTime(value string, params map[string]string, loc *time.Location) (time.Time, error) {
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	switch {
+	case params["VALUE"] == "DATE" || len(value) == 8:
+		return time.ParseInLocation("20060102", value, loc)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(icalStamp, value)
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+// parseICalDuration reads a DURATION value such as PT1H30M.
+func parseICalDuration(s string) (time.Duration, bool) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, false
+	}
+	var d time.Duration
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	n := 0
+	for i := 1; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == 'T':
+		case ch >= '0' && ch <= '9':
+			n = n*10 + int(ch-'0')
+		case units[ch] != 0:
+			d += time.Duration(n) * units[ch]
+			n = 0
+		default:
+			return 0, false
+		}
+	}
+	if neg {
+		d = -d
+	}
+	return d, true
+}
+
+// ParseCalendarEvents reads the VEVENTs of a calendar; components nested in an
+// event, such as alarms, are skipped.
+func ParseCalendarEvents(data string, loc *time.Location) ([]CalendarEvent, error) {
+	var out []CalendarEvent
+	var ev *CalendarEvent
+	var dur time.Duration
+	nested := 0
+	for _, line := range icalLines(data) {
+		name, params, value := icalProp(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			ev, dur, nested = &CalendarEvent{}, 0, 0
+			continue
+		case ev == nil:
+			continue
+		case name == "BEGIN":
+			nested++
+			continue
+		case name == "END" && nested > 0:
+			nested--
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if ev.End.IsZero() && !ev.Start.IsZero() && dur > 0 {
+				ev.End = ev.Start.Add(dur)
+			}
+			out = append(out, *ev)
+			ev = nil
+			continue
+		case nested > 0:
+			continue
+		}
+		var err error
+		switch name {
+		case "UID":
+			ev.UID = value
+		case "SUMMARY":
+			ev.Summary = icalUnescape(value)
+		case "LOCATION":
+			ev.Location = icalUnescape(value)
+		case "DESCRIPTION":
+			ev.Description = icalUnescape(value)
+		case "STATUS":
+			ev.Status = strings.ToUpper(value)
+		case "SEQUENCE":
+			ev.Sequence, _ = strconv.ParseInt(value, 10, 64)
+		case "DTSTART":
+			ev.Start, err = parseICalTime(value, params, loc)
+		case "DTEND":
+			ev.End, err = parseICalTime(value, params, loc)
+		case "DURATION":
+			dur, _ = parseICalDuration(value)
+		case "LAST-MODIFIED":
+			ev.LastModified, _ = parseICalTime(value, params, time.UTC)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return out, nil
+}
@@ -3,41 +3,214 @@ package matches
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/xaitan80/X-Matches/internal/audit"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/logging"
 	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// parseImport reads a CSV or XLSX file from a multipart form file and returns a slice of Match.
-func parseImport(fh *multipart.FileHeader, ourTeam string) ([]Match, error) {
-	ext := strings.ToLower(filepath.Ext(fh.Filename))
+var tracer = otel.Tracer("github.com/xaitan80/X-Matches/internal/matches")
+
+// JobImport is the job type that reads and stores an uploaded schedule.
+const JobImport = "matches.import"
+
+// maxImportSize caps an uploaded schedule; the whole file is kept in memory
+// and in import_uploads until the job has read it.
+const maxImportSize = 10 << 20
+
+var errImportTooLarge = errors.New("file too large")
+
+// importJob is the payload of a JobImport: the upload to read, not its rows,
+// so a large schedule stays out of the jobs table.
+type importJob struct {
+	UploadID int64  `json:"upload_id"`
+	OurTeam  string `json:"our_team"`
+}
+
+// UseJobs runs imports on q. The upload handler then answers with a job id
+// right away instead of holding the request open while the file is read and
+// every row is stored.
+func (r *Repository) UseJobs(q *jobs.Queue) {
+	r.jobs = q
+	q.Handle(JobImport, func(ctx context.Context, j jobs.Job) (any, error) {
+		var p importJob
+		if err := j.Decode(&p); err != nil {
+			return nil, jobs.Permanent(err)
+		}
+		up, err := r.q.GetImportUpload(ctx, p.UploadID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, jobs.Permanent(fmt.Errorf("upload %d is gone", p.UploadID))
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Imports run once, so the file is not needed whatever happens next
+		defer func() {
+			if err := r.q.DeleteImportUpload(context.WithoutCancel(ctx), up.ID); err != nil {
+				slog.ErrorContext(ctx, "import: delete upload", "upload_id", up.ID, "err", err)
+			}
+		}()
+		ctx = logging.With(ctx, "import_batch", strconv.FormatInt(j.ID, 10))
+		rows, err := parseImport(ctx, up.Filename, up.Data, p.OurTeam)
+		if err != nil {
+			return nil, jobs.Permanent(err)
+		}
+		res := r.Import(ctx, rows)
+		r.hub.Publish(ctx, Change{Type: ChangeImported, Import: &res})
+		return res, nil
+	})
+}
+
+// QueueImport stores an uploaded schedule and queues a job that reads and
+// imports it, returning the job id. The file is only checked for its type here.
+func (r *Repository) QueueImport(ctx context.Context, filename string, data []byte, ourTeam string) (int64, error) {
+	if err := checkImportType(filename); err != nil {
+		return 0, err
+	}
+	uploadID, err := r.q.CreateImportUpload(ctx, dbpkg.CreateImportUploadParams{Filename: filename, Data: data})
+	if err != nil {
+		return 0, err
+	}
+	id, err := r.jobs.Enqueue(ctx, JobImport, importJob{UploadID: uploadID, OurTeam: ourTeam}, jobs.MaxAttempts(1))
+	if err != nil {
+		if derr := r.q.DeleteImportUpload(ctx, uploadID); derr != nil {
+			slog.ErrorContext(ctx, "import: delete upload", "upload_id", uploadID, "err", derr)
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+// ScheduleChange records a match whose time or place moved during a re-import.
+type ScheduleChange struct {
+	MatchID int64 `json:"match_id"`
+	Before  Match `json:"before"`
+	After   Match `json:"after"`
+}
+
+// scheduleMoved reports whether date, time or place differ between a and b.
+func scheduleMoved(a, b Match) bool {
+	return a.DateRaw != b.DateRaw || a.TimeRaw != b.TimeRaw || a.Venue != b.Venue ||
+		a.Court != b.Court || a.City != b.City
+}
+
+// Import stores parsed rows. A row whose match number already exists updates
+// that match instead of creating a duplicate, so a federation schedule can be
+// re-imported; moved matches are listed in the result's Changes.
+func (r *Repository) Import(ctx context.Context, rows []Match) ImportResult {
+	ctx, span := tracer.Start(ctx, "matches.Import", trace.WithAttributes(attribute.Int("import.rows", len(rows))))
+	defer span.End()
+	var res ImportResult
+	start := time.Now()
+	for idx, m := range rows {
+		line := idx + 2 // header is line 1
+		if m.MatchNumber != "" {
+			cur, err := r.q.GetMatchByNumber(ctx, &m.MatchNumber)
+			if err == nil {
+				row, err := r.Update(ctx, cur.ID, m)
+				if err != nil {
+					res.Errors = append(res.Errors, fmt.Sprintf("row %d: %v", line, err))
+					continue
+				}
+				res.Updated++
+				if before, after := toAPI(cur), toAPI(row); scheduleMoved(before, after) {
+					res.Changes = append(res.Changes, ScheduleChange{MatchID: row.ID, Before: before, After: after})
+				}
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				res.Errors = append(res.Errors, fmt.Sprintf("row %d: %v", line, err))
+				continue
+			}
+		}
+		if _, err := r.Create(ctx, m); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("row %d: %v", line, err))
+			continue
+		}
+		res.Imported++
+	}
+	res.Failed = len(res.Errors)
+	res.Duration = time.Since(start)
+	span.SetAttributes(attribute.Int("import.imported", res.Imported), attribute.Int("import.updated", res.Updated),
+		attribute.Int("import.failed", res.Failed))
+	slog.InfoContext(ctx, "import finished", "rows", len(rows), "imported", res.Imported, "updated", res.Updated,
+		"failed", res.Failed, "duration_ms", res.Duration.Milliseconds())
+	// Each stored row has its own match.create/match.update entry; this one ties them together
+	summary := map[string]any{"rows": len(rows), "imported": res.Imported, "updated": res.Updated, "failed": res.Failed}
+	if err := audit.Record(ctx, r.db, AuditImport, audit.EntityMatch, 0, nil, summary); err != nil {
+		slog.ErrorContext(ctx, "audit: import", "err", err)
+	}
+	return res
+}
+
+// checkImportType rejects files that are neither CSV nor XLSX by their name.
+func checkImportType(filename string) error {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".csv", ".xlsx":
+		return nil
+	default:
+		return fmt.Errorf("unsupported file type: %s", ext)
+	}
+}
+
+// readUpload returns the contents of a multipart form file, up to maxImportSize.
+func readUpload(fh *multipart.FileHeader) ([]byte, error) {
 	file, err := fh.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
+	b, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxImportSize {
+		return nil, errImportTooLarge
+	}
+	return b, nil
+}
 
-	switch ext {
-	case ".csv":
-		return parseCSV(file, ourTeam)
-	case ".xlsx":
-		// excelize needs a path or bytes; we can read into memory (reasonable for small files).
-		// To avoid large memory, cap at ~10MB by streaming into a buffer.
-		b, err := io.ReadAll(io.LimitReader(file, 10<<20))
+// parseImport reads a CSV or XLSX file and returns a slice of Match.
+func parseImport(ctx context.Context, filename string, data []byte, ourTeam string) (rows []Match, err error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	ctx, span := tracer.Start(ctx, "matches.parseImport", trace.WithAttributes(
+		attribute.String("import.file", filename),
+		attribute.Int("import.size", len(data)),
+		attribute.String("import.format", strings.TrimPrefix(ext, "."))))
+	defer func() {
+		span.SetAttributes(attribute.Int("import.rows", len(rows)))
 		if err != nil {
-			return nil, err
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 		}
-		return parseXLSX(b, ourTeam)
-	default:
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
+		span.End()
+	}()
+	if err := checkImportType(filename); err != nil {
+		return nil, err
+	}
+	if ext == ".xlsx" {
+		return parseXLSX(ctx, data, ourTeam)
 	}
+	return parseCSV(bytes.NewReader(data), ourTeam)
 }
 
 func parseCSV(r io.Reader, ourTeam string) ([]Match, error) {
@@ -69,9 +242,13 @@ func parseCSV(r io.Reader, ourTeam string) ([]Match, error) {
 	return out, nil
 }
 
-func parseXLSX(b []byte, ourTeam string) ([]Match, error) {
+func parseXLSX(ctx context.Context, b []byte, ourTeam string) ([]Match, error) {
+	// Opening unzips and parses the shared strings, reading the sheet parses its XML;
+	// separate spans show which of the two a slow file spends its time in
+	_, span := tracer.Start(ctx, "xlsx.open")
 	// Use bytes.Reader to provide Reader, ReaderAt, and Seeker which excelize can leverage
 	f, err := excelize.OpenReader(bytes.NewReader(b))
+	span.End()
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +257,10 @@ func parseXLSX(b []byte, ourTeam string) ([]Match, error) {
 	if sheet == "" {
 		return nil, fmt.Errorf("no sheet")
 	}
+	_, span = tracer.Start(ctx, "xlsx.rows", trace.WithAttributes(attribute.String("xlsx.sheet", sheet)))
 	rows, err := f.GetRows(sheet)
+	span.SetAttributes(attribute.Int("xlsx.rows", len(rows)))
+	span.End()
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +315,7 @@ func normHeaders(hdr []string) map[int]string {
 			k = "court"
 		case "samlingstid":
 			k = "gathertime"
-		case "samlingplats":
+		case "samlingplats", "samlingsplats":
 			k = "gatherplace"
 		case "noteringar", "notis":
 			k = "notes"
@@ -159,6 +339,14 @@ func normHeaders(hdr []string) map[int]string {
 			k = "weekday"
 		case "resultat":
 			k = "result"
+		case "matchstatus":
+			k = "status"
+		case "perioder", "periodresultat", "halvlekar":
+			k = "periods"
+		case "forlangning":
+			k = "overtime"
+		case "straffar", "strafflaggning", "straffslaggning":
+			k = "shootout"
 		}
 		m[i] = k
 	}
@@ -206,6 +394,8 @@ func rowToMatch(h map[int]string, row []string, ourTeam string) Match {
 		Venue:             venue,
 		Court:             get("court"),
 		City:              get("city"),
+		GatherTime:        get("gathertime"),
+		GatherPlace:       get("gatherplace"),
 		MatchNumber:       get("matchnumber"),
 		Referees:          get("referees"),
 		Notes:             get("notes"),
@@ -267,6 +457,19 @@ func rowToMatch(h map[int]string, row []string, ourTeam string) Match {
 			}
 		}
 	}
+	if st := parseStatus(get("status")); st != "" {
+		m.Status = st
+	}
+	// Period results like "2-1;1-1", overtime and shootout like "1-0"
+	if ps := parseScores(get("periods")); len(ps) > 0 {
+		m.Periods = ps
+	}
+	if ps := parseScores(get("overtime")); len(ps) == 1 {
+		m.Overtime = &ps[0]
+	}
+	if ps := parseScores(get("shootout")); len(ps) == 1 {
+		m.Shootout = &ps[0]
+	}
 	// Optional ISO columns
 	if s := get("startiso"); s != "" {
 		m.StartISO = &s
@@ -276,3 +479,39 @@ func rowToMatch(h map[int]string, row []string, ourTeam string) Match {
 	}
 	return m
 }
+
+// parseScores reads "2-1;1-1" (also comma or space separated) into scores.
+// Parts that don't look like a score are skipped.
+func parseScores(s string) []Score {
+	var out []Score
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+		a, b, ok := strings.Cut(part, "-")
+		if !ok {
+			continue
+		}
+		gf, err1 := strconv.ParseInt(strings.TrimSpace(a), 10, 64)
+		ga, err2 := strconv.ParseInt(strings.TrimSpace(b), 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		out = append(out, Score{For: gf, Against: ga})
+	}
+	return out
+}
+
+// parseStatus maps English and Swedish status words to a Status; unknown values yield "".
+func parseStatus(s string) Status {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "scheduled", "planerad":
+		return StatusScheduled
+	case "postponed", "uppskjuten", "flyttad":
+		return StatusPostponed
+	case "cancelled", "canceled", "inställd", "installd":
+		return StatusCancelled
+	case "played", "spelad", "färdigspelad":
+		return StatusPlayed
+	case "walkover", "wo", "w.o.":
+		return StatusWalkover
+	}
+	return ""
+}
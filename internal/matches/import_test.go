@@ -1,10 +1,23 @@
 package matches
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/settings"
 	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestParseCSV_WithSwedishHeaders_MapsFields(t *testing.T) {
@@ -62,7 +75,7 @@ func TestParseXLSX_Basic(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	rows, err := parseXLSX(buf.Bytes(), "H43 Lund HF")
+	rows, err := parseXLSX(context.Background(), buf.Bytes(), "H43 Lund HF")
 	if err != nil {
 		t.Fatalf("parseXLSX error: %v", err)
 	}
@@ -94,3 +107,202 @@ func TestParseLocalISO(t *testing.T) {
 		t.Errorf("unexpected offset in iso: %s", *iso)
 	}
 }
+
+func TestImport_ReimportUpdatesByMatchNumber(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	first := repo.Import(ctx, []Match{
+		{MatchNumber: "101", DateRaw: "2025-10-10", TimeRaw: "12:00", Team: "A", Opponent: "B", Venue: "Hallen"},
+		{MatchNumber: "102", DateRaw: "2025-10-17", TimeRaw: "12:00", Team: "A", Opponent: "C", Venue: "Hallen"},
+		{DateRaw: "2025-10-24", Team: "A", Opponent: "D"},
+	})
+	assertEq(t, first.Imported, 3)
+	assertEq(t, first.Updated, 0)
+
+	second := repo.Import(ctx, []Match{
+		{MatchNumber: "101", DateRaw: "2025-10-11", TimeRaw: "13:00", Team: "A", Opponent: "B", Venue: "Hallen"},
+		{MatchNumber: "102", DateRaw: "2025-10-17", TimeRaw: "12:00", Team: "A", Opponent: "C", Venue: "Hallen"},
+		{MatchNumber: "103", DateRaw: "2025-10-31", Team: "A", Opponent: "E"},
+	})
+	assertEq(t, second.Imported, 1)
+	assertEq(t, second.Updated, 2)
+	assertEq(t, second.Failed, 0)
+	assertEq(t, len(second.Changes), 1)
+	ch := second.Changes[0]
+	assertEq(t, ch.Before.DateRaw, "2025-10-10")
+	assertEq(t, ch.After.DateRaw, "2025-10-11")
+	assertEq(t, ch.After.TimeRaw, "13:00")
+
+	list, _ := repo.List(ctx)
+	assertEq(t, len(list), 4)
+}
+
+func TestImport_QueuedThroughJobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	q := jobs.New(db)
+	repo.UseJobs(q)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+
+	var imported []ImportResult
+	repo.Hub().Listen(func(_ context.Context, c Change) {
+		if c.Type == ChangeImported {
+			imported = append(imported, *c.Import)
+		}
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "schema.csv")
+	fw.Write([]byte("Matchnr;Datum;Tid;Hemmalag;Bortalag;Spelplats\r\n" +
+		"101;2025-11-08;14:30;IK Sund;H43 Lund HF;Hallen, Lund\r\n"))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/matches/import?our_team=H43+Lund+HF", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var accepted struct {
+		JobID     int64  `json:"job_id"`
+		StatusURL string `json:"status_url"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &accepted)
+
+	// Nothing is read or stored until a worker picks the job up
+	if list, _ := repo.List(context.Background()); len(list) != 0 {
+		t.Fatalf("expected no matches before the job ran, got %d", len(list))
+	}
+	j, err := q.Get(context.Background(), accepted.JobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(j.Payload), "IK Sund") {
+		t.Fatalf("expected the payload to point at the upload, not carry its rows: %s", j.Payload)
+	}
+	if ran, err := q.RunNext(context.Background()); !ran || err != nil {
+		t.Fatalf("expected the import job to run: ran=%v err=%v", ran, err)
+	}
+	if len(imported) != 1 || imported[0].Imported != 1 {
+		t.Fatalf("expected an import.completed change: %+v", imported)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, accepted.StatusURL, nil))
+	var status struct {
+		Status string       `json:"status"`
+		Result ImportResult `json:"result"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &status)
+	assertEq(t, status.Status, jobs.StatusDone)
+	assertEq(t, status.Result.Imported, 1)
+}
+
+func TestImport_QueuedFileIsReadByTheJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	q := jobs.New(db)
+	repo.UseJobs(q)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+
+	// Not a spreadsheet: the request cannot tell, only the job finds out
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "schema.xlsx")
+	fw.Write([]byte("not a zip"))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/matches/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var accepted struct {
+		JobID int64 `json:"job_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &accepted)
+
+	if ran, err := q.RunNext(context.Background()); !ran || err != nil {
+		t.Fatalf("expected the import job to run: ran=%v err=%v", ran, err)
+	}
+	j, _ := q.Get(context.Background(), accepted.JobID)
+	assertEq(t, j.Status, jobs.StatusFailed)
+	if j.LastError == "" {
+		t.Fatal("expected the parse error on the job")
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM import_uploads`).Scan(&n)
+	assertEq(t, n, 0)
+
+	// The type is still checked up front
+	body.Reset()
+	mw = multipart.NewWriter(&body)
+	fw, _ = mw.CreateFormFile("file", "schema.pdf")
+	fw.Write([]byte("%PDF"))
+	mw.Close()
+	req = httptest.NewRequest(http.MethodPost, "/api/matches/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertEq(t, w.Code, http.StatusBadRequest)
+}
+
+func TestImport_XLSXUploadIsTraced(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	repo, db := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+
+	f := excelize.NewFile()
+	sh := f.GetSheetName(0)
+	f.SetSheetRow(sh, "A1", &[]string{"Matchnr", "Datum", "Tid", "Hemmalag", "Bortalag", "Spelplats"})
+	f.SetSheetRow(sh, "A2", &[]string{"101", "2025-11-08", "14:30", "IK Sund", "H43 Lund HF", "Hallen, Lund"})
+	xlsx, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "schema.xlsx")
+	fw.Write(xlsx.Bytes())
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/matches/import?our_team=H43+Lund+HF", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		spans[s.Name()] = s
+	}
+	parse := spans["matches.parseImport"]
+	if parse == nil || spans["matches.Import"] == nil {
+		t.Fatalf("expected parse and import spans, got %v", spans)
+	}
+	for _, name := range []string{"xlsx.open", "xlsx.rows"} {
+		if s := spans[name]; s == nil || s.Parent().SpanID() != parse.SpanContext().SpanID() {
+			t.Fatalf("expected %s inside the parse span", name)
+		}
+	}
+	attrs := attribute.NewSet(parse.Attributes()...)
+	if v, _ := attrs.Value("import.format"); v.AsString() != "xlsx" {
+		t.Fatalf("unexpected format: %v", parse.Attributes())
+	}
+	if v, _ := attrs.Value("import.rows"); v.AsInt64() != 1 {
+		t.Fatalf("unexpected row count: %v", parse.Attributes())
+	}
+	imp := attribute.NewSet(spans["matches.Import"].Attributes()...)
+	if v, _ := imp.Value("import.imported"); v.AsInt64() != 1 {
+		t.Fatalf("unexpected import attributes: %v", spans["matches.Import"].Attributes())
+	}
+}
@@ -1,28 +1,170 @@
 package matches
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 type Match struct {
-	ID                int64   `json:"id"`
-	StartISO          *string `json:"start_iso"`
-	EndISO            *string `json:"end_iso"`
-	DateRaw           string  `json:"date_raw"`
-	TimeRaw           string  `json:"time_raw"`
-	EndTimeRaw        string  `json:"end_time_raw"`
-	Weekday           string  `json:"weekday"`
-	League            string  `json:"league"`
-	Team              string  `json:"team"`
-	Opponent          string  `json:"opponent"`
-	HomeTeam          string  `json:"home_team"`
-	AwayTeam          string  `json:"away_team"`
-	Venue             string  `json:"venue"`
-	Court             string  `json:"court"`
-	City              string  `json:"city"`
-	MatchNumber       string  `json:"match_number"`
-	Referees          string  `json:"referees"`
-	Notes             string  `json:"notes"`
-	Played            bool    `json:"played"`
-	GoalsFor          int64   `json:"goals_for"`     // <-- int64
-	GoalsAgainst      int64   `json:"goals_against"` // <-- int64
-	PlayerNotes       string  `json:"player_notes"`
-	TopScorerTeam     string  `json:"top_scorer_team"`
-	TopScorerOpponent string  `json:"top_scorer_opponent"`
+	ID                int64      `json:"id"`
+	StartISO          *string    `json:"start_iso"`
+	EndISO            *string    `json:"end_iso"`
+	DateRaw           string     `json:"date_raw"`
+	TimeRaw           string     `json:"time_raw"`
+	EndTimeRaw        string     `json:"end_time_raw"`
+	Weekday           string     `json:"weekday"`
+	League            string     `json:"league"`
+	Team              string     `json:"team"`
+	Opponent          string     `json:"opponent"`
+	HomeTeam          string     `json:"home_team"`
+	AwayTeam          string     `json:"away_team"`
+	Venue             string     `json:"venue"`
+	Court             string     `json:"court"`
+	City              string     `json:"city"`
+	GatherTime        string     `json:"gather_time"` // "HH:MM" on the match date, or RFC 3339
+	GatherPlace       string     `json:"gather_place"`
+	GatherAt          *time.Time `json:"gather_at"` // gather_time resolved, or the league's default before kick-off; read-only
+	MatchNumber       string     `json:"match_number"`
+	Referees          string     `json:"referees"`
+	Notes             string     `json:"notes"`
+	Played            bool       `json:"played"`
+	GoalsFor          int64      `json:"goals_for"`     // <-- int64
+	GoalsAgainst      int64      `json:"goals_against"` // <-- int64
+	PlayerNotes       string     `json:"player_notes"`
+	TopScorerTeam     string     `json:"top_scorer_team"`
+	TopScorerOpponent string     `json:"top_scorer_opponent"`
+	Status            Status     `json:"status"`
+	StatusReason      string     `json:"status_reason"`
+	RescheduledTo     *int64     `json:"rescheduled_to"`
+	Sequence          int64      `json:"sequence"`
+	Periods           []Score    `json:"periods"`
+	Overtime          *Score     `json:"overtime"`
+	Shootout          *Score     `json:"shootout"`
+	Version           int64      `json:"version"` // bumped on every write; the ETag
+	UpdatedAt         *time.Time `json:"updated_at"`
+	CreatedAt         *time.Time `json:"created_at"`
+}
+
+// Score is a for/against pair seen from our team's side.
+type Score struct {
+	For     int64 `json:"for"`
+	Against int64 `json:"against"`
+}
+
+// Status is the lifecycle state of a match.
+type Status string
+
+const (
+	StatusScheduled Status = "scheduled"
+	StatusPostponed Status = "postponed"
+	StatusCancelled Status = "cancelled"
+	StatusPlayed    Status = "played"
+	StatusWalkover  Status = "walkover"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("invalid status")
+	ErrStatusTransition  = errors.New("status transition not allowed")
+	ErrRescheduledTarget = errors.New("rescheduled_to must reference another existing match")
+	ErrInvalidScore      = errors.New("invalid score")
+)
+
+// transitions lists the allowed next states. Finished or cancelled matches can
+// only go back to scheduled, which covers correcting a mistake.
+var transitions = map[Status][]Status{
+	StatusScheduled: {StatusPostponed, StatusCancelled, StatusPlayed, StatusWalkover},
+	StatusPostponed: {StatusScheduled, StatusCancelled, StatusPlayed, StatusWalkover},
+	StatusCancelled: {StatusScheduled},
+	StatusPlayed:    {StatusScheduled, StatusWalkover},
+	StatusWalkover:  {StatusScheduled, StatusPlayed},
+}
+
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransition reports whether a match in state s may move to next.
+// Staying in the same state is always allowed.
+func (s Status) CanTransition(next Status) bool {
+	if s == next {
+		return true
+	}
+	for _, t := range transitions[s] {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPlayed reports whether the match has a final result (the legacy played flag).
+func (s Status) IsPlayed() bool {
+	return s == StatusPlayed || s == StatusWalkover
+}
+
+// validateScore checks period, overtime and shootout results against the final
+// score. Overtime goals count towards the final score; shootout goals do not,
+// and a shootout is only valid after a tied match.
+func validateScore(periods []Score, overtime, shootout *Score, goalsFor, goalsAgainst int64, wantPeriods int) error {
+	neg := func(s Score) bool { return s.For < 0 || s.Against < 0 }
+	if goalsFor < 0 || goalsAgainst < 0 {
+		return fmt.Errorf("%w: goals must not be negative", ErrInvalidScore)
+	}
+	var sum Score
+	for _, p := range periods {
+		if neg(p) {
+			return fmt.Errorf("%w: period goals must not be negative", ErrInvalidScore)
+		}
+		sum.For += p.For
+		sum.Against += p.Against
+	}
+	if overtime != nil {
+		if neg(*overtime) {
+			return fmt.Errorf("%w: overtime goals must not be negative", ErrInvalidScore)
+		}
+		if goalsFor < overtime.For || goalsAgainst < overtime.Against {
+			return fmt.Errorf("%w: overtime exceeds final score", ErrInvalidScore)
+		}
+	}
+	if len(periods) > 0 {
+		if len(periods) != wantPeriods {
+			return fmt.Errorf("%w: expected %d periods, got %d", ErrInvalidScore, wantPeriods, len(periods))
+		}
+		if overtime != nil {
+			sum.For += overtime.For
+			sum.Against += overtime.Against
+		}
+		if sum.For != goalsFor || sum.Against != goalsAgainst {
+			return fmt.Errorf("%w: periods sum to %d-%d but final score is %d-%d",
+				ErrInvalidScore, sum.For, sum.Against, goalsFor, goalsAgainst)
+		}
+	}
+	if shootout != nil {
+		if neg(*shootout) {
+			return fmt.Errorf("%w: shootout goals must not be negative", ErrInvalidScore)
+		}
+		if goalsFor != goalsAgainst {
+			return fmt.Errorf("%w: shootout requires a tied final score", ErrInvalidScore)
+		}
+		if shootout.For == shootout.Against {
+			return fmt.Errorf("%w: shootout cannot end tied", ErrInvalidScore)
+		}
+	}
+	return nil
+}
+
+// sumScores adds up periods plus overtime.
+func sumScores(periods []Score, overtime *Score) Score {
+	var sum Score
+	for _, p := range periods {
+		sum.For += p.For
+		sum.Against += p.Against
+	}
+	if overtime != nil {
+		sum.For += overtime.For
+		sum.Against += overtime.Against
+	}
+	return sum
 }
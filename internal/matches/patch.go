@@ -0,0 +1,130 @@
+package matches
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Field is one member of a JSON merge patch (RFC 7396). A member missing from
+// the document leaves the value alone, null clears it and anything else
+// replaces it, including zero values such as 0, false and "".
+type Field[T any] struct {
+	Set   bool // present in the document
+	Null  bool // present as null
+	Value T
+}
+
+// Val returns a field that sets v.
+func Val[T any](v T) Field[T] { return Field[T]{Set: true, Value: v} }
+
+// Null returns a field that clears the value.
+func Null[T any]() Field[T] { return Field[T]{Set: true, Null: true} }
+
+func (f *Field[T]) UnmarshalJSON(b []byte) error {
+	*f = Field[T]{Set: true}
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(b, &f.Value)
+}
+
+// given reports whether the field carries a value rather than being absent or null.
+func (f Field[T]) given() bool { return f.Set && !f.Null }
+
+// MatchPatch is the body of PATCH /api/matches/:id. start_iso and end_iso are
+// derived from the date and time fields and cannot be patched directly.
+type MatchPatch struct {
+	DateRaw           Field[string]  `json:"date_raw"`
+	TimeRaw           Field[string]  `json:"time_raw"`
+	EndTimeRaw        Field[string]  `json:"end_time_raw"`
+	Weekday           Field[string]  `json:"weekday"`
+	League            Field[string]  `json:"league"`
+	Team              Field[string]  `json:"team"`
+	Opponent          Field[string]  `json:"opponent"`
+	HomeTeam          Field[string]  `json:"home_team"`
+	AwayTeam          Field[string]  `json:"away_team"`
+	Venue             Field[string]  `json:"venue"`
+	Court             Field[string]  `json:"court"`
+	City              Field[string]  `json:"city"`
+	GatherTime        Field[string]  `json:"gather_time"`
+	GatherPlace       Field[string]  `json:"gather_place"`
+	MatchNumber       Field[string]  `json:"match_number"`
+	Referees          Field[string]  `json:"referees"`
+	Notes             Field[string]  `json:"notes"`
+	Played            Field[bool]    `json:"played"`
+	GoalsFor          Field[int64]   `json:"goals_for"`
+	GoalsAgainst      Field[int64]   `json:"goals_against"`
+	PlayerNotes       Field[string]  `json:"player_notes"`
+	TopScorerTeam     Field[string]  `json:"top_scorer_team"`
+	TopScorerOpponent Field[string]  `json:"top_scorer_opponent"`
+	Status            Field[Status]  `json:"status"`
+	StatusReason      Field[string]  `json:"status_reason"`
+	RescheduledTo     Field[int64]   `json:"rescheduled_to"`
+	Periods           Field[[]Score] `json:"periods"`
+	Overtime          Field[Score]   `json:"overtime"`
+	Shootout          Field[Score]   `json:"shootout"`
+}
+
+// nonEmptyPatch sets only the fields of m that carry a value, so everything
+// else is kept. Imports use it: a column missing from the file must not wipe
+// what was entered by hand. The score counts as given for a played match, so
+// a 0-0 result replaces an earlier one.
+func nonEmptyPatch(m Match) MatchPatch {
+	str := func(s string) Field[string] {
+		if s == "" {
+			return Field[string]{}
+		}
+		return Val(s)
+	}
+	goals := func(v int64) Field[int64] {
+		if v == 0 && !m.Played {
+			return Field[int64]{}
+		}
+		return Val(v)
+	}
+	p := MatchPatch{
+		DateRaw:           str(m.DateRaw),
+		TimeRaw:           str(m.TimeRaw),
+		EndTimeRaw:        str(m.EndTimeRaw),
+		Weekday:           str(m.Weekday),
+		League:            str(m.League),
+		Team:              str(m.Team),
+		Opponent:          str(m.Opponent),
+		HomeTeam:          str(m.HomeTeam),
+		AwayTeam:          str(m.AwayTeam),
+		Venue:             str(m.Venue),
+		Court:             str(m.Court),
+		City:              str(m.City),
+		GatherTime:        str(m.GatherTime),
+		GatherPlace:       str(m.GatherPlace),
+		MatchNumber:       str(m.MatchNumber),
+		Referees:          str(m.Referees),
+		Notes:             str(m.Notes),
+		GoalsFor:          goals(m.GoalsFor),
+		GoalsAgainst:      goals(m.GoalsAgainst),
+		PlayerNotes:       str(m.PlayerNotes),
+		TopScorerTeam:     str(m.TopScorerTeam),
+		TopScorerOpponent: str(m.TopScorerOpponent),
+		StatusReason:      str(m.StatusReason),
+	}
+	if m.Played {
+		p.Played = Val(true)
+	}
+	if m.Status != "" {
+		p.Status = Val(m.Status)
+	}
+	if m.RescheduledTo != nil {
+		p.RescheduledTo = Val(*m.RescheduledTo)
+	}
+	if m.Periods != nil {
+		p.Periods = Val(m.Periods)
+	}
+	if m.Overtime != nil {
+		p.Overtime = Val(*m.Overtime)
+	}
+	if m.Shootout != nil {
+		p.Shootout = Val(*m.Shootout)
+	}
+	return p
+}
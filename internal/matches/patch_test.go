@@ -0,0 +1,119 @@
+package matches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestMatchPatch_TracksPresence(t *testing.T) {
+	var p MatchPatch
+	if err := json.Unmarshal([]byte(`{"notes": null, "goals_for": 0, "played": false, "venue": ""}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Notes.Set || !p.Notes.Null {
+		t.Errorf("expected notes to be cleared: %+v", p.Notes)
+	}
+	if !p.GoalsFor.given() || p.GoalsFor.Value != 0 {
+		t.Errorf("expected goals_for set to 0: %+v", p.GoalsFor)
+	}
+	if !p.Played.given() || p.Played.Value {
+		t.Errorf("expected played set to false: %+v", p.Played)
+	}
+	if !p.Venue.given() || p.Venue.Value != "" {
+		t.Errorf("expected venue set to empty: %+v", p.Venue)
+	}
+	if p.Team.Set || p.GoalsAgainst.Set || p.Periods.Set {
+		t.Errorf("expected absent fields to stay unset: %+v", p)
+	}
+	if err := json.Unmarshal([]byte(`{"goals_for": "tre"}`), &p); err == nil {
+		t.Errorf("expected a type error")
+	}
+}
+
+func TestRepository_PatchClearsAndSetsZero(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", Team: "A", Opponent: "B", Notes: "Ta med västar", Played: true, GoalsFor: 2, GoalsAgainst: 1})
+
+	// A corrected 0-0 result replaces the earlier score
+	row, err := repo.Patch(ctx, m.ID, MatchPatch{GoalsFor: Val[int64](0), GoalsAgainst: Val[int64](0)})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if row.GoalsFor == nil || *row.GoalsFor != 0 || row.GoalsAgainst == nil || *row.GoalsAgainst != 0 {
+		t.Fatalf("expected a stored 0-0, got %v-%v", row.GoalsFor, row.GoalsAgainst)
+	}
+	assertEq(t, Status(row.Status), StatusPlayed)
+
+	// played=false reopens the match; null clears the notes and the score
+	row, err = repo.Patch(ctx, m.ID, MatchPatch{Played: Val(false), Notes: Null[string](), GoalsFor: Null[int64](), GoalsAgainst: Null[int64]()})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	assertEq(t, Status(row.Status), StatusScheduled)
+	assertEq(t, bval(row.Played), false)
+	if row.Notes != nil || row.GoalsFor != nil || row.GoalsAgainst != nil {
+		t.Fatalf("expected cleared fields: notes=%v goals=%v-%v", row.Notes, row.GoalsFor, row.GoalsAgainst)
+	}
+	// Untouched fields are kept
+	assertEq(t, sval(row.Opponent), "B")
+	assertEq(t, sval(row.StartIso), sval(m.StartIso))
+
+	// Clearing the time drops the start time but keeps the date
+	seq := row.Sequence
+	row, _ = repo.Patch(ctx, m.ID, MatchPatch{TimeRaw: Null[string]()})
+	assertEq(t, sval(row.DateRaw), "2025-09-20")
+	if row.TimeRaw != nil || row.StartIso == nil || row.Sequence != seq+1 {
+		t.Fatalf("unexpected start after clearing the time: %v %v seq=%d", row.TimeRaw, sval(row.StartIso), row.Sequence)
+	}
+}
+
+func TestPatchAndPutRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+	ctx := context.Background()
+	repo.Create(ctx, Match{DateRaw: "2025-09-20", Team: "A", Opponent: "B", Venue: "Hallen", Notes: "Buss 12:00"})
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, Match) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", "*")
+		r.ServeHTTP(w, req)
+		var m Match
+		json.Unmarshal(w.Body.Bytes(), &m)
+		return w, m
+	}
+	w, m := do(http.MethodPatch, "/api/matches/1", `{"notes": null, "played": true, "goals_for": 0, "goals_against": 0}`)
+	if w.Code != http.StatusOK || m.Notes != "" || !m.Played || m.Venue != "Hallen" {
+		t.Fatalf("patch: %d %s", w.Code, w.Body.String())
+	}
+	w, m = do(http.MethodPatch, "/api/matches/1", `{"played": false}`)
+	if w.Code != http.StatusOK || m.Played || m.Status != StatusScheduled {
+		t.Fatalf("unplay: %d %s", w.Code, w.Body.String())
+	}
+
+	// PUT replaces the whole match
+	w, m = do(http.MethodPut, "/api/matches/1", `{"date_raw": "2025-09-21", "team": "A", "opponent": "C"}`)
+	if w.Code != http.StatusOK || m.Opponent != "C" || m.Venue != "" || m.DateRaw != "2025-09-21" || m.Status != StatusScheduled {
+		t.Fatalf("put: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, method := range []string{http.MethodPatch, http.MethodPut} {
+		if w, _ := do(method, "/api/matches/99", `{"notes": "x"}`); w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404 for a missing match, got %d", method, w.Code)
+		}
+	}
+	if w, _ := do(http.MethodPatch, "/api/matches/1", `{"goals_for": "tre"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad value, got %d", w.Code)
+	}
+}
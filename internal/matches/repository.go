@@ -2,28 +2,108 @@ package matches
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
+	"github.com/xaitan80/X-Matches/internal/audit"
 	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/logging"
+	"github.com/xaitan80/X-Matches/internal/settings"
 )
 
 type Repository struct {
-	q *dbpkg.Queries
+	db  *sql.DB
+	q   *dbpkg.Queries
+	cfg *settings.Service
+	hub *Hub
+	loc atomic.Pointer[time.Location]
+
+	jobs *jobs.Queue
+}
+
+// NewRepository wraps db. cfg supplies league rules for score validation and may be nil.
+func NewRepository(db *sql.DB, cfg *settings.Service) *Repository {
+	return &Repository{db: db, q: dbpkg.New(db), cfg: cfg, hub: NewHub()}
+}
+
+// Hub returns the hub that receives a Change after every committed mutation.
+func (r *Repository) Hub() *Hub { return r.hub }
+
+// publish sends a change carrying the current API form of row.
+func (r *Repository) publish(ctx context.Context, typ string, row dbpkg.Match) {
+	slog.InfoContext(logging.With(ctx, "match_id", row.ID), "match changed", "change", typ)
+	periods, _ := loadPeriods(ctx, r.q, row.ID)
+	m := r.apiMatch(ctx, row, periods)
+	r.hub.Publish(ctx, Change{Type: typ, MatchID: row.ID, Match: &m})
+}
+
+// inTx runs fn with queries bound to a transaction, committing if fn succeeds.
+func (r *Repository) inTx(ctx context.Context, fn func(q *dbpkg.Queries) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := fn(r.q.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func NewRepository(q *dbpkg.Queries) *Repository { return &Repository{q: q} }
+// Audit actions for matches.
+const (
+	AuditCreate      = "match.create"
+	AuditUpdate      = "match.update"
+	AuditDelete      = "match.delete"
+	AuditEventAdd    = "match.event_add"
+	AuditEventDelete = "match.event_delete"
+	AuditImport      = "matches.import"
+	AuditDeleteAll   = "matches.delete_all"
+)
+
+// auditForm is the API form of row with its periods, as read through q. It
+// is what audit entries for matches store; take the before form ahead of any
+// write that replaces the periods.
+func (r *Repository) auditForm(ctx context.Context, q *dbpkg.Queries, row dbpkg.Match) (Match, error) {
+	periods, err := loadPeriods(ctx, q, row.ID)
+	if err != nil {
+		return Match{}, err
+	}
+	m := toAPIWithPeriods(row, periods)
+	m.UpdatedAt, m.CreatedAt = nil, nil // the entry has its own time
+	return m, nil
+}
+
+// SetLocation changes the timezone used to turn raw date/time into ISO timestamps.
+func (r *Repository) SetLocation(loc *time.Location) { r.loc.Store(loc) }
+
+func (r *Repository) location() *time.Location {
+	if loc := r.loc.Load(); loc != nil {
+		return loc
+	}
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	return loc
+}
 
 // -------- Helpers --------
 
 func ParseLocalISO(dateRaw, timeRaw string) *string {
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	return parseLocalISOIn(loc, dateRaw, timeRaw)
+}
+
+func parseLocalISOIn(loc *time.Location, dateRaw, timeRaw string) *string {
 	if dateRaw == "" && timeRaw == "" {
 		return nil
 	}
 	if timeRaw == "" {
 		timeRaw = "00:00"
 	}
-	loc, _ := time.LoadLocation("Europe/Stockholm")
 	t, err := time.ParseInLocation("2006-01-02 15:04", dateRaw+" "+timeRaw, loc)
 	if err != nil {
 		return nil
@@ -32,6 +112,15 @@ func ParseLocalISO(dateRaw, timeRaw string) *string {
 	return &s
 }
 
+var clock = time.Now
+
+// stamp is the value for updated_at and deleted_at. It is kept in UTC at whole
+// seconds so the stored text compares in time order.
+func stamp() *time.Time {
+	t := clock().UTC().Truncate(time.Second)
+	return &t
+}
+
 func pstr(s string) *string {
 	if s == "" {
 		return nil
@@ -40,11 +129,21 @@ func pstr(s string) *string {
 	return &v
 }
 
-func pstrKeep(newVal string, cur *string) *string {
-	if newVal != "" {
-		return pstr(newVal)
+// setStr applies a patch field to a nullable text column; "" is stored as NULL.
+func setStr(dst **string, f Field[string]) {
+	if f.Set {
+		*dst = pstr(f.Value)
+	}
+}
+
+// setInt applies a patch field to a nullable integer column.
+func setInt(dst **int64, f Field[int64]) {
+	if f.given() {
+		v := f.Value
+		*dst = &v
+	} else if f.Null {
+		*dst = nil
 	}
-	return cur
 }
 
 func pPlayed(b bool) *int64 {
@@ -63,6 +162,83 @@ func pI64ZeroNil(v int64) *int64 {
 	return &x
 }
 
+func scoreFor(s *Score) *int64 {
+	if s == nil {
+		return nil
+	}
+	v := s.For
+	return &v
+}
+
+func scoreAgainst(s *Score) *int64 {
+	if s == nil {
+		return nil
+	}
+	v := s.Against
+	return &v
+}
+
+// scorePtr builds a Score from a pair of nullable columns; nil if both are NULL.
+func scorePtr(f, a *int64) *Score {
+	if f == nil && a == nil {
+		return nil
+	}
+	return &Score{For: ival(f), Against: ival(a)}
+}
+
+// -------- Perioder --------
+
+func loadPeriods(ctx context.Context, q *dbpkg.Queries, matchID int64) ([]Score, error) {
+	rows, err := q.ListMatchPeriods(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Score, 0, len(rows))
+	for _, p := range rows {
+		out = append(out, Score{For: p.GoalsFor, Against: p.GoalsAgainst})
+	}
+	return out, nil
+}
+
+func replacePeriods(ctx context.Context, q *dbpkg.Queries, matchID int64, periods []Score) error {
+	if err := q.DeleteMatchPeriods(ctx, matchID); err != nil {
+		return err
+	}
+	for i, p := range periods {
+		if err := q.CreateMatchPeriod(ctx, dbpkg.CreateMatchPeriodParams{
+			MatchID:      matchID,
+			Period:       int64(i + 1),
+			GoalsFor:     p.For,
+			GoalsAgainst: p.Against,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Periods returns the period scores of one match in order.
+func (r *Repository) Periods(ctx context.Context, matchID int64) ([]Score, error) {
+	return loadPeriods(ctx, r.q, matchID)
+}
+
+// AllPeriods returns period scores for every match, keyed by match id.
+func (r *Repository) AllPeriods(ctx context.Context) (map[int64][]Score, error) {
+	return allPeriods(ctx, r.q)
+}
+
+func allPeriods(ctx context.Context, q *dbpkg.Queries) (map[int64][]Score, error) {
+	rows, err := q.ListAllMatchPeriods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64][]Score)
+	for _, p := range rows {
+		out[p.MatchID] = append(out[p.MatchID], Score{For: p.GoalsFor, Against: p.GoalsAgainst})
+	}
+	return out, nil
+}
+
 // -------- CRUD --------
 
 func (r *Repository) List(ctx context.Context) ([]dbpkg.Match, error) {
@@ -74,17 +250,70 @@ func (r *Repository) Get(ctx context.Context, id int64) (dbpkg.Match, error) {
 }
 
 func (r *Repository) Create(ctx context.Context, m Match) (dbpkg.Match, error) {
+	var row dbpkg.Match
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		var err error
+		row, err = r.createAudited(ctx, q, m)
+		return err
+	})
+	if err == nil {
+		r.publish(ctx, ChangeCreated, row)
+	}
+	return row, err
+}
+
+// createAudited creates a match through q and records it in the audit log.
+func (r *Repository) createAudited(ctx context.Context, q *dbpkg.Queries, m Match) (dbpkg.Match, error) {
+	row, err := r.create(ctx, q, m)
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	after, err := r.auditForm(ctx, q, row)
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	return row, audit.Record(ctx, q, AuditCreate, audit.EntityMatch, row.ID, nil, after)
+}
+
+func (r *Repository) create(ctx context.Context, q *dbpkg.Queries, m Match) (dbpkg.Match, error) {
 	// Beräkna ISO-tider om inte satta
 	startISO := m.StartISO
 	if startISO == nil && (m.DateRaw != "" || m.TimeRaw != "") {
-		startISO = ParseLocalISO(m.DateRaw, m.TimeRaw)
+		startISO = parseLocalISOIn(r.location(), m.DateRaw, m.TimeRaw)
 	}
 	endISO := m.EndISO
 	if endISO == nil && m.DateRaw != "" && m.EndTimeRaw != "" {
-		endISO = ParseLocalISO(m.DateRaw, m.EndTimeRaw)
+		endISO = parseLocalISOIn(r.location(), m.DateRaw, m.EndTimeRaw)
+	}
+
+	// Status: default scheduled; a result implies played
+	status := m.Status
+	if status == "" {
+		status = StatusScheduled
+	}
+	if !status.Valid() {
+		return dbpkg.Match{}, ErrInvalidStatus
+	}
+	if m.Played && !status.IsPlayed() {
+		status = StatusPlayed
+	}
+
+	// Periods give the final score when none was supplied
+	goalsFor, goalsAgainst := m.GoalsFor, m.GoalsAgainst
+	if len(m.Periods) > 0 && goalsFor == 0 && goalsAgainst == 0 {
+		sum := sumScores(m.Periods, m.Overtime)
+		goalsFor, goalsAgainst = sum.For, sum.Against
+	}
+	if err := validateScore(m.Periods, m.Overtime, m.Shootout, goalsFor, goalsAgainst, r.cfg.League(ctx, m.League).Periods); err != nil {
+		return dbpkg.Match{}, err
 	}
 
-	row, err := r.q.CreateMatch(ctx, dbpkg.CreateMatchParams{
+	seq, err := q.NextChangeSeq(ctx)
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	now := stamp()
+	row, err := q.CreateMatch(ctx, dbpkg.CreateMatchParams{
 		StartIso:          startISO,           // *string
 		EndIso:            endISO,             // *string
 		DateRaw:           pstr(m.DateRaw),    // *string
@@ -99,70 +328,236 @@ func (r *Repository) Create(ctx context.Context, m Match) (dbpkg.Match, error) {
 		Venue:             pstr(m.Venue),
 		Court:             pstr(m.Court),
 		City:              pstr(m.City),
+		GatherTime:        pstr(m.GatherTime),
+		GatherPlace:       pstr(m.GatherPlace),
 		MatchNumber:       pstr(m.MatchNumber),
 		Referees:          pstr(m.Referees),
 		Notes:             pstr(m.Notes),
-		Played:            pPlayed(m.Played),       // *int64 (0/1)
-		GoalsFor:          pI64ZeroNil(m.GoalsFor), // *int64
-		GoalsAgainst:      pI64ZeroNil(m.GoalsAgainst),
+		Played:            pPlayed(status.IsPlayed()), // *int64 (0/1), mirrors status
+		GoalsFor:          pI64ZeroNil(goalsFor),      // *int64
+		GoalsAgainst:      pI64ZeroNil(goalsAgainst),
 		PlayerNotes:       pstr(m.PlayerNotes),
 		TopScorerTeam:     pstr(m.TopScorerTeam),
 		TopScorerOpponent: pstr(m.TopScorerOpponent),
+		Status:            string(status),
+		StatusReason:      pstr(m.StatusReason),
+		OvertimeFor:       scoreFor(m.Overtime),
+		OvertimeAgainst:   scoreAgainst(m.Overtime),
+		ShootoutFor:       scoreFor(m.Shootout),
+		ShootoutAgainst:   scoreAgainst(m.Shootout),
+		UpdatedAt:         now,
+		CreatedAt:         now,
+		ChangeSeq:         seq,
 	})
-	return row, err
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	if err := replacePeriods(ctx, q, row.ID, m.Periods); err != nil {
+		return dbpkg.Match{}, err
+	}
+	return row, nil
 }
 
+// Update sets the fields of m that carry a value and keeps the rest; see
+// nonEmptyPatch. Use Patch to clear fields.
 func (r *Repository) Update(ctx context.Context, id int64, m Match) (dbpkg.Match, error) {
-	cur, err := r.q.GetMatch(ctx, id)
+	return r.Patch(ctx, id, nonEmptyPatch(m))
+}
+
+// Patch applies a merge patch to a match.
+func (r *Repository) Patch(ctx context.Context, id int64, p MatchPatch) (dbpkg.Match, error) {
+	return r.PatchAt(ctx, id, AnyVersion, p)
+}
+
+// PatchAt applies a merge patch to a match if it is still at version, and
+// returns ErrVersionMismatch otherwise.
+func (r *Repository) PatchAt(ctx context.Context, id, version int64, p MatchPatch) (dbpkg.Match, error) {
+	var before, row dbpkg.Match
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		var err error
+		before, row, err = r.patchAudited(ctx, q, id, version, p)
+		return err
+	})
+	if err == nil {
+		r.publishPatched(ctx, before, row)
+	}
+	return row, err
+}
+
+// patchAudited applies a merge patch through q, keeping the replaced state as
+// a version and recording the change in the audit log. It returns the match
+// before and after.
+func (r *Repository) patchAudited(ctx context.Context, q *dbpkg.Queries, id, version int64, p MatchPatch) (before, row dbpkg.Match, err error) {
+	if before, err = q.GetMatch(ctx, id); err != nil {
+		return before, row, fmt.Errorf("get: %w", err)
+	}
+	if version != AnyVersion && before.Version != version {
+		return before, row, ErrVersionMismatch
+	}
+	prev, err := r.auditForm(ctx, q, before)
+	if err != nil {
+		return before, row, err
+	}
+	if err := r.saveVersion(ctx, q, before, AuditUpdate); err != nil {
+		return before, row, err
+	}
+	if row, err = r.update(ctx, q, id, p); err != nil {
+		return before, row, err
+	}
+	next, err := r.auditForm(ctx, q, row)
+	if err != nil {
+		return before, row, err
+	}
+	return before, row, audit.Record(ctx, q, AuditUpdate, audit.EntityMatch, id, prev, next)
+}
+
+// publishPatched announces a committed patch, and a new result if it changed one.
+func (r *Repository) publishPatched(ctx context.Context, before, row dbpkg.Match) {
+	r.publish(ctx, ChangeUpdated, row)
+	if resultChanged(before, row) {
+		r.publish(ctx, ChangeResult, row)
+	}
+}
+
+// resultChanged reports whether the score or the played state differs between a and b.
+func resultChanged(a, b dbpkg.Match) bool {
+	eq := func(x, y *int64) bool { return ival(x) == ival(y) && (x == nil) == (y == nil) }
+	return !eq(a.GoalsFor, b.GoalsFor) || !eq(a.GoalsAgainst, b.GoalsAgainst) ||
+		!eq(a.ShootoutFor, b.ShootoutFor) || !eq(a.ShootoutAgainst, b.ShootoutAgainst) ||
+		Status(a.Status).IsPlayed() != Status(b.Status).IsPlayed()
+}
+
+func (r *Repository) update(ctx context.Context, q *dbpkg.Queries, id int64, p MatchPatch) (dbpkg.Match, error) {
+	cur, err := q.GetMatch(ctx, id)
 	if err != nil {
 		return dbpkg.Match{}, fmt.Errorf("get: %w", err)
 	}
 
-	// Mergning: tom sträng => behåll, annars sätt nytt
+	// Merge patch: absent => keep, null or "" => clear, otherwise set
 	out := cur
-	out.DateRaw = pstrKeep(m.DateRaw, cur.DateRaw)
-	out.TimeRaw = pstrKeep(m.TimeRaw, cur.TimeRaw)
-	out.EndTimeRaw = pstrKeep(m.EndTimeRaw, cur.EndTimeRaw)
-	out.Weekday = pstrKeep(m.Weekday, cur.Weekday)
-	out.League = pstrKeep(m.League, cur.League)
-	out.Team = pstrKeep(m.Team, cur.Team)
-	out.Opponent = pstrKeep(m.Opponent, cur.Opponent)
-	out.HomeTeam = pstrKeep(m.HomeTeam, cur.HomeTeam)
-	out.AwayTeam = pstrKeep(m.AwayTeam, cur.AwayTeam)
-	out.Venue = pstrKeep(m.Venue, cur.Venue)
-	out.Court = pstrKeep(m.Court, cur.Court)
-	out.City = pstrKeep(m.City, cur.City)
-	out.MatchNumber = pstrKeep(m.MatchNumber, cur.MatchNumber)
-	out.Referees = pstrKeep(m.Referees, cur.Referees)
-	out.Notes = pstrKeep(m.Notes, cur.Notes)
-	out.PlayerNotes = pstrKeep(m.PlayerNotes, cur.PlayerNotes)
+	setStr(&out.DateRaw, p.DateRaw)
+	setStr(&out.TimeRaw, p.TimeRaw)
+	setStr(&out.EndTimeRaw, p.EndTimeRaw)
+	setStr(&out.Weekday, p.Weekday)
+	setStr(&out.League, p.League)
+	setStr(&out.Team, p.Team)
+	setStr(&out.Opponent, p.Opponent)
+	setStr(&out.HomeTeam, p.HomeTeam)
+	setStr(&out.AwayTeam, p.AwayTeam)
+	setStr(&out.Venue, p.Venue)
+	setStr(&out.Court, p.Court)
+	setStr(&out.City, p.City)
+	setStr(&out.GatherTime, p.GatherTime)
+	setStr(&out.GatherPlace, p.GatherPlace)
+	setStr(&out.MatchNumber, p.MatchNumber)
+	setStr(&out.Referees, p.Referees)
+	setStr(&out.Notes, p.Notes)
+	setStr(&out.PlayerNotes, p.PlayerNotes)
 	// Toppskyttar
-	out.TopScorerTeam = pstrKeep(m.TopScorerTeam, cur.TopScorerTeam)
-	out.TopScorerOpponent = pstrKeep(m.TopScorerOpponent, cur.TopScorerOpponent)
+	setStr(&out.TopScorerTeam, p.TopScorerTeam)
+	setStr(&out.TopScorerOpponent, p.TopScorerOpponent)
+
+	// Status: explicit status wins (null resets to scheduled); otherwise the
+	// played flag moves an open match to played or a finished one back
+	curStatus := Status(cur.Status)
+	status := curStatus
+	switch {
+	case p.Status.Set:
+		status = p.Status.Value
+		if p.Status.Null || status == "" {
+			status = StatusScheduled
+		}
+	case p.Played.given() && p.Played.Value && !curStatus.IsPlayed():
+		status = StatusPlayed
+	case p.Played.Set && !p.Played.Value && curStatus.IsPlayed():
+		status = StatusScheduled
+	}
+	if !status.Valid() {
+		return dbpkg.Match{}, ErrInvalidStatus
+	}
+	if !curStatus.CanTransition(status) {
+		return dbpkg.Match{}, fmt.Errorf("%w: %s -> %s", ErrStatusTransition, curStatus, status)
+	}
+	out.Status = string(status)
+	out.Played = pPlayed(status.IsPlayed())
+	setStr(&out.StatusReason, p.StatusReason)
+	if p.RescheduledTo.given() {
+		target := p.RescheduledTo.Value
+		if target == id {
+			return dbpkg.Match{}, ErrRescheduledTarget
+		}
+		if _, err := q.GetMatch(ctx, target); err != nil {
+			return dbpkg.Match{}, ErrRescheduledTarget
+		}
+		out.RescheduledTo = &target
+	} else if p.RescheduledTo.Null {
+		out.RescheduledTo = nil
+	}
 
-	// Played/mål – sätt om inkommande värden är "meningsfulla"
-	// (Vi tolkar Goals* = 0 som "lämna som är")
-	if m.Played {
-		out.Played = pPlayed(m.Played)
+	// Mål: 0 is a result like any other; null clears the score
+	setInt(&out.GoalsFor, p.GoalsFor)
+	setInt(&out.GoalsAgainst, p.GoalsAgainst)
+
+	// Förlängning/straffar: null clears, a value replaces
+	if p.Overtime.Set {
+		out.OvertimeFor, out.OvertimeAgainst = nil, nil
+		if !p.Overtime.Null {
+			out.OvertimeFor, out.OvertimeAgainst = scoreFor(&p.Overtime.Value), scoreAgainst(&p.Overtime.Value)
+		}
+	}
+	if p.Shootout.Set {
+		out.ShootoutFor, out.ShootoutAgainst = nil, nil
+		if !p.Shootout.Null {
+			out.ShootoutFor, out.ShootoutAgainst = scoreFor(&p.Shootout.Value), scoreAgainst(&p.Shootout.Value)
+		}
+	}
+	// Perioder: absent => keep, null or [] => clear. Without a final score in
+	// the patch it is summed from the periods.
+	periods := p.Periods.Value
+	if !p.Periods.Set {
+		if periods, err = loadPeriods(ctx, q, id); err != nil {
+			return dbpkg.Match{}, err
+		}
+	} else if len(periods) > 0 && !p.GoalsFor.given() && !p.GoalsAgainst.given() {
+		sum := sumScores(periods, scorePtr(out.OvertimeFor, out.OvertimeAgainst))
+		out.GoalsFor, out.GoalsAgainst = &sum.For, &sum.Against
 	}
-	if m.GoalsFor != 0 {
-		out.GoalsFor = pI64ZeroNil(m.GoalsFor)
+	if err := validateScore(periods, scorePtr(out.OvertimeFor, out.OvertimeAgainst), scorePtr(out.ShootoutFor, out.ShootoutAgainst),
+		ival(out.GoalsFor), ival(out.GoalsAgainst), r.cfg.League(ctx, sval(out.League)).Periods); err != nil {
+		return dbpkg.Match{}, err
 	}
-	if m.GoalsAgainst != 0 {
-		out.GoalsAgainst = pI64ZeroNil(m.GoalsAgainst)
+	if p.Periods.Set {
+		if err := replacePeriods(ctx, q, id, periods); err != nil {
+			return dbpkg.Match{}, err
+		}
 	}
 
 	// Recompute ISO-tider om date/time ändrats
 	startISO := out.StartIso
 	endISO := out.EndIso
-	if m.DateRaw != "" || m.TimeRaw != "" {
-		startISO = ParseLocalISO(sval(out.DateRaw), sval(out.TimeRaw))
+	if p.DateRaw.Set || p.TimeRaw.Set {
+		startISO = parseLocalISOIn(r.location(), sval(out.DateRaw), sval(out.TimeRaw))
 	}
-	if m.DateRaw != "" || m.EndTimeRaw != "" {
-		endISO = ParseLocalISO(sval(out.DateRaw), sval(out.EndTimeRaw))
+	if p.DateRaw.Set || p.EndTimeRaw.Set {
+		// Without an end time the end follows the start, as on create
+		endISO = nil
+		if sval(out.EndTimeRaw) != "" {
+			endISO = parseLocalISOIn(r.location(), sval(out.DateRaw), sval(out.EndTimeRaw))
+		}
+	}
+
+	// Bump the iCal SEQUENCE on changes calendar clients must pick up
+	if out.Status != cur.Status || sval(startISO) != sval(cur.StartIso) || sval(endISO) != sval(cur.EndIso) ||
+		sval(out.Venue) != sval(cur.Venue) || sval(out.City) != sval(cur.City) ||
+		sval(out.GatherTime) != sval(cur.GatherTime) || sval(out.GatherPlace) != sval(cur.GatherPlace) {
+		out.Sequence = cur.Sequence + 1
 	}
 
-	return r.q.UpdateMatch(ctx, dbpkg.UpdateMatchParams{
+	seq, err := q.NextChangeSeq(ctx)
+	if err != nil {
+		return dbpkg.Match{}, err
+	}
+	return q.UpdateMatch(ctx, dbpkg.UpdateMatchParams{
 		StartIso:          startISO,
 		EndIso:            endISO,
 		DateRaw:           out.DateRaw,
@@ -188,14 +583,107 @@ func (r *Repository) Update(ctx context.Context, id int64, m Match) (dbpkg.Match
 		PlayerNotes:       out.PlayerNotes,
 		TopScorerTeam:     out.TopScorerTeam,
 		TopScorerOpponent: out.TopScorerOpponent,
+		Status:            out.Status,
+		StatusReason:      out.StatusReason,
+		RescheduledTo:     out.RescheduledTo,
+		Sequence:          out.Sequence,
+		OvertimeFor:       out.OvertimeFor,
+		OvertimeAgainst:   out.OvertimeAgainst,
+		ShootoutFor:       out.ShootoutFor,
+		ShootoutAgainst:   out.ShootoutAgainst,
+		UpdatedAt:         stamp(),
+		ChangeSeq:         seq,
 		ID:                id,
 	})
 }
 
+// Delete moves a match to the trash. It can be restored until it is purged.
 func (r *Repository) Delete(ctx context.Context, id int64) error {
-	return r.q.DeleteMatch(ctx, id)
+	return r.DeleteAt(ctx, id, AnyVersion)
 }
 
+// DeleteAt moves a match to the trash if it is still at version, and returns
+// ErrVersionMismatch otherwise.
+func (r *Repository) DeleteAt(ctx context.Context, id, version int64) error {
+	var deleted bool
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		var err error
+		deleted, err = r.deleteAudited(ctx, q, id, version)
+		return err
+	})
+	if err == nil && deleted {
+		r.publishDeleted(ctx, id)
+	}
+	return err
+}
+
+// deleteAudited moves a match to the trash through q and records it in the
+// audit log. A missing match is not an error; deleted reports whether there
+// was one.
+func (r *Repository) deleteAudited(ctx context.Context, q *dbpkg.Queries, id, version int64) (deleted bool, err error) {
+	before, err := q.GetMatch(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil // already gone; nothing to record
+	}
+	if err != nil {
+		return false, err
+	}
+	if version != AnyVersion && before.Version != version {
+		return false, ErrVersionMismatch
+	}
+	prev, err := r.auditForm(ctx, q, before)
+	if err != nil {
+		return false, err
+	}
+	seq, err := q.NextChangeSeq(ctx)
+	if err != nil {
+		return false, err
+	}
+	if _, err := q.SoftDeleteMatch(ctx, dbpkg.SoftDeleteMatchParams{DeletedAt: stamp(), UpdatedAt: stamp(), ChangeSeq: seq, ID: id}); err != nil {
+		return false, err
+	}
+	return true, audit.Record(ctx, q, AuditDelete, audit.EntityMatch, id, prev, nil)
+}
+
+func (r *Repository) publishDeleted(ctx context.Context, id int64) {
+	slog.InfoContext(logging.With(ctx, "match_id", id), "match changed", "change", ChangeDeleted)
+	r.hub.Publish(ctx, Change{Type: ChangeDeleted, MatchID: id})
+}
+
+// DeleteAll moves every match to the trash.
 func (r *Repository) DeleteAll(ctx context.Context) (int64, error) {
-	return r.q.DeleteAllMatches(ctx)
+	var n int64
+	var ids []int64
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		// The whole schedule goes into the entry, so a wiped schedule can be reconstructed
+		rows, err := q.ListMatches(ctx)
+		if err != nil {
+			return err
+		}
+		periods, err := allPeriods(ctx, q)
+		if err != nil {
+			return err
+		}
+		before := make([]Match, 0, len(rows))
+		ids = make([]int64, 0, len(rows))
+		for _, row := range rows {
+			before = append(before, toAPIWithPeriods(row, periods[row.ID]))
+			ids = append(ids, row.ID)
+		}
+		seq, err := q.NextChangeSeq(ctx)
+		if err != nil {
+			return err
+		}
+		if n, err = q.SoftDeleteAllMatches(ctx, dbpkg.SoftDeleteAllMatchesParams{DeletedAt: stamp(), UpdatedAt: stamp(), ChangeSeq: seq}); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, AuditDeleteAll, audit.EntityMatch, 0, before, map[string]int64{"deleted": n})
+	})
+	if err == nil {
+		slog.WarnContext(ctx, "all matches deleted", "count", n)
+		// One change naming every match, so listeners that track single
+		// matches (webhooks) can fan it out without a publish per match
+		r.hub.Publish(ctx, Change{Type: ChangeAllDeleted, MatchIDs: ids})
+	}
+	return n, err
 }
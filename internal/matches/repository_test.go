@@ -0,0 +1,340 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/xaitan80/X-Matches/internal/audit"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func newTestRepo(t *testing.T) (*Repository, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewRepository(db, settings.NewService(db)), db
+}
+
+func TestRepository_CreateDefaultsStatus(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	m, err := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", Team: "A", Opponent: "B"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	assertEq(t, Status(m.Status), StatusScheduled)
+	assertEq(t, bval(m.Played), false)
+
+	m, err = repo.Create(ctx, Match{Team: "A", Opponent: "C", Played: true, GoalsFor: 2, GoalsAgainst: 1})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	assertEq(t, Status(m.Status), StatusPlayed)
+	assertEq(t, bval(m.Played), true)
+}
+
+func TestRepository_StatusTransitions(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", Team: "A", Opponent: "B"})
+	replacement, _ := repo.Create(ctx, Match{DateRaw: "2025-10-04", TimeRaw: "10:00", Team: "A", Opponent: "B"})
+
+	up, err := repo.Update(ctx, m.ID, Match{Status: StatusPostponed, StatusReason: "Hallen stängd", RescheduledTo: &replacement.ID})
+	if err != nil {
+		t.Fatalf("postpone: %v", err)
+	}
+	assertEq(t, Status(up.Status), StatusPostponed)
+	assertEq(t, sval(up.StatusReason), "Hallen stängd")
+	assertEq(t, ival(up.RescheduledTo), replacement.ID)
+	assertEq(t, up.Sequence, int64(1))
+
+	if _, err := repo.Update(ctx, m.ID, Match{Status: StatusCancelled}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	// cancelled -> played is not allowed
+	if _, err := repo.Update(ctx, m.ID, Match{Status: StatusPlayed}); !errors.Is(err, ErrStatusTransition) {
+		t.Fatalf("expected ErrStatusTransition, got %v", err)
+	}
+	if _, err := repo.Update(ctx, m.ID, Match{Status: "abandoned"}); !errors.Is(err, ErrInvalidStatus) {
+		t.Fatalf("expected ErrInvalidStatus, got %v", err)
+	}
+	if _, err := repo.Update(ctx, m.ID, Match{RescheduledTo: &m.ID}); !errors.Is(err, ErrRescheduledTarget) {
+		t.Fatalf("expected ErrRescheduledTarget, got %v", err)
+	}
+}
+
+func TestRepository_PlayedFlagMovesToPlayed(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30"})
+	up, err := repo.Update(ctx, m.ID, Match{Played: true, GoalsFor: 3, GoalsAgainst: 1})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	assertEq(t, Status(up.Status), StatusPlayed)
+	assertEq(t, bval(up.Played), true)
+}
+
+func TestRepository_SequenceBumpsOnReschedule(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", Venue: "Hall A"})
+	up, _ := repo.Update(ctx, m.ID, Match{Notes: "Ta med vattenflaska"})
+	assertEq(t, up.Sequence, int64(0))
+	up, _ = repo.Update(ctx, m.ID, Match{TimeRaw: "16:00"})
+	assertEq(t, up.Sequence, int64(1))
+	up, _ = repo.Update(ctx, m.ID, Match{Venue: "Hall B"})
+	assertEq(t, up.Sequence, int64(2))
+}
+
+func TestWriteICal_StatusAndSequence(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", HomeTeam: "A", AwayTeam: "B"})
+	if _, err := repo.Update(ctx, m.ID, Match{Status: StatusCancelled, StatusReason: "Snöoväder"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	list, _ := repo.List(ctx)
+
+	var b strings.Builder
+	writeICal(&b, list, settings.Defaults(), icalOptions{gather: repo.gatherResolver(ctx, settings.Defaults())})
+	out := b.String()
+	for _, want := range []string{"STATUS:CANCELLED", "SEQUENCE:1", "DESCRIPTION:Snöoväder", "DTSTART:20250920T123000Z", "DTEND:20250920T133000Z"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ical missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestValidateScore(t *testing.T) {
+	cases := []struct {
+		name    string
+		periods []Score
+		ot, so  *Score
+		gf, ga  int64
+		wantErr bool
+	}{
+		{"halves sum", []Score{{2, 1}, {1, 1}}, nil, nil, 3, 2, false},
+		{"wrong count", []Score{{1, 0}, {1, 0}, {1, 0}}, nil, nil, 3, 0, true},
+		{"mismatch", []Score{{2, 1}, {1, 1}}, nil, nil, 4, 2, true},
+		{"overtime counts", []Score{{1, 1}, {1, 1}}, &Score{1, 0}, nil, 3, 2, false},
+		{"shootout after draw", []Score{{1, 1}, {1, 1}}, &Score{0, 0}, &Score{4, 3}, 2, 2, false},
+		{"shootout without draw", nil, nil, &Score{4, 3}, 3, 2, true},
+		{"tied shootout", nil, nil, &Score{3, 3}, 2, 2, true},
+		{"negative", []Score{{-1, 0}, {1, 0}}, nil, nil, 0, 0, true},
+	}
+	for _, tc := range cases {
+		err := validateScore(tc.periods, tc.ot, tc.so, tc.gf, tc.ga, 2)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err=%v, wantErr=%v", tc.name, err, tc.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidScore) {
+			t.Errorf("%s: expected ErrInvalidScore, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestRepository_PeriodScores(t *testing.T) {
+	repo, db := newTestRepo(t)
+	ctx := context.Background()
+	if _, err := settings.NewService(db).PutLeague(ctx, settings.LeagueRules{Name: "Innebandy", Periods: 3}); err != nil {
+		t.Fatalf("put league: %v", err)
+	}
+
+	// Final score is derived from the periods when omitted
+	m, err := repo.Create(ctx, Match{League: "Innebandy", Team: "A", Opponent: "B", Played: true,
+		Periods: []Score{{1, 0}, {2, 2}, {0, 1}}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	assertEq(t, ival(m.GoalsFor), int64(3))
+	assertEq(t, ival(m.GoalsAgainst), int64(3))
+	ps, _ := repo.Periods(ctx, m.ID)
+	assertEq(t, len(ps), 3)
+
+	// Two halves in a three-period league is rejected
+	if _, err := repo.Create(ctx, Match{League: "Innebandy", Periods: []Score{{1, 0}, {1, 0}}}); !errors.Is(err, ErrInvalidScore) {
+		t.Fatalf("expected ErrInvalidScore, got %v", err)
+	}
+
+	up, err := repo.Update(ctx, m.ID, Match{Overtime: &Score{0, 0}, Shootout: &Score{3, 2}})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	assertEq(t, ival(up.ShootoutFor), int64(3))
+
+	// A rejected update leaves the stored periods untouched
+	if _, err := repo.Update(ctx, m.ID, Match{Periods: []Score{{5, 0}, {0, 0}, {0, 0}}, GoalsFor: 4}); !errors.Is(err, ErrInvalidScore) {
+		t.Fatalf("expected ErrInvalidScore, got %v", err)
+	}
+	ps, _ = repo.Periods(ctx, m.ID)
+	assertEq(t, ps[0], Score{1, 0})
+
+	// An empty list clears the periods
+	if _, err := repo.Update(ctx, m.ID, Match{Periods: []Score{}}); err != nil {
+		t.Fatalf("clear periods: %v", err)
+	}
+	ps, _ = repo.Periods(ctx, m.ID)
+	assertEq(t, len(ps), 0)
+}
+
+func TestComputeStandings(t *testing.T) {
+	played := func(team, opp string, gf, ga int64, ot, so *Score) dbpkg.Match {
+		m := dbpkg.Match{Team: &team, Opponent: &opp, GoalsFor: &gf, GoalsAgainst: &ga, Status: string(StatusPlayed)}
+		if ot != nil {
+			m.OvertimeFor, m.OvertimeAgainst = &ot.For, &ot.Against
+		}
+		if so != nil {
+			m.ShootoutFor, m.ShootoutAgainst = &so.For, &so.Against
+		}
+		return m
+	}
+	cfg := settings.Defaults()
+	cfg.PointsWin, cfg.PointsOvertimeWin, cfg.PointsOvertimeLoss = 3, 2, 1
+	list := []dbpkg.Match{
+		played("A", "B", 3, 1, nil, nil),
+		played("A", "C", 2, 2, nil, nil),
+		played("B", "C", 3, 2, &Score{1, 0}, nil),
+		played("A", "B", 1, 1, &Score{0, 0}, &Score{2, 4}),
+		played("A", "C", 0, 0, nil, nil),
+	}
+	list[4].Status = string(StatusScheduled) // not counted
+	got := computeStandings(list, "", cfg)
+	want := map[string]Standing{
+		"A": {Team: "A", Played: 3, Won: 1, Drawn: 1, OvertimeLost: 1, GoalsFor: 6, GoalsAgainst: 4, GoalDiff: 2, Points: 5},
+		"B": {Team: "B", Played: 3, OvertimeWon: 2, Lost: 1, GoalsFor: 5, GoalsAgainst: 6, GoalDiff: -1, Points: 4},
+		"C": {Team: "C", Played: 2, Drawn: 1, OvertimeLost: 1, GoalsFor: 4, GoalsAgainst: 5, GoalDiff: -1, Points: 2},
+	}
+	assertEq(t, len(got), 3)
+	assertEq(t, got[0].Team, "A")
+	for _, s := range got {
+		assertEq(t, s, want[s.Team])
+	}
+}
+
+func TestRepository_EventsDriveScore(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+
+	add := func(e Event) dbpkg.Match {
+		t.Helper()
+		_, row, err := repo.AddEvent(ctx, m.ID, e)
+		if err != nil {
+			t.Fatalf("add %s: %v", e.Kind, err)
+		}
+		return row
+	}
+	add(Event{Kind: EventPeriodStart, Period: 1})
+	add(Event{Kind: EventGoal, Side: SideUs, Period: 1, Player: "Svensson", Assist: "Karlsson"})
+	add(Event{Kind: EventPenalty, Side: SideThem, Period: 1, PenaltyMinutes: 2})
+	add(Event{Kind: EventGoal, Side: SideThem, Period: 2})
+	row := add(Event{Kind: EventGoal, Side: SideUs, Period: 2})
+	assertEq(t, ival(row.GoalsFor), int64(2))
+	assertEq(t, ival(row.GoalsAgainst), int64(1))
+	ps, _ := repo.Periods(ctx, m.ID)
+	assertEq(t, len(ps), 2)
+	assertEq(t, ps[1], Score{1, 1})
+
+	if _, _, err := repo.AddEvent(ctx, m.ID, Event{Kind: EventGoal}); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected ErrInvalidEvent, got %v", err)
+	}
+
+	events, _ := repo.Events(ctx, m.ID)
+	assertEq(t, len(events), 5)
+	assertEq(t, events[3].Score, Score{1, 1})
+
+	// Undo the last goal
+	row, err := repo.DeleteEvent(ctx, m.ID, events[4].ID)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	assertEq(t, ival(row.GoalsFor), int64(1))
+
+	row = add(Event{Kind: EventMatchEnd})
+	assertEq(t, Status(row.Status), StatusPlayed)
+	assertEq(t, bval(row.Played), true)
+}
+
+func TestRepository_ChangesAreAudited(t *testing.T) {
+	repo, db := newTestRepo(t)
+	log := audit.NewService(db)
+	ctx := audit.WithActor(context.Background(), 42)
+
+	m, err := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", Team: "A", Opponent: "B"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := repo.Update(ctx, m.ID, Match{Played: true, GoalsFor: 3, GoalsAgainst: 1, Periods: []Score{{2, 0}, {1, 1}}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := repo.Delete(ctx, m.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	list, err := log.List(ctx, audit.Filter{Entity: audit.EntityMatch, EntityID: m.ID})
+	if err != nil || len(list) != 3 {
+		t.Fatalf("expected create, update and delete entries: %v %+v", err, list)
+	}
+	del, upd, cre := list[0], list[1], list[2]
+	assertEq(t, cre.Action, AuditCreate)
+	assertEq(t, upd.Action, AuditUpdate)
+	assertEq(t, del.Action, AuditDelete)
+	assertEq(t, *upd.UserID, int64(42))
+	assertEq(t, string(upd.Before), `{"goals_against":0,"goals_for":0,"periods":[],"played":false,"sequence":0,"status":"scheduled","version":1}`)
+	assertEq(t, string(upd.After), `{"goals_against":1,"goals_for":3,"periods":[{"for":2,"against":0},{"for":1,"against":1}],"played":true,"sequence":1,"status":"played","version":2}`)
+	if cre.Before != nil || del.After != nil || !strings.Contains(string(del.Before), `"periods":[{"for":2`) {
+		t.Fatalf("unexpected create/delete entries: %+v %+v", cre, del)
+	}
+
+	// Deleting a missing match records nothing
+	repo.Delete(ctx, m.ID)
+	if list, _ := log.List(ctx, audit.Filter{Action: AuditDelete}); len(list) != 1 {
+		t.Fatalf("expected one delete entry, got %d", len(list))
+	}
+
+	repo.Create(ctx, Match{Team: "A", Opponent: "C"})
+	if n, err := repo.DeleteAll(ctx); err != nil || n != 1 {
+		t.Fatalf("delete all: %d %v", n, err)
+	}
+	list, _ = log.List(ctx, audit.Filter{Action: AuditDeleteAll})
+	if len(list) != 1 || !strings.Contains(string(list[0].Before), `"opponent":"C"`) || string(list[0].After) != `{"deleted":1}` {
+		t.Fatalf("expected the wiped schedule in the entry: %+v", list)
+	}
+}
+
+func TestImport_QueuedIsAuditedAsEnqueuer(t *testing.T) {
+	repo, db := newTestRepo(t)
+	q := jobs.New(db)
+	repo.UseJobs(q)
+	ctx := context.Background()
+
+	csv := []byte("Matchnr;Hemmalag;Bortalag\r\n1;A;B\r\n")
+	if _, err := repo.QueueImport(audit.WithActor(ctx, 42), "schema.csv", csv, "A"); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	q.RunNext(ctx)
+
+	list, err := audit.NewService(db).List(ctx, audit.Filter{UserID: 42})
+	if err != nil || len(list) != 2 {
+		t.Fatalf("expected the create and the import summary: %v %+v", err, list)
+	}
+	assertEq(t, list[0].Action, AuditImport)
+	assertEq(t, string(list[0].After), `{"failed":0,"imported":1,"rows":1,"updated":0}`)
+	assertEq(t, list[1].Action, AuditCreate)
+}
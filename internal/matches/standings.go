@@ -0,0 +1,112 @@
+package matches
+
+import (
+	"sort"
+	"strings"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// Standing is one row in a league table.
+type Standing struct {
+	Team         string `json:"team"`
+	Played       int    `json:"played"`
+	Won          int    `json:"won"`
+	OvertimeWon  int    `json:"overtime_won"`
+	Drawn        int    `json:"drawn"`
+	OvertimeLost int    `json:"overtime_lost"`
+	Lost         int    `json:"lost"`
+	GoalsFor     int64  `json:"goals_for"`
+	GoalsAgainst int64  `json:"goals_against"`
+	GoalDiff     int64  `json:"goal_diff"`
+	Points       int    `json:"points"`
+}
+
+// computeStandings builds a table from played matches. Each match counts for
+// both Team and Opponent; wins decided in overtime or a shootout use the
+// overtime points from cfg. An empty league includes every match.
+func computeStandings(list []dbpkg.Match, league string, cfg settings.Settings) []Standing {
+	rows := map[string]*Standing{}
+	row := func(name string) *Standing {
+		key := strings.ToLower(name)
+		if rows[key] == nil {
+			rows[key] = &Standing{Team: name}
+		}
+		return rows[key]
+	}
+	record := func(s *Standing, gf, ga int64, extra bool, shootout *Score, flip bool) {
+		s.Played++
+		s.GoalsFor += gf
+		s.GoalsAgainst += ga
+		won := gf > ga
+		if gf == ga && shootout != nil {
+			won = shootout.For > shootout.Against
+			if flip {
+				won = !won
+			}
+		}
+		switch {
+		case gf == ga && shootout == nil:
+			s.Drawn++
+			s.Points += cfg.PointsDraw
+		case won && extra:
+			s.OvertimeWon++
+			s.Points += cfg.PointsOvertimeWin
+		case won:
+			s.Won++
+			s.Points += cfg.PointsWin
+		case extra:
+			s.OvertimeLost++
+			s.Points += cfg.PointsOvertimeLoss
+		default:
+			s.Lost++
+			s.Points += cfg.PointsLoss
+		}
+	}
+
+	for _, m := range list {
+		if !Status(m.Status).IsPlayed() {
+			continue
+		}
+		if league != "" && !strings.EqualFold(sval(m.League), league) {
+			continue
+		}
+		team := sval(m.Team)
+		if team == "" {
+			team = cfg.OurTeam
+		}
+		opp := sval(m.Opponent)
+		if team == "" || opp == "" {
+			continue
+		}
+		gf, ga := ival(m.GoalsFor), ival(m.GoalsAgainst)
+		ot := scorePtr(m.OvertimeFor, m.OvertimeAgainst)
+		so := scorePtr(m.ShootoutFor, m.ShootoutAgainst)
+		// Decided after regulation if there was a shootout or a goal in overtime
+		extra := so != nil || (ot != nil && ot.For != ot.Against)
+
+		record(row(team), gf, ga, extra, so, false)
+		record(row(opp), ga, gf, extra, so, true)
+	}
+
+	out := make([]Standing, 0, len(rows))
+	for _, s := range rows {
+		s.GoalDiff = s.GoalsFor - s.GoalsAgainst
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.GoalDiff != b.GoalDiff {
+			return a.GoalDiff > b.GoalDiff
+		}
+		if a.GoalsFor != b.GoalsFor {
+			return a.GoalsFor > b.GoalsFor
+		}
+		return a.Team < b.Team
+	})
+	return out
+}
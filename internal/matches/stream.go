@@ -0,0 +1,68 @@
+package matches
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat keeps idle connections open through proxies.
+const streamHeartbeat = 25 * time.Second
+
+// registerStreamRoutes mounts GET /api/events/stream, a Server-Sent Events feed
+// of repository changes. ?match_id= limits the feed to one match.
+func registerStreamRoutes(api *gin.RouterGroup, hub *Hub) {
+	api.GET("/events/stream", func(c *gin.Context) {
+		var only int64
+		if v := c.Query("match_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match_id"})
+				return
+			}
+			only = id
+		}
+
+		changes, unsubscribe := hub.Subscribe()
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // nginx: don't buffer the stream
+		// The stream outlives the server's write timeout
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+		c.Status(http.StatusOK)
+		_, _ = io.WriteString(c.Writer, "retry: 3000\n\n")
+		c.Writer.Flush()
+
+		ticker := time.NewTicker(streamHeartbeat)
+		defer ticker.Stop()
+		ctx := c.Request.Context()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hub.Done():
+				// Server is shutting down; the client reconnects after retry
+				return
+			case ch, ok := <-changes:
+				if !ok {
+					return
+				}
+				// Bulk deletes have no match id and reach every subscriber
+				if only != 0 && ch.MatchID != 0 && ch.MatchID != only {
+					continue
+				}
+				c.SSEvent(ch.Type, ch)
+				c.Writer.Flush()
+			case <-ticker.C:
+				_, _ = io.WriteString(c.Writer, ": ping\n\n")
+				c.Writer.Flush()
+			}
+		}
+	})
+}
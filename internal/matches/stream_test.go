@@ -0,0 +1,111 @@
+package matches
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHub_DropsForSlowSubscribers(t *testing.T) {
+	h := NewHub()
+	ch, unsubscribe := h.Subscribe()
+	for i := 0; i < 100; i++ {
+		h.Publish(context.Background(), Change{Type: ChangeUpdated, MatchID: int64(i)})
+	}
+	assertEq(t, len(ch), cap(ch))
+	unsubscribe()
+	unsubscribe() // idempotent
+	assertEq(t, h.Subscribers(), 0)
+}
+
+func TestEventStream_BroadcastsRepositoryChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, _ := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, nil, nil)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/events/stream", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer res.Body.Close()
+	assertEq(t, res.Header.Get("Content-Type"), "text/event-stream")
+
+	// Wait for the handler to subscribe before mutating
+	for repo.Hub().Subscribers() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	if _, _, err := repo.AddEvent(ctx, m.ID, Event{Kind: EventGoal, Side: SideUs}); err != nil {
+		t.Fatalf("add event: %v", err)
+	}
+
+	var got []string
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() {
+		if name, ok := strings.CutPrefix(sc.Text(), "event:"); ok {
+			got = append(got, name)
+		}
+		if data, ok := strings.CutPrefix(sc.Text(), "data:"); ok && len(got) == 2 {
+			if !strings.Contains(data, `"goals_for":1`) {
+				t.Errorf("live event should carry the new score: %s", data)
+			}
+			break
+		}
+	}
+	assertEq(t, strings.Join(got, ","), ChangeCreated+","+ChangeLiveEvent)
+}
+
+func TestEventStream_EndsWhenHubCloses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, _ := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, nil, nil)
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/events/stream", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer res.Body.Close()
+	for repo.Hub().Subscribers() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The write timeout does not apply to the stream
+	time.Sleep(100 * time.Millisecond)
+	if _, err := repo.Create(ctx, Match{Team: "A", Opponent: "B"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() && !strings.HasPrefix(sc.Text(), "event:") {
+	}
+	assertEq(t, sc.Text(), "event:"+ChangeCreated)
+
+	repo.Hub().Close()
+	for sc.Scan() {
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("expected the stream to end cleanly: %v", err)
+	}
+	for repo.Hub().Subscribers() != 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,202 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+// ErrInvalidSyncToken means the since token is not one this server hands out.
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+// Tombstone marks a match deleted after the client's token.
+type Tombstone struct {
+	ID        int64     `json:"id"`
+	Version   int64     `json:"version"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// SyncDelta is what changed after a token. With Full set, Matches is the
+// whole schedule and the client drops anything else it keeps.
+type SyncDelta struct {
+	Token   string      `json:"token"`
+	Full    bool        `json:"full"`
+	Matches []Match     `json:"matches"`
+	Deleted []Tombstone `json:"deleted"`
+}
+
+// Changes returns the matches created, changed or deleted after since, and
+// the token to pass next time. Every write to a match stamps it with the next
+// change token, so the token only moves forward. An empty since, a token
+// older than the last purge of the trash, or one the server has not reached
+// (as after restoring a backup) gets the full schedule.
+func (r *Repository) Changes(ctx context.Context, since string) (SyncDelta, error) {
+	var from int64
+	if since != "" {
+		var err error
+		if from, err = strconv.ParseInt(since, 10, 64); err != nil || from < 0 {
+			return SyncDelta{}, ErrInvalidSyncToken
+		}
+	}
+	out := SyncDelta{Matches: []Match{}, Deleted: []Tombstone{}}
+	gather := r.gatherResolver(ctx, r.cfg.Current(ctx))
+	// One transaction, so the token matches the rows read
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		rows, state, full, err := changedRows(ctx, q, from)
+		if err != nil {
+			return err
+		}
+		out.Token = strconv.FormatInt(state.Seq, 10)
+		out.Full = full
+		periods, err := allPeriods(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if row.DeletedAt != nil {
+				out.Deleted = append(out.Deleted, Tombstone{ID: row.ID, Version: row.Version, DeletedAt: *row.DeletedAt})
+				continue
+			}
+			m := toAPIWithPeriods(row, periods[row.ID])
+			m.GatherAt = timePtr(gather(row))
+			out.Matches = append(out.Matches, m)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// changedRows returns the sync state and the matches, deleted ones
+// included, changed after from. If the client cannot be brought up to date
+// from there, it returns every match and full.
+func changedRows(ctx context.Context, q *dbpkg.Queries, from int64) ([]dbpkg.Match, dbpkg.SyncState, bool, error) {
+	state, err := q.GetSyncState(ctx)
+	if err != nil {
+		return nil, state, false, err
+	}
+	full := from == 0 || from < state.PurgedSeq || from > state.Seq
+	var rows []dbpkg.Match
+	if full {
+		rows, err = q.ListMatches(ctx)
+	} else {
+		rows, err = q.ListMatchesChangedSince(ctx, from)
+	}
+	return rows, state, full, err
+}
+
+// SyncChange is a change made on a client while offline. It is a bulk
+// operation on one match; a create with a ClientID is applied once however
+// often the upload is retried.
+type SyncChange struct {
+	ClientID string `json:"client_id,omitempty"`
+	BulkOp
+}
+
+// SyncResult reports one uploaded change. On a version conflict Current is
+// the match as the server has it, for the client to merge and retry.
+type SyncResult struct {
+	BulkResult
+	ClientID string `json:"client_id,omitempty"`
+	Status   int    `json:"status"`
+	Conflict bool   `json:"conflict,omitempty"`
+	Current  *Match `json:"current,omitempty"`
+}
+
+// Upload applies changes in order, each in its own transaction, so one
+// conflict does not hold back the rest.
+func (r *Repository) Upload(ctx context.Context, changes []SyncChange) ([]SyncResult, error) {
+	if len(changes) == 0 || len(changes) > MaxBulkOps {
+		return nil, fmt.Errorf("%w: expected 1 to %d changes, got %d", ErrInvalidBulk, MaxBulkOps, len(changes))
+	}
+	results := make([]SyncResult, 0, len(changes))
+	for i, ch := range changes {
+		results = append(results, r.uploadOne(ctx, i, ch))
+	}
+	return results, nil
+}
+
+func (r *Repository) uploadOne(ctx context.Context, i int, ch SyncChange) SyncResult {
+	res := SyncResult{BulkResult: BulkResult{Index: i, Op: ch.Op, IDs: []int64{}}, ClientID: ch.ClientID}
+	idempotent := ch.Op == BulkCreate && ch.ClientID != ""
+	var pub []func()
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		if ch.Filter != nil {
+			return fmt.Errorf("%w: sync changes target one match by id", ErrInvalidBulk)
+		}
+		if idempotent {
+			up, err := q.GetSyncUpload(ctx, ch.ClientID)
+			if err == nil {
+				// Created by an earlier attempt whose response was lost
+				res.IDs = append(res.IDs, up.MatchID)
+				return nil
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		var err error
+		if pub, err = r.bulkOne(ctx, q, ch.BulkOp, &res.BulkResult); err != nil {
+			return err
+		}
+		if idempotent {
+			return q.CreateSyncUpload(ctx, dbpkg.CreateSyncUploadParams{ClientID: ch.ClientID, MatchID: res.IDs[0], CreatedAt: *stamp()})
+		}
+		return nil
+	})
+	res.Status = bulkItemStatus(err)
+	if err != nil {
+		res.IDs = []int64{}
+		res.err, res.Error = err, err.Error()
+		if errors.Is(err, ErrVersionMismatch) {
+			res.Conflict = true
+			if row, err := r.Get(ctx, ch.ID); err == nil {
+				periods, _ := r.Periods(ctx, row.ID)
+				m := r.apiMatch(ctx, row, periods)
+				res.Current = &m
+			}
+		}
+		return res
+	}
+	for _, p := range pub {
+		p()
+	}
+	return res
+}
+
+// ----- Routes -----
+
+type uploadReq struct {
+	Changes []SyncChange `json:"changes"`
+}
+
+func registerSyncRoutes(api *gin.RouterGroup, repo *Repository, protect gin.HandlerFunc) {
+	api.GET("/sync", func(c *gin.Context) {
+		delta, err := repo.Changes(c.Request.Context(), c.Query("since"))
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, delta)
+	})
+
+	api.POST("/sync", attachProtect(protect, func(c *gin.Context) {
+		var req uploadReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+			return
+		}
+		results, err := repo.Upload(c.Request.Context(), req.Changes)
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"results": results})
+	}))
+}
@@ -0,0 +1,158 @@
+package matches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestRepository_ChangesSinceToken(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	a, _ := repo.Create(ctx, Match{DateRaw: "2025-10-04", Team: "A", Opponent: "B"})
+	b, _ := repo.Create(ctx, Match{DateRaw: "2025-10-11", Team: "A", Opponent: "C"})
+	if a.CreatedAt == nil || b.ChangeSeq <= a.ChangeSeq {
+		t.Fatalf("expected created_at and increasing change tokens: %+v %+v", a, b)
+	}
+
+	full, err := repo.Changes(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !full.Full || len(full.Matches) != 2 || len(full.Deleted) != 0 {
+		t.Fatalf("expected the full schedule: %+v", full)
+	}
+
+	repo.Patch(ctx, a.ID, MatchPatch{Venue: Val("Hallen")})
+	repo.Delete(ctx, b.ID)
+	delta, _ := repo.Changes(ctx, full.Token)
+	if delta.Full || len(delta.Matches) != 1 || delta.Matches[0].Venue != "Hallen" ||
+		len(delta.Deleted) != 1 || delta.Deleted[0].ID != b.ID {
+		t.Fatalf("unexpected delta: %+v", delta)
+	}
+	next, _ := strconv.ParseInt(delta.Token, 10, 64)
+	prev, _ := strconv.ParseInt(full.Token, 10, 64)
+	if next <= prev {
+		t.Fatalf("expected the token to move forward: %s -> %s", full.Token, delta.Token)
+	}
+	if empty, _ := repo.Changes(ctx, delta.Token); empty.Full || len(empty.Matches)+len(empty.Deleted) != 0 || empty.Token != delta.Token {
+		t.Fatalf("expected nothing new: %+v", empty)
+	}
+
+	// A restored match comes back as a change
+	repo.Undelete(ctx, b.ID)
+	if d, _ := repo.Changes(ctx, delta.Token); len(d.Matches) != 1 || d.Matches[0].ID != b.ID {
+		t.Fatalf("expected the restored match: %+v", d)
+	}
+
+	// After a purge the tombstone is gone, so older tokens start over
+	mark, _ := repo.Changes(ctx, delta.Token)
+	repo.Delete(ctx, b.ID)
+	clock = func() time.Time { return time.Now().Add(time.Minute) }
+	t.Cleanup(func() { clock = time.Now })
+	repo.PurgeDeletedBefore(ctx, time.Time{})
+	if d, _ := repo.Changes(ctx, mark.Token); !d.Full || len(d.Matches) != 1 || d.Matches[0].ID != a.ID {
+		t.Fatalf("expected a full sync after the purge: %+v", d)
+	}
+
+	if d, _ := repo.Changes(ctx, "9999"); !d.Full {
+		t.Fatalf("expected a full sync for a token from the future: %+v", d)
+	}
+	if _, err := repo.Changes(ctx, "abc"); !errors.Is(err, ErrInvalidSyncToken) {
+		t.Fatalf("expected an invalid token error, got %v", err)
+	}
+}
+
+func TestRepository_UploadReportsConflicts(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B", Notes: "Buss 12:00"})
+	repo.Patch(ctx, m.ID, MatchPatch{Notes: Val("Buss 12:30")}) // version 2 on the server
+
+	newMatch := Match{DateRaw: "2025-10-25", Team: "A", Opponent: "C"}
+	changes := []SyncChange{
+		{ClientID: "phone-1", BulkOp: BulkOp{Op: BulkCreate, Match: &newMatch}},
+		{BulkOp: BulkOp{Op: BulkPatch, ID: m.ID, Version: 1, Patch: MatchPatch{Notes: Val("Buss 11:45")}}},
+		{BulkOp: BulkOp{Op: BulkPatch, ID: m.ID, Version: 2, Patch: MatchPatch{Venue: Val("Hallen")}}},
+		{BulkOp: BulkOp{Op: BulkDelete, ID: 99}},
+	}
+	results, err := repo.Upload(ctx, changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEq(t, results[0].Status, http.StatusOK)
+	assertEq(t, results[0].ClientID, "phone-1")
+	created := results[0].IDs[0]
+
+	conflict := results[1]
+	if conflict.Status != http.StatusPreconditionFailed || !conflict.Conflict || conflict.Current == nil ||
+		conflict.Current.Notes != "Buss 12:30" || conflict.Current.Version != 2 {
+		t.Fatalf("expected a conflict with the server's match: %+v", conflict)
+	}
+	assertEq(t, results[2].Status, http.StatusOK)
+	assertEq(t, results[3].Status, http.StatusNotFound)
+	row, _ := repo.Get(ctx, m.ID)
+	assertEq(t, sval(row.Venue), "Hallen")
+	assertEq(t, sval(row.Notes), "Buss 12:30")
+
+	// A retried upload does not create the match twice
+	results, _ = repo.Upload(ctx, changes[:1])
+	assertEq(t, results[0].IDs[0], created)
+	list, _ := repo.List(ctx)
+	assertEq(t, len(list), 2)
+
+	venue := "Hallen"
+	results, _ = repo.Upload(ctx, []SyncChange{{BulkOp: BulkOp{Op: BulkDelete, Filter: &BulkFilter{Venue: &venue}}}})
+	assertEq(t, results[0].Status, http.StatusBadRequest)
+	if _, err := repo.Upload(ctx, nil); !errors.Is(err, ErrInvalidBulk) {
+		t.Fatalf("expected an invalid upload error, got %v", err)
+	}
+}
+
+func TestSyncRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+	repo.Create(context.Background(), Match{Team: "A", Opponent: "B"})
+
+	get := func(since string) (int, SyncDelta) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/sync?since="+since, nil))
+		var d SyncDelta
+		json.Unmarshal(w.Body.Bytes(), &d)
+		return w.Code, d
+	}
+	code, d := get("")
+	if code != http.StatusOK || !d.Full || len(d.Matches) != 1 || d.Token == "" {
+		t.Fatalf("sync: %d %+v", code, d)
+	}
+
+	w := httptest.NewRecorder()
+	body := `{"changes": [{"op": "patch", "id": 1, "version": 1, "patch": {"notes": "Ta med västar"}}, {"op": "create", "client_id": "x1", "match": {"team": "A", "opponent": "C"}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/sync", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	var out struct{ Results []SyncResult }
+	json.Unmarshal(w.Body.Bytes(), &out)
+	if w.Code != http.StatusOK || len(out.Results) != 2 || out.Results[0].Status != http.StatusOK || out.Results[1].ClientID != "x1" {
+		t.Fatalf("upload: %d %s", w.Code, w.Body.String())
+	}
+
+	_, d2 := get(d.Token)
+	if d2.Full || len(d2.Matches) != 2 {
+		t.Fatalf("expected both changes: %+v", d2)
+	}
+	if code, _ := get("abc"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad token, got %d", code)
+	}
+}
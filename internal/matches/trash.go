@@ -0,0 +1,189 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/audit"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+// Audit actions for the trash.
+const (
+	AuditUndelete = "match.undelete"
+	AuditPurge    = "match.purge"
+	AuditPurgeAll = "matches.purge"
+)
+
+// DefaultTrashRetention is how long deleted matches are kept before the
+// automatic purge removes them.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashedMatch is a deleted match waiting in the trash.
+type TrashedMatch struct {
+	Match
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Trash lists deleted matches, most recently deleted first.
+func (r *Repository) Trash(ctx context.Context) ([]TrashedMatch, error) {
+	rows, err := r.q.ListDeletedMatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]TrashedMatch, 0, len(rows))
+	for _, row := range rows {
+		periods, err := loadPeriods(ctx, r.q, row.ID)
+		if err != nil {
+			return nil, err
+		}
+		t := TrashedMatch{Match: toAPIWithPeriods(row, periods)}
+		if row.DeletedAt != nil {
+			t.DeletedAt = *row.DeletedAt
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// Undelete moves a match out of the trash. It returns sql.ErrNoRows if the
+// match is not in the trash.
+func (r *Repository) Undelete(ctx context.Context, id int64) (dbpkg.Match, error) {
+	var row dbpkg.Match
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		seq, err := q.NextChangeSeq(ctx)
+		if err != nil {
+			return err
+		}
+		if row, err = q.RestoreDeletedMatch(ctx, dbpkg.RestoreDeletedMatchParams{UpdatedAt: stamp(), ChangeSeq: seq, ID: id}); err != nil {
+			return err
+		}
+		next, err := r.auditForm(ctx, q, row)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, AuditUndelete, audit.EntityMatch, id, nil, next)
+	})
+	if err == nil {
+		// To feeds and clients the match is new again
+		r.publish(ctx, ChangeCreated, row)
+	}
+	return row, err
+}
+
+// Purge removes a match from the trash for good, together with its events,
+// periods and versions. It returns sql.ErrNoRows if the match is not in the trash.
+func (r *Repository) Purge(ctx context.Context, id int64) error {
+	return r.inTx(ctx, func(q *dbpkg.Queries) error {
+		n, err := q.PurgeMatch(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+		// Clients that have not synced since may have missed the deletion
+		if err := q.MarkSyncPurged(ctx); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, AuditPurge, audit.EntityMatch, id, nil, nil)
+	})
+}
+
+// PurgeDeletedBefore removes every match that went to the trash at or before
+// cutoff. A zero cutoff empties the trash.
+func (r *Repository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	if cutoff.IsZero() {
+		cutoff = clock()
+	}
+	cutoff = cutoff.UTC().Truncate(time.Second)
+	var n int64
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		var err error
+		if n, err = q.PurgeDeletedMatches(ctx, &cutoff); err != nil || n == 0 {
+			return err
+		}
+		if err := q.MarkSyncPurged(ctx); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, AuditPurgeAll, audit.EntityMatch, 0, nil, map[string]int64{"purged": n})
+	})
+	if err == nil && n > 0 {
+		slog.InfoContext(ctx, "trash purged", "count", n)
+	}
+	return n, err
+}
+
+// RunTrashPurge purges matches that have been in the trash longer than
+// retention, once at start and then hourly, until ctx is cancelled.
+func (r *Repository) RunTrashPurge(ctx context.Context, retention time.Duration) {
+	t := time.NewTicker(time.Hour)
+	defer t.Stop()
+	for {
+		if _, err := r.PurgeDeletedBefore(ctx, clock().Add(-retention)); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "trash: purge", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// ----- Routes -----
+
+func registerTrashRoutes(api *gin.RouterGroup, repo *Repository, protect gin.HandlerFunc) {
+	api.GET("/matches/trash", attachProtect(protect, func(c *gin.Context) {
+		list, err := repo.Trash(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}))
+
+	api.POST("/matches/trash/:id/restore", attachProtect(protect, func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		row, err := repo.Undelete(c.Request.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, withPeriods(c, repo, row))
+	}))
+
+	api.DELETE("/matches/trash/:id", attachProtect(protect, func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		err := repo.Purge(c.Request.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}))
+
+	// Empty the trash
+	api.DELETE("/matches/trash", attachProtect(protect, func(c *gin.Context) {
+		n, err := repo.PurgeDeletedBefore(c.Request.Context(), time.Time{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"purged": n})
+	}))
+}
@@ -0,0 +1,133 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestRepository_DeleteMovesToTrash(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{MatchNumber: "101", Team: "A", Opponent: "B"})
+	repo.AddEvent(ctx, m.ID, Event{Kind: EventGoal, Side: SideUs})
+
+	if err := repo.Delete(ctx, m.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if list, _ := repo.List(ctx); len(list) != 0 {
+		t.Fatalf("expected the match to leave the list, got %d", len(list))
+	}
+	if _, err := repo.Get(ctx, m.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected a trashed match to be not found, got %v", err)
+	}
+	trash, err := repo.Trash(ctx)
+	if err != nil || len(trash) != 1 || trash[0].ID != m.ID || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("unexpected trash: %v %+v", err, trash)
+	}
+
+	row, err := repo.Undelete(ctx, m.ID)
+	if err != nil {
+		t.Fatalf("undelete: %v", err)
+	}
+	assertEq(t, sval(row.Opponent), "B")
+	events, _ := repo.Events(ctx, m.ID)
+	assertEq(t, len(events), 1)
+	if _, err := repo.Undelete(ctx, m.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected a match outside the trash to be not found, got %v", err)
+	}
+}
+
+func TestRepository_PurgeDeletedBefore(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+	base := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	clock = func() time.Time { return base }
+	t.Cleanup(func() { clock = time.Now })
+
+	old, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	recent, _ := repo.Create(ctx, Match{Team: "A", Opponent: "C"})
+	kept, _ := repo.Create(ctx, Match{Team: "A", Opponent: "D"})
+	repo.Delete(ctx, old.ID)
+	clock = func() time.Time { return base.Add(10 * 24 * time.Hour) }
+	repo.Delete(ctx, recent.ID)
+
+	n, err := repo.PurgeDeletedBefore(ctx, base.Add(5*24*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("expected one purged match: %d %v", n, err)
+	}
+	trash, _ := repo.Trash(ctx)
+	if len(trash) != 1 || trash[0].ID != recent.ID {
+		t.Fatalf("expected only the recent deletion in the trash: %+v", trash)
+	}
+	if _, err := repo.Undelete(ctx, old.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected the purged match to be gone, got %v", err)
+	}
+
+	// A zero cutoff empties the trash but leaves live matches alone
+	if n, _ := repo.PurgeDeletedBefore(ctx, time.Time{}); n != 1 {
+		t.Fatalf("expected the rest of the trash purged, got %d", n)
+	}
+	list, _ := repo.List(ctx)
+	if len(list) != 1 || list[0].ID != kept.ID {
+		t.Fatalf("expected the live match to stay: %+v", list)
+	}
+}
+
+func TestTrashRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+	ctx := context.Background()
+	repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	repo.Create(ctx, Match{Team: "A", Opponent: "C"})
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	if w := do(http.MethodDelete, "/api/matches"); w.Code != http.StatusOK {
+		t.Fatalf("delete all: %d %s", w.Code, w.Body.String())
+	}
+	w := do(http.MethodGet, "/api/matches/trash")
+	var trash []TrashedMatch
+	json.Unmarshal(w.Body.Bytes(), &trash)
+	if w.Code != http.StatusOK || len(trash) != 2 {
+		t.Fatalf("trash: %d %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodPost, "/api/matches/trash/1/restore")
+	var restored Match
+	json.Unmarshal(w.Body.Bytes(), &restored)
+	if w.Code != http.StatusOK || restored.Opponent != "B" {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/api/matches/1"); w.Code != http.StatusOK {
+		t.Fatalf("expected the restored match to be readable, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/matches/trash/2"); w.Code != http.StatusNoContent {
+		t.Fatalf("purge: %d %s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/api/matches/trash/1", "/api/matches/trash/2"} {
+		if w := do(http.MethodDelete, path); w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if w := do(http.MethodPost, "/api/matches/trash/1/restore"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 restoring a live match, got %d", w.Code)
+	}
+	w = do(http.MethodDelete, "/api/matches/trash")
+	if w.Code != http.StatusOK || w.Body.String() != `{"purged":0}` {
+		t.Fatalf("empty trash: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,41 @@
+package matches
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// Upcoming is an open match with its start and gather times resolved.
+type Upcoming struct {
+	Match       Match
+	Start       time.Time
+	Gather      time.Time // zero when neither the match nor its league sets one
+	GatherPlace string
+}
+
+// Upcoming returns scheduled matches starting within [from, to), ordered by start.
+func (r *Repository) Upcoming(ctx context.Context, cfg settings.Settings, from, to time.Time) ([]Upcoming, error) {
+	list, err := r.q.ListMatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gather := r.gatherResolver(ctx, cfg)
+	var out []Upcoming
+	for _, m := range list {
+		if Status(m.Status) != StatusScheduled {
+			continue
+		}
+		start, _ := matchTimes(m, cfg)
+		if start.IsZero() || start.Before(from) || !start.Before(to) {
+			continue
+		}
+		u := Upcoming{Match: toAPI(m), Start: start, Gather: gather(m), GatherPlace: sval(m.GatherPlace)}
+		u.Match.GatherAt = timePtr(u.Gather)
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out, nil
+}
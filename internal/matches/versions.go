@@ -0,0 +1,221 @@
+package matches
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/audit"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+// AuditRestore is the audit action for rolling a match back to a version.
+const AuditRestore = "match.restore"
+
+// Version is an earlier state of a match, kept when a change replaced it.
+type Version struct {
+	Version   int64     `json:"version"`
+	Match     Match     `json:"match"`
+	Action    string    `json:"action"`            // the change that replaced this state
+	UserID    *int64    `json:"user_id,omitempty"` // who made that change
+	CreatedAt time.Time `json:"created_at"`        // when this state was replaced
+}
+
+// versionData is what match_versions.data holds: the row and its periods, so
+// a restore can write back every column rather than merge like a PATCH.
+type versionData struct {
+	Row     dbpkg.Match `json:"row"`
+	Periods []Score     `json:"periods"`
+}
+
+// saveVersion stores row, read through q before a change, as the match's next
+// version. action names the change about to be made.
+func (r *Repository) saveVersion(ctx context.Context, q *dbpkg.Queries, row dbpkg.Match, action string) error {
+	periods, err := loadPeriods(ctx, q, row.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(versionData{Row: row, Periods: periods})
+	if err != nil {
+		return err
+	}
+	latest, err := q.LatestMatchVersion(ctx, row.ID)
+	if err != nil {
+		return err
+	}
+	var user *int64
+	if uid, ok := audit.Actor(ctx); ok {
+		user = &uid
+	}
+	_, err = q.CreateMatchVersion(ctx, dbpkg.CreateMatchVersionParams{
+		MatchID:     row.ID,
+		Version:     latest + 1,
+		Data:        string(data),
+		Action:      action,
+		UserID:      user,
+		CreatedAtMs: time.Now().UnixMilli(),
+	})
+	return err
+}
+
+func toVersion(v dbpkg.MatchVersion) (Version, error) {
+	var d versionData
+	if err := json.Unmarshal([]byte(v.Data), &d); err != nil {
+		return Version{}, err
+	}
+	return Version{
+		Version:   v.Version,
+		Match:     toAPIWithPeriods(d.Row, d.Periods),
+		Action:    v.Action,
+		UserID:    v.UserID,
+		CreatedAt: time.UnixMilli(v.CreatedAtMs).UTC(),
+	}, nil
+}
+
+// Versions lists the earlier states of a match, newest first.
+func (r *Repository) Versions(ctx context.Context, matchID int64) ([]Version, error) {
+	if _, err := r.q.GetMatch(ctx, matchID); err != nil {
+		return nil, err
+	}
+	rows, err := r.q.ListMatchVersions(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Version, 0, len(rows))
+	for _, row := range rows {
+		v, err := toVersion(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// Restore writes version v back to the match. The current state is kept as a
+// new version first, so a restore can itself be undone. The event timeline is
+// left alone; the next logged event recomputes the score from it.
+func (r *Repository) Restore(ctx context.Context, matchID, v int64) (dbpkg.Match, error) {
+	var cur, row dbpkg.Match
+	err := r.inTx(ctx, func(q *dbpkg.Queries) error {
+		var err error
+		if cur, err = q.GetMatch(ctx, matchID); err != nil {
+			return err
+		}
+		ver, err := q.GetMatchVersion(ctx, dbpkg.GetMatchVersionParams{MatchID: matchID, Version: v})
+		if err != nil {
+			return err
+		}
+		var d versionData
+		if err := json.Unmarshal([]byte(ver.Data), &d); err != nil {
+			return err
+		}
+		prev, err := r.auditForm(ctx, q, cur)
+		if err != nil {
+			return err
+		}
+		if err := r.saveVersion(ctx, q, cur, AuditRestore); err != nil {
+			return err
+		}
+		seq, err := q.NextChangeSeq(ctx)
+		if err != nil {
+			return err
+		}
+		old := d.Row
+		row, err = q.UpdateMatch(ctx, dbpkg.UpdateMatchParams{
+			StartIso:          old.StartIso,
+			EndIso:            old.EndIso,
+			DateRaw:           old.DateRaw,
+			TimeRaw:           old.TimeRaw,
+			EndTimeRaw:        old.EndTimeRaw,
+			Weekday:           old.Weekday,
+			League:            old.League,
+			Team:              old.Team,
+			Opponent:          old.Opponent,
+			HomeTeam:          old.HomeTeam,
+			AwayTeam:          old.AwayTeam,
+			Venue:             old.Venue,
+			Court:             old.Court,
+			City:              old.City,
+			GatherTime:        old.GatherTime,
+			GatherPlace:       old.GatherPlace,
+			MatchNumber:       old.MatchNumber,
+			Referees:          old.Referees,
+			Notes:             old.Notes,
+			Played:            old.Played,
+			GoalsFor:          old.GoalsFor,
+			GoalsAgainst:      old.GoalsAgainst,
+			PlayerNotes:       old.PlayerNotes,
+			TopScorerTeam:     old.TopScorerTeam,
+			TopScorerOpponent: old.TopScorerOpponent,
+			Status:            old.Status,
+			StatusReason:      old.StatusReason,
+			RescheduledTo:     old.RescheduledTo,
+			// Always moves forward so subscribed calendars pick the change up
+			Sequence:        cur.Sequence + 1,
+			OvertimeFor:     old.OvertimeFor,
+			OvertimeAgainst: old.OvertimeAgainst,
+			ShootoutFor:     old.ShootoutFor,
+			ShootoutAgainst: old.ShootoutAgainst,
+			UpdatedAt:       stamp(),
+			ChangeSeq:       seq,
+			ID:              matchID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := replacePeriods(ctx, q, matchID, d.Periods); err != nil {
+			return err
+		}
+		next, err := r.auditForm(ctx, q, row)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, AuditRestore, audit.EntityMatch, matchID, prev, next)
+	})
+	if err == nil {
+		r.publish(ctx, ChangeUpdated, row)
+		if resultChanged(cur, row) {
+			r.publish(ctx, ChangeResult, row)
+		}
+	}
+	return row, err
+}
+
+// ----- Routes -----
+
+func registerVersionRoutes(api *gin.RouterGroup, repo *Repository, protect gin.HandlerFunc) {
+	api.GET("/matches/:id/versions", attachProtect(protect, func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		list, err := repo.Versions(c.Request.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}))
+
+	api.POST("/matches/:id/versions/:v/restore", attachProtect(protect, func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		v, _ := strconv.ParseInt(c.Param("v"), 10, 64)
+		row, err := repo.Restore(c.Request.Context(), id, v)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, withPeriods(c, repo, row))
+	}))
+}
@@ -0,0 +1,95 @@
+package matches
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xaitan80/X-Matches/internal/audit"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestRepository_VersionsAndRestore(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := audit.WithActor(context.Background(), 5)
+
+	m, _ := repo.Create(ctx, Match{DateRaw: "2025-09-20", TimeRaw: "14:30", Team: "A", Opponent: "B", Venue: "Hallen"})
+	if _, err := repo.Update(ctx, m.ID, Match{Notes: "Ta med västar"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	// The fat-fingered PATCH
+	if _, err := repo.Update(ctx, m.ID, Match{Played: true, GoalsFor: 30, GoalsAgainst: 1, Venue: "Fel hall"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.Versions(ctx, m.ID)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("expected two earlier versions: %v %+v", err, list)
+	}
+	assertEq(t, list[0].Version, int64(2))
+	assertEq(t, list[0].Action, AuditUpdate)
+	assertEq(t, *list[0].UserID, int64(5))
+	assertEq(t, list[0].Match.Notes, "Ta med västar")
+	assertEq(t, list[1].Match.Notes, "")
+
+	// Back to the state before the bad PATCH
+	row, err := repo.Restore(ctx, m.ID, 2)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	got := toAPI(row)
+	assertEq(t, got.Venue, "Hallen")
+	assertEq(t, got.Notes, "Ta med västar")
+	assertEq(t, got.Played, false)
+	assertEq(t, got.GoalsFor, int64(0))
+	assertEq(t, Status(row.Status), StatusScheduled)
+
+	// The replaced state is a version too, so the restore can be undone
+	list, _ = repo.Versions(ctx, m.ID)
+	assertEq(t, len(list), 3)
+	assertEq(t, list[0].Action, AuditRestore)
+	assertEq(t, list[0].Match.GoalsFor, int64(30))
+
+	// Restoring the first version clears the notes added after it
+	row, _ = repo.Restore(ctx, m.ID, 1)
+	assertEq(t, sval(row.Notes), "")
+}
+
+func TestVersionRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := newTestRepo(t)
+	r := gin.New()
+	RegisterRoutes(r, repo, settings.NewService(db), nil)
+	ctx := context.Background()
+	m, _ := repo.Create(ctx, Match{Team: "A", Opponent: "B"})
+	repo.Update(ctx, m.ID, Match{Opponent: "C"})
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	w := do(http.MethodGet, "/api/matches/1/versions")
+	var list []Version
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list) != 1 || list[0].Match.Opponent != "B" {
+		t.Fatalf("versions: %d %s", w.Code, w.Body.String())
+	}
+	w = do(http.MethodPost, "/api/matches/1/versions/1/restore")
+	var restored Match
+	json.Unmarshal(w.Body.Bytes(), &restored)
+	if w.Code != http.StatusOK || restored.Opponent != "B" {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/api/matches/1/versions/9/restore", "/api/matches/99/versions/1/restore"} {
+		if w := do(http.MethodPost, path); w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if w := do(http.MethodGet, "/api/matches/99/versions"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing match, got %d", w.Code)
+	}
+}
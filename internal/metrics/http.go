@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Access decides who may scrape /metrics: a bearer token, or a client IP in
+// Allow. The client IP is resolved by gin, so it honours TRUSTED_PROXIES.
+type Access struct {
+	Token string
+	Allow []netip.Prefix
+}
+
+// ParseAllow parses a comma-separated list of IPs and CIDRs.
+func ParseAllow(list string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ip %q", s)
+		}
+		out = append(out, netip.PrefixFrom(a, a.BitLen()))
+	}
+	return out, nil
+}
+
+func (a Access) allowed(c *gin.Context) bool {
+	if a.Token != "" {
+		if tok, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok &&
+			subtle.ConstantTimeCompare([]byte(tok), []byte(a.Token)) == 1 {
+			return true
+		}
+	}
+	ip, err := netip.ParseAddr(c.ClientIP())
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range a.Allow {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterRoutes mounts GET /metrics behind access.
+func RegisterRoutes(r *gin.Engine, m *Metrics, access Access) {
+	h := promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+	r.GET("/metrics", func(c *gin.Context) {
+		if !access.allowed(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	})
+}
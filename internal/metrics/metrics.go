@@ -0,0 +1,172 @@
+// Package metrics exposes Prometheus metrics on /metrics: HTTP traffic per
+// route, logins, imports, and gauges read from the database on every scrape.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"github.com/xaitan80/X-Matches/internal/matches"
+)
+
+const namespace = "xmatches"
+
+// loginRoute is the route whose responses are counted as login attempts.
+const loginRoute = "/api/auth/login"
+
+// Metrics holds the collectors and the registry they are served from.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests       *prometheus.CounterVec
+	duration       *prometheus.HistogramVec
+	logins         *prometheus.CounterVec
+	importDuration prometheus.Histogram
+	importRows     *prometheus.CounterVec
+}
+
+// New registers all metrics, including the sql.DB pool stats and the
+// database-backed gauges, in a fresh registry.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "http_requests_total",
+			Help: "HTTP requests by route and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "http_request_duration_seconds",
+			Help:    "HTTP request latency by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "logins_total",
+			Help: "Login attempts by result (success or failure).",
+		}, []string{"result"}),
+		importDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "import_duration_seconds",
+			Help:    "Time spent storing an imported schedule.",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}),
+		importRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "import_rows_total",
+			Help: "Imported rows by result (imported, updated or failed).",
+		}, []string{"result"}),
+	}
+	m.Registry.MustRegister(
+		m.requests, m.duration, m.logins, m.importDuration, m.importRows,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "xmatches"),
+		&dbCollector{db: db},
+	)
+	return m
+}
+
+// Middleware records every request under its route pattern, so /api/matches/1
+// and /api/matches/2 share a series. Unknown paths share the route "unmatched".
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		m.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(status)).Inc()
+		m.duration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+
+		// Malformed requests (400) are neither
+		if route == loginRoute {
+			switch status {
+			case 200:
+				m.logins.WithLabelValues("success").Inc()
+			case 401:
+				m.logins.WithLabelValues("failure").Inc()
+			}
+		}
+	}
+}
+
+// ObserveImport records a finished import.
+func (m *Metrics) ObserveImport(res matches.ImportResult) {
+	m.importDuration.Observe(res.Duration.Seconds())
+	m.importRows.WithLabelValues("imported").Add(float64(res.Imported))
+	m.importRows.WithLabelValues("updated").Add(float64(res.Updated))
+	m.importRows.WithLabelValues("failed").Add(float64(res.Failed))
+}
+
+var (
+	sessionsDesc = prometheus.NewDesc(namespace+"_sessions_active", "Sessions that have not expired.", nil, nil)
+	matchesDesc  = prometheus.NewDesc(namespace+"_matches", "Matches by status.", []string{"status"}, nil)
+	jobsDesc     = prometheus.NewDesc(namespace+"_jobs", "Background jobs by status; pending is the queue depth.", []string{"status"}, nil)
+)
+
+// jobStatuses are always reported, so an empty queue shows as 0 instead of missing.
+var jobStatuses = []string{"pending", "running", "failed"}
+
+// dbCollector reads gauges from the database on each scrape.
+type dbCollector struct {
+	db *sql.DB
+}
+
+func (d *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsDesc
+	ch <- matchesDesc
+	ch <- jobsDesc
+}
+
+func (d *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var sessions int64
+	if err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sessions WHERE expires_at > CURRENT_TIMESTAMP`).Scan(&sessions); err != nil {
+		slog.Error("metrics: sessions", "err", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(sessionsDesc, prometheus.GaugeValue, float64(sessions))
+	}
+
+	byStatus, err := d.countBy(ctx, `SELECT status, COUNT(*) FROM matches WHERE deleted_at IS NULL GROUP BY status`)
+	if err != nil {
+		slog.Error("metrics: matches", "err", err)
+	}
+	for status, n := range byStatus {
+		ch <- prometheus.MustNewConstMetric(matchesDesc, prometheus.GaugeValue, n, status)
+	}
+
+	jobs, err := d.countBy(ctx, `SELECT status, COUNT(*) FROM jobs WHERE status IN ('pending', 'running', 'failed') GROUP BY status`)
+	if err != nil {
+		slog.Error("metrics: jobs", "err", err)
+		return
+	}
+	for _, status := range jobStatuses {
+		ch <- prometheus.MustNewConstMetric(jobsDesc, prometheus.GaugeValue, jobs[status], status)
+	}
+}
+
+func (d *dbCollector) countBy(ctx context.Context, query string) (map[string]float64, error) {
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]float64{}
+	for rows.Next() {
+		var key string
+		var n int64
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, err
+		}
+		out[key] = float64(n)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	_ "modernc.org/sqlite"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/matches"
+)
+
+func newTestRouter(t *testing.T, access Access) (*gin.Engine, *Metrics, *sql.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m := New(db)
+	r := gin.New()
+	r.Use(m.Middleware())
+	RegisterRoutes(r, m, access)
+	r.GET("/api/matches/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST(loginRoute, func(c *gin.Context) {
+		if c.Query("ok") == "1" {
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Status(http.StatusUnauthorized)
+	})
+	return r, m, db
+}
+
+func do(r *gin.Engine, method, path, remote string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remote
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_CountsByRouteAndLogins(t *testing.T) {
+	allow, _ := ParseAllow("127.0.0.1")
+	r, m, _ := newTestRouter(t, Access{Allow: allow})
+
+	do(r, http.MethodGet, "/api/matches/1", "10.0.0.1:1", nil)
+	do(r, http.MethodGet, "/api/matches/2", "10.0.0.1:1", nil)
+	do(r, http.MethodGet, "/nope", "10.0.0.1:1", nil)
+	do(r, http.MethodPost, loginRoute+"?ok=1", "10.0.0.1:1", nil)
+	do(r, http.MethodPost, loginRoute, "10.0.0.1:1", nil)
+	do(r, http.MethodPost, loginRoute, "10.0.0.1:1", nil)
+
+	if n := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/matches/:id", "200")); n != 2 {
+		t.Fatalf("expected both match requests under the route pattern, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.requests.WithLabelValues("GET", "unmatched", "404")); n != 1 {
+		t.Fatalf("expected unknown paths to share one series, got %v", n)
+	}
+	if s, f := testutil.ToFloat64(m.logins.WithLabelValues("success")), testutil.ToFloat64(m.logins.WithLabelValues("failure")); s != 1 || f != 2 {
+		t.Fatalf("expected 1 successful and 2 failed logins, got %v/%v", s, f)
+	}
+
+	m.ObserveImport(matches.ImportResult{Imported: 3, Updated: 1, Failed: 2, Duration: time.Second})
+	if n := testutil.ToFloat64(m.importRows.WithLabelValues("imported")); n != 3 {
+		t.Fatalf("expected 3 imported rows, got %v", n)
+	}
+}
+
+func TestRoutes_AccessAndDatabaseGauges(t *testing.T) {
+	allow, err := ParseAllow("127.0.0.1, 192.168.0.0/16")
+	if err != nil {
+		t.Fatalf("parse allow: %v", err)
+	}
+	r, _, db := newTestRouter(t, Access{Token: "s3cret", Allow: allow})
+	db.Exec(`INSERT INTO matches (date_raw, time_raw, team, opponent, status) VALUES ('2025-09-20', '14:30', 'A', 'B', 'cancelled')`)
+	db.Exec(`INSERT INTO jobs (type, run_at_ms) VALUES ('x', 0)`)
+
+	if w := do(r, http.MethodGet, "/metrics", "10.0.0.1:1", nil); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 outside the allowlist, got %d", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/metrics", "10.0.0.1:1", http.Header{"Authorization": {"Bearer wrong"}}); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a wrong token, got %d", w.Code)
+	}
+	if w := do(r, http.MethodGet, "/metrics", "10.0.0.1:1", http.Header{"Authorization": {"Bearer s3cret"}}); w.Code != http.StatusOK {
+		t.Fatalf("expected the token to grant access, got %d", w.Code)
+	}
+	w := do(r, http.MethodGet, "/metrics", "192.168.1.20:1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected access from the allowed range, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`xmatches_matches{status="cancelled"} 1`,
+		`xmatches_jobs{status="pending"} 1`,
+		`xmatches_jobs{status="failed"} 0`,
+		`xmatches_sessions_active 0`,
+		`go_sql_open_connections{db_name="xmatches"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in metrics output", want)
+		}
+	}
+}
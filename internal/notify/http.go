@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xaitan80/X-Matches/internal/auth"
+)
+
+func errStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// RegisterRoutes mounts the signed-in user's subscription settings at
+// /api/auth/me/notifications.
+func RegisterRoutes(r *gin.Engine, svc *Service, users *auth.Repository) {
+	api := r.Group("/api/auth/me")
+
+	api.GET("/notifications", func(c *gin.Context) {
+		u, ok := auth.CurrentUser(c, users)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		p, err := svc.Prefs(c.Request.Context(), u.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+
+	api.PUT("/notifications", func(c *gin.Context) {
+		u, ok := auth.CurrentUser(c, users)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var p Prefs
+		if err := c.BindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		if err := svc.SetPrefs(c.Request.Context(), u.ID, p); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+}
+
+// RegisterAdminRoutes mounts /api/admin/email behind the given admin middleware.
+func RegisterAdminRoutes(r *gin.Engine, svc *Service, admin gin.HandlerFunc) {
+	g := r.Group("/api/admin/email")
+	g.Use(admin)
+
+	g.GET("/templates", func(c *gin.Context) {
+		list, err := svc.Templates.List(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	g.PUT("/templates/:name", func(c *gin.Context) {
+		var req struct {
+			Subject string `json:"subject"`
+			Body    string `json:"body"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		tpl, err := svc.Templates.Save(c.Request.Context(), Template{Name: Kind(c.Param("name")), Subject: req.Subject, Body: req.Body})
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, tpl)
+	})
+
+	// Restores the built-in template
+	g.DELETE("/templates/:name", func(c *gin.Context) {
+		name := Kind(c.Param("name"))
+		if err := svc.Templates.Reset(c.Request.Context(), name); err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		tpl, err := svc.Templates.Get(c.Request.Context(), name)
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, tpl)
+	})
+
+	// Renders a template with sample data so admins can check their edits
+	g.GET("/templates/:name/preview", func(c *gin.Context) {
+		subject, body, err := svc.Templates.Render(c.Request.Context(), Kind(c.Param("name")), sample())
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"subject": subject, "body": body})
+	})
+
+	g.POST("/test", func(c *gin.Context) {
+		var req struct {
+			To string `json:"to"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		to := strings.TrimSpace(req.To)
+		if to == "" || !strings.Contains(to, "@") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email"})
+			return
+		}
+		err := svc.Send(c.Request.Context(), Message{To: to, Subject: "X-Matches: testmejl", Body: "Det här är ett testmejl från X-Matches.\n"})
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+}
@@ -0,0 +1,194 @@
+// Package notify sends email to users who subscribed to match reminders,
+// schedule changes or results.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// encode renders m as an RFC 5322 message.
+func encode(from string, m Message, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// smtpTimeout bounds a whole SMTP session when ctx has no earlier deadline,
+// so a server that stops answering cannot hold a job worker forever.
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends through an SMTP server, e.g. MailHog on localhost:1025 during
+// development. Auth is only used when Username is set.
+type SMTPMailer struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+	Timeout  time.Duration // for the whole session; zero means smtpTimeout
+}
+
+// Send delivers m like smtp.SendMail, using STARTTLS when the server offers
+// it, but gives up when ctx is done or the session takes longer than Timeout.
+func (s SMTPMailer) Send(ctx context.Context, m Message) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = smtpTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	// Unblock a pending read or write as soon as ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return ctxErr(ctx, err)
+	}
+	defer c.Close()
+	if err := s.session(c, host, m); err != nil {
+		return ctxErr(ctx, err)
+	}
+	return nil
+}
+
+func (s SMTPMailer) session(c *smtp.Client, host string, m Message) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+	from := s.From
+	if a, err := mailAddress(from); err == nil {
+		from = a
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(encode(s.From, m, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// ctxErr prefers ctx's error over the I/O error its cancellation caused.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("smtp: %w", ctx.Err())
+	}
+	return err
+}
+
+// mailAddress extracts the bare address from "Name <addr>".
+func mailAddress(s string) (string, error) {
+	if i, j := strings.LastIndex(s, "<"), strings.LastIndex(s, ">"); i >= 0 && j > i {
+		return s[i+1 : j], nil
+	}
+	if !strings.Contains(s, "@") {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	return s, nil
+}
+
+// FileMailer writes each message as an .eml file in Dir. With an empty Dir it
+// only logs the message, which is the default when no SMTP server is configured.
+type FileMailer struct {
+	Dir  string
+	From string
+	n    atomic.Int64
+}
+
+func (f *FileMailer) Send(ctx context.Context, m Message) error {
+	if f.Dir == "" {
+		slog.InfoContext(ctx, "mail (not sent, no SMTP_ADDR or MAIL_DIR)", "to", m.To, "subject", m.Subject, "body", m.Body)
+		return nil
+	}
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%03d.eml", now.UTC().Format("20060102T150405"), f.n.Add(1)%1000)
+	return os.WriteFile(filepath.Join(f.Dir, name), encode(f.From, m, now), 0o644)
+}
+
+// MailerFromEnv picks SMTP when SMTP_ADDR is set and otherwise writes messages
+// to MAIL_DIR (or the log).
+func MailerFromEnv() Mailer {
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "X-Matches <noreply@localhost>"
+	}
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		return SMTPMailer{
+			Addr:     addr,
+			From:     from,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+	return &FileMailer{Dir: os.Getenv("MAIL_DIR"), From: from}
+}
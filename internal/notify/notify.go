@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/matches"
+	"github.com/xaitan80/X-Matches/internal/settings"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/xaitan80/X-Matches/internal/notify")
+
+// JobSend is the job type that renders and mails one message.
+const JobSend = "email.send"
+
+// Prefs are a user's email subscriptions. Everything is off until the user opts in.
+type Prefs struct {
+	Reminders       bool `json:"reminders"`
+	ScheduleChanges bool `json:"schedule_changes"`
+	Results         bool `json:"results"`
+}
+
+// Recipient is a subscribed user.
+type Recipient struct {
+	UserID int64
+	Email  string
+}
+
+// prefColumn maps a kind to its notification_prefs column.
+var prefColumn = map[Kind]string{
+	KindReminder:       "reminders",
+	KindScheduleChange: "schedule_changes",
+	KindResult:         "results",
+}
+
+// Service renders templates and mails them to subscribed users.
+type Service struct {
+	db        *sql.DB
+	mailer    Mailer
+	cfg       *settings.Service
+	jobs      *jobs.Queue
+	Templates *Templates
+}
+
+// NewService creates a Service; cfg supplies the club name and may be nil.
+func NewService(db *sql.DB, mailer Mailer, cfg *settings.Service) *Service {
+	return &Service{db: db, mailer: mailer, cfg: cfg, Templates: NewTemplates(db)}
+}
+
+// UseJobs sends mail from q so a slow or unreachable SMTP server is retried
+// in the background instead of delaying the caller.
+func (s *Service) UseJobs(q *jobs.Queue) {
+	s.jobs = q
+	q.Handle(JobSend, func(ctx context.Context, j jobs.Job) (any, error) {
+		var p sendJob
+		if err := j.Decode(&p); err != nil {
+			return nil, jobs.Permanent(err)
+		}
+		return nil, s.SendTo(ctx, p.To, p.Kind, p.Data)
+	})
+}
+
+type sendJob struct {
+	To   string `json:"to"`
+	Kind Kind   `json:"kind"`
+	Data Data   `json:"data"`
+}
+
+// deliver mails kind to one recipient, through the job queue when there is one.
+func (s *Service) deliver(ctx context.Context, to string, kind Kind, data Data) error {
+	if s.jobs == nil {
+		return s.SendTo(ctx, to, kind, data)
+	}
+	if data.Club == "" {
+		data.Club = s.cfg.Current(ctx).ClubName
+	}
+	_, err := s.jobs.Enqueue(ctx, JobSend, sendJob{To: to, Kind: kind, Data: data})
+	return err
+}
+
+// Prefs returns the subscriptions of userID.
+func (s *Service) Prefs(ctx context.Context, userID int64) (Prefs, error) {
+	var p Prefs
+	err := s.db.QueryRowContext(ctx,
+		`SELECT reminders, schedule_changes, results FROM notification_prefs WHERE user_id = ?`, userID,
+	).Scan(&p.Reminders, &p.ScheduleChanges, &p.Results)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Prefs{}, nil
+	}
+	return p, err
+}
+
+// SetPrefs stores the subscriptions of userID.
+func (s *Service) SetPrefs(ctx context.Context, userID int64, p Prefs) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO notification_prefs(user_id, reminders, schedule_changes, results, updated_at)
+		 VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(user_id) DO UPDATE SET reminders = excluded.reminders, schedule_changes = excluded.schedule_changes,
+		   results = excluded.results, updated_at = excluded.updated_at`,
+		userID, p.Reminders, p.ScheduleChanges, p.Results,
+	)
+	return err
+}
+
+// Recipients lists the users subscribed to kind.
+func (s *Service) Recipients(ctx context.Context, kind Kind) ([]Recipient, error) {
+	col, ok := prefColumn[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification %q", kind)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT u.id, u.email FROM users u JOIN notification_prefs p ON p.user_id = u.id
+		 WHERE p.`+col+` = 1 ORDER BY u.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Recipient
+	for rows.Next() {
+		var r Recipient
+		if err := rows.Scan(&r.UserID, &r.Email); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Send delivers a single message, e.g. a test email.
+func (s *Service) Send(ctx context.Context, m Message) error {
+	return s.mail(ctx, "message", m)
+}
+
+// mail hands m to the mailer inside a span, so slow SMTP servers show up in traces.
+func (s *Service) mail(ctx context.Context, kind Kind, m Message) error {
+	ctx, span := tracer.Start(ctx, "email.send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("email.kind", string(kind)), attribute.String("email.mailer", fmt.Sprintf("%T", s.mailer))))
+	defer span.End()
+	err := s.mailer.Send(ctx, m)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// SendTo renders kind for data and mails it to one recipient.
+func (s *Service) SendTo(ctx context.Context, to string, kind Kind, data Data) error {
+	if data.Club == "" {
+		data.Club = s.cfg.Current(ctx).ClubName
+	}
+	subject, body, err := s.Templates.Render(ctx, kind, data)
+	if err != nil {
+		return err
+	}
+	return s.mail(ctx, kind, Message{To: to, Subject: subject, Body: body})
+}
+
+// Notify mails kind to every subscriber and returns how many were sent (or
+// queued). A failing recipient does not stop the others; their errors are joined.
+func (s *Service) Notify(ctx context.Context, kind Kind, data Data) (int, error) {
+	list, err := s.Recipients(ctx, kind)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	var errs []error
+	for _, r := range list {
+		if err := s.deliver(ctx, r.Email, kind, data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Email, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// Result tells subscribers the final score of m.
+func (s *Service) Result(ctx context.Context, m matches.Match) (int, error) {
+	return s.Notify(ctx, KindResult, Data{Match: &m})
+}
+
+// ScheduleChanges tells subscribers which matches moved in a re-import.
+func (s *Service) ScheduleChanges(ctx context.Context, changes []matches.ScheduleChange) (int, error) {
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	return s.Notify(ctx, KindScheduleChange, Data{Changes: changes})
+}
@@ -0,0 +1,297 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/matches"
+)
+
+// fakeMailer records sent messages, or fails with err when it is set.
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []Message
+	err  error
+}
+
+func (f *fakeMailer) Send(ctx context.Context, m Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *fakeMailer, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m := &fakeMailer{}
+	return NewService(db, m, nil), m, db
+}
+
+func addUser(t *testing.T, db *sql.DB, email string) int64 {
+	t.Helper()
+	res, err := db.Exec(`INSERT INTO users (email, password_hash) VALUES (?, 'x')`, email)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+func TestNotify_OnlySubscribersGetResults(t *testing.T) {
+	svc, mailer, db := newTestService(t)
+	ctx := context.Background()
+	anna := addUser(t, db, "anna@example.com")
+	bo := addUser(t, db, "bo@example.com")
+	addUser(t, db, "cecilia@example.com") // no prefs row
+
+	if p, _ := svc.Prefs(ctx, anna); p != (Prefs{}) {
+		t.Fatalf("expected everything off by default, got %+v", p)
+	}
+	svc.SetPrefs(ctx, anna, Prefs{Results: true})
+	svc.SetPrefs(ctx, bo, Prefs{Reminders: true})
+
+	n, err := svc.Result(ctx, matches.Match{Team: "IFK X", Opponent: "BK Y", GoalsFor: 3, GoalsAgainst: 1,
+		Periods: []matches.Score{{For: 2, Against: 0}, {For: 1, Against: 1}}})
+	if err != nil || n != 1 {
+		t.Fatalf("result: n=%d err=%v", n, err)
+	}
+	got := mailer.sent[0]
+	if got.To != "anna@example.com" || got.Subject != "Resultat: IFK X 3–1 BK Y" {
+		t.Fatalf("unexpected message %+v", got)
+	}
+	if !strings.Contains(got.Body, "Period 2: 1–1") || !strings.Contains(got.Body, "/X-Matches") {
+		t.Fatalf("unexpected body:\n%s", got.Body)
+	}
+}
+
+func TestNotify_ScheduleChanges(t *testing.T) {
+	svc, mailer, db := newTestService(t)
+	ctx := context.Background()
+	id := addUser(t, db, "anna@example.com")
+	svc.SetPrefs(ctx, id, Prefs{ScheduleChanges: true})
+
+	if n, _ := svc.ScheduleChanges(ctx, nil); n != 0 {
+		t.Fatalf("expected no mail without changes, got %d", n)
+	}
+	before := matches.Match{Team: "A", Opponent: "B", DateRaw: "2025-10-10", TimeRaw: "12:00", Venue: "Hallen"}
+	after := before
+	after.DateRaw = "2025-10-11"
+	if _, err := svc.ScheduleChanges(ctx, []matches.ScheduleChange{{Before: before, After: after}}); err != nil {
+		t.Fatalf("schedule changes: %v", err)
+	}
+	body := mailer.sent[0].Body
+	if !strings.Contains(body, "Förut: 2025-10-10 12:00, Hallen") || !strings.Contains(body, "Nu:    2025-10-11 12:00, Hallen") {
+		t.Fatalf("unexpected body:\n%s", body)
+	}
+}
+
+func TestNotify_QueuedThroughJobs(t *testing.T) {
+	svc, mailer, db := newTestService(t)
+	q := jobs.New(db)
+	svc.UseJobs(q)
+	ctx := context.Background()
+	anna := addUser(t, db, "anna@example.com")
+	svc.SetPrefs(ctx, anna, Prefs{Results: true})
+
+	if n, err := svc.Result(ctx, matches.Match{Team: "IFK X", Opponent: "BK Y", GoalsFor: 2, GoalsAgainst: 2}); err != nil || n != 1 {
+		t.Fatalf("result: n=%d err=%v", n, err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatalf("expected mail to wait for a worker, got %+v", mailer.sent)
+	}
+	if ran, err := q.RunNext(ctx); !ran || err != nil {
+		t.Fatalf("expected the mail job to run: ran=%v err=%v", ran, err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].Subject != "Resultat: IFK X 2–2 BK Y" {
+		t.Fatalf("unexpected messages %+v", mailer.sent)
+	}
+}
+
+func TestTemplates_SaveValidatesAndReset(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+	tpls := svc.Templates
+
+	if _, err := tpls.Save(ctx, Template{Name: KindResult, Subject: "{{.Match.Nope}}", Body: "x"}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for unknown field, got %v", err)
+	}
+	if _, err := tpls.Save(ctx, Template{Name: KindResult, Subject: "{{.Match.Team", Body: "x"}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for syntax error, got %v", err)
+	}
+	if _, err := tpls.Save(ctx, Template{Name: "nope", Subject: "x", Body: "x"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	saved, err := tpls.Save(ctx, Template{Name: KindResult, Subject: "Slut: {{.Match.GoalsFor}}-{{.Match.GoalsAgainst}}", Body: "{{.Club}}"})
+	if err != nil || !saved.Custom {
+		t.Fatalf("save: %+v %v", saved, err)
+	}
+	subject, body, _ := tpls.Render(ctx, KindResult, Data{Club: "Klubben", Match: &matches.Match{GoalsFor: 2}})
+	if subject != "Slut: 2-0" || body != "Klubben" {
+		t.Fatalf("custom render: %q %q", subject, body)
+	}
+
+	if err := tpls.Reset(ctx, KindResult); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	tpl, _ := tpls.Get(ctx, KindResult)
+	if tpl.Custom || tpl.Subject != defaults[KindResult].Subject {
+		t.Fatalf("expected built-in template after reset: %+v", tpl)
+	}
+}
+
+func TestFileMailer_WritesEML(t *testing.T) {
+	dir := t.TempDir()
+	m := &FileMailer{Dir: dir, From: "X-Matches <noreply@localhost>"}
+	if err := m.Send(context.Background(), Message{To: "anna@example.com", Subject: "Påminnelse", Body: "Hej!\nRad två"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v", files)
+	}
+	b, _ := os.ReadFile(files[0])
+	s := string(b)
+	if !strings.Contains(s, "To: anna@example.com\r\n") || !strings.Contains(s, "Subject: =?utf-8?q?P=C3=A5minnelse?=\r\n") ||
+		!strings.HasSuffix(s, "\r\n\r\nHej!\r\nRad två") {
+		t.Fatalf("unexpected message:\n%s", s)
+	}
+}
+
+// TestSMTPMailer_Delivers talks to a minimal SMTP stand-in, the same way the
+// mailer talks to MailHog in development.
+func TestSMTPMailer_Delivers(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	type received struct {
+		from, to, data string
+	}
+	done := make(chan received, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rd := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		var got received
+		reply("220 localhost ESMTP")
+		for {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				got.from = strings.TrimSpace(line[10:])
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				got.to = strings.TrimSpace(line[8:])
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := rd.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				got.data = b.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				done <- got
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	m := SMTPMailer{Addr: ln.Addr().String(), From: "X-Matches <noreply@example.com>"}
+	if err := m.Send(context.Background(), Message{To: "anna@example.com", Subject: "Hej", Body: "Test"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	got := <-done
+	if got.from != "<noreply@example.com>" || got.to != "<anna@example.com>" {
+		t.Fatalf("unexpected envelope: %+v", got)
+	}
+	if !strings.Contains(got.data, "From: X-Matches <noreply@example.com>\r\n") || !strings.HasSuffix(got.data, "\r\n\r\nTest\r\n") {
+		t.Fatalf("unexpected data:\n%s", got.data)
+	}
+}
+
+// TestSMTPMailer_GivesUpOnSilentServer connects to a server that accepts and
+// never answers, which used to block the sending job for good.
+func TestSMTPMailer_GivesUpOnSilentServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	msg := Message{To: "anna@example.com", Subject: "Hej", Body: "Test"}
+
+	m := SMTPMailer{Addr: ln.Addr().String(), From: "noreply@example.com", Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	if err := m.Send(context.Background(), msg); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("send took %v", d)
+	}
+
+	// An earlier ctx deadline wins over Timeout
+	m.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := m.Send(ctx, msg); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the ctx deadline, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("send took %v", d)
+	}
+}
@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/matches"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+// Reminder kinds stored in sent_reminders.
+const (
+	reminderStart  = "start"
+	reminderGather = "gather"
+)
+
+// Scheduler mails reminders to subscribed users ahead of kick-off and gather
+// time, as configured by the reminder_minutes settings. A reminder that is due
+// while the server is down is sent on the next run, as long as the match has
+// not started yet.
+type Scheduler struct {
+	svc  *Service
+	repo *matches.Repository
+	cfg  *settings.Service
+	now  func() time.Time
+
+	Interval time.Duration
+}
+
+func NewScheduler(svc *Service, repo *matches.Repository, cfg *settings.Service) *Scheduler {
+	return &Scheduler{svc: svc, repo: repo, cfg: cfg, now: time.Now, Interval: time.Minute}
+}
+
+// RunOnce sends every reminder that is due and returns how many were mailed.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	set := s.cfg.Current(ctx)
+	startLead := time.Duration(set.ReminderMinutes) * time.Minute
+	gatherLead := time.Duration(set.GatherReminderMinutes) * time.Minute
+	if startLead <= 0 && gatherLead <= 0 {
+		return 0, nil
+	}
+	now := s.now()
+	// Gathering is before kick-off, so a match can be due for a gather
+	// reminder while kick-off is further away than gatherLead
+	horizon := max(startLead, gatherLead) + 24*time.Hour
+	list, err := s.repo.Upcoming(ctx, set, now, now.Add(horizon))
+	if err != nil {
+		return 0, err
+	}
+	recipients, err := s.svc.Recipients(ctx, KindReminder)
+	if err != nil || len(recipients) == 0 {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, u := range list {
+		if startLead > 0 && !now.Before(u.Start.Add(-startLead)) {
+			n, err := s.remind(ctx, recipients, u, reminderStart, u.Start, Data{Club: set.ClubName, Match: &u.Match})
+			sent += n
+			errs = append(errs, err)
+		}
+		if gatherLead > 0 && !u.Gather.IsZero() && now.Before(u.Gather) && !now.Before(u.Gather.Add(-gatherLead)) {
+			g := &Gather{Time: u.Gather.In(set.Location()).Format("15:04"), Place: u.GatherPlace}
+			n, err := s.remind(ctx, recipients, u, reminderGather, u.Gather, Data{Club: set.ClubName, Match: &u.Match, Gather: g})
+			sent += n
+			errs = append(errs, err)
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// remind mails one reminder to every recipient that has not had it yet. The
+// reminder is recorded before it is sent, so a failed record never leads to
+// a second mail; a failed send removes the record again for the next pass.
+func (s *Scheduler) remind(ctx context.Context, recipients []Recipient, u matches.Upcoming, kind string, at time.Time, data Data) (int, error) {
+	sent := 0
+	var errs []error
+	for _, r := range recipients {
+		res, err := s.svc.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO sent_reminders(match_id, user_id, kind, at_ms) VALUES(?, ?, ?, ?)`,
+			u.Match.ID, r.UserID, kind, at.UnixMilli(),
+		)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if n == 0 {
+			continue // already sent
+		}
+		if err := s.svc.deliver(ctx, r.Email, KindReminder, data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Email, err))
+			if _, err := s.svc.db.ExecContext(ctx,
+				`DELETE FROM sent_reminders WHERE match_id = ? AND user_id = ? AND kind = ? AND at_ms = ?`,
+				u.Match.ID, r.UserID, kind, at.UnixMilli(),
+			); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// Run checks for due reminders every Interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		if n, err := s.RunOnce(ctx); err != nil {
+			slog.ErrorContext(ctx, "reminders", "err", err)
+		} else if n > 0 {
+			slog.InfoContext(ctx, "reminders sent", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/matches"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func TestScheduler_SendsEachReminderOnce(t *testing.T) {
+	svc, mailer, db := newTestService(t)
+	ctx := context.Background()
+	cfg := settings.NewService(db)
+	repo := matches.NewRepository(db, cfg)
+
+	anna := addUser(t, db, "anna@example.com")
+	addUser(t, db, "bo@example.com") // not subscribed
+	svc.SetPrefs(ctx, anna, Prefs{Reminders: true})
+
+	m, _ := repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", TimeRaw: "18:00", Team: "A", Opponent: "B"})
+	if _, err := db.Exec(`UPDATE matches SET gather_time = '16:30', gather_place = 'Entrén' WHERE id = ?`, m.ID); err != nil {
+		t.Fatal(err)
+	}
+	repo.Create(ctx, matches.Match{DateRaw: "2025-09-23", TimeRaw: "18:00", Team: "A", Opponent: "C"})
+	cancelled, _ := repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", TimeRaw: "19:00", Team: "A", Opponent: "D"})
+	repo.Update(ctx, cancelled.ID, matches.Match{Status: matches.StatusCancelled})
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	now := time.Date(2025, 9, 20, 12, 0, 0, 0, loc)
+	s := NewScheduler(svc, repo, cfg)
+	s.now = func() time.Time { return now }
+
+	// Kick-off is within 24h; gathering is more than 2h away
+	if n, err := s.RunOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("first run: n=%d err=%v", n, err)
+	}
+	if got := mailer.sent[0]; got.To != "anna@example.com" || !strings.HasPrefix(got.Subject, "Påminnelse: A – B") {
+		t.Fatalf("unexpected reminder %+v", got)
+	}
+
+	// Neither a second run nor a restarted scheduler sends it again
+	if n, _ := s.RunOnce(ctx); n != 0 {
+		t.Fatalf("expected no duplicate, got %d", n)
+	}
+	restarted := NewScheduler(svc, repo, cfg)
+	restarted.now = s.now
+	if n, _ := restarted.RunOnce(ctx); n != 0 {
+		t.Fatalf("expected no duplicate after restart, got %d", n)
+	}
+
+	now = time.Date(2025, 9, 20, 15, 0, 0, 0, loc)
+	if n, _ := s.RunOnce(ctx); n != 1 {
+		t.Fatalf("expected gather reminder, got %d", n)
+	}
+	got := mailer.sent[1]
+	if !strings.HasPrefix(got.Subject, "Samling 16:30: A – B") || !strings.Contains(got.Body, "Samling: 16:30, Entrén") {
+		t.Fatalf("unexpected gather reminder %+v", got)
+	}
+
+	// Moving the match earns a new kick-off reminder
+	repo.Update(ctx, m.ID, matches.Match{TimeRaw: "19:00"})
+	if n, _ := s.RunOnce(ctx); n != 1 {
+		t.Fatalf("expected reminder for new time, got %d", n)
+	}
+}
+
+func TestScheduler_RecordsBeforeSending(t *testing.T) {
+	svc, mailer, db := newTestService(t)
+	ctx := context.Background()
+	cfg := settings.NewService(db)
+	repo := matches.NewRepository(db, cfg)
+	anna := addUser(t, db, "anna@example.com")
+	svc.SetPrefs(ctx, anna, Prefs{Reminders: true})
+	repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", TimeRaw: "18:00", Team: "A", Opponent: "B"})
+
+	loc, _ := time.LoadLocation("Europe/Stockholm")
+	s := NewScheduler(svc, repo, cfg)
+	s.now = func() time.Time { return time.Date(2025, 9, 20, 12, 0, 0, 0, loc) }
+
+	// A failed send is not counted and is tried again on the next pass
+	mailer.err = errors.New("smtp down")
+	if n, err := s.RunOnce(ctx); err == nil || n != 0 {
+		t.Fatalf("failed send: n=%d err=%v", n, err)
+	}
+	mailer.err = nil
+	if n, err := s.RunOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("retry: n=%d err=%v", n, err)
+	}
+
+	// Without a record there is no mail, so it cannot go out twice
+	if _, err := db.Exec(`DELETE FROM sent_reminders`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TRIGGER no_reminders BEFORE INSERT ON sent_reminders BEGIN SELECT RAISE(FAIL, 'disk full'); END`); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.RunOnce(ctx); err == nil || n != 0 {
+		t.Fatalf("failed record: n=%d err=%v", n, err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected only the first reminder, got %+v", mailer.sent)
+	}
+}
+
+func TestScheduler_DisabledBySettings(t *testing.T) {
+	svc, _, db := newTestService(t)
+	ctx := context.Background()
+	cfg := settings.NewService(db)
+	off := settings.Defaults()
+	off.ReminderMinutes, off.GatherReminderMinutes = 0, 0
+	if _, err := cfg.Update(ctx, off); err != nil {
+		t.Fatal(err)
+	}
+	repo := matches.NewRepository(db, cfg)
+	anna := addUser(t, db, "anna@example.com")
+	svc.SetPrefs(ctx, anna, Prefs{Reminders: true})
+	repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", TimeRaw: "18:00", Team: "A", Opponent: "B"})
+
+	s := NewScheduler(svc, repo, cfg)
+	s.now = func() time.Time { return time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC) }
+	if n, _ := s.RunOnce(ctx); n != 0 {
+		t.Fatalf("expected no reminders, got %d", n)
+	}
+}
@@ -0,0 +1,233 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/matches"
+)
+
+// Kind names a type of notification. It is also the template name and the
+// subscription a user must have to receive it.
+type Kind string
+
+const (
+	KindReminder       Kind = "reminder"
+	KindScheduleChange Kind = "schedule_change"
+	KindResult         Kind = "result"
+)
+
+var kinds = []Kind{KindReminder, KindScheduleChange, KindResult}
+
+var (
+	ErrInvalid  = errors.New("invalid template")
+	ErrNotFound = errors.New("not found")
+)
+
+// Data is what templates are rendered with. Match is set for reminders and
+// results, Changes for schedule changes and Gather for gather-time reminders.
+type Data struct {
+	Club    string
+	Match   *matches.Match
+	Changes []matches.ScheduleChange
+	Gather  *Gather
+}
+
+// Gather is when and where the team meets before a match.
+type Gather struct {
+	Time  string // HH:MM
+	Place string
+}
+
+// Template is an email template; Custom is false while the built-in text is used.
+type Template struct {
+	Name      Kind       `json:"name"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	Custom    bool       `json:"custom"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+var defaults = map[Kind]Template{
+	KindReminder: {
+		Subject: `{{if .Gather}}Samling {{.Gather.Time}}{{else}}Påminnelse{{end}}: {{.Match.Team}} – {{.Match.Opponent}} {{.Match.DateRaw}} {{.Match.TimeRaw}}`,
+		Body: `Hej!
+
+Snart är det match: {{.Match.Team}} – {{.Match.Opponent}}
+{{with .Gather}}
+Samling: {{.Time}}{{with .Place}}, {{.}}{{end}}
+{{end}}
+Tid: {{.Match.Weekday}} {{.Match.DateRaw}} {{.Match.TimeRaw}}
+Plats: {{.Match.Venue}}{{with .Match.Court}} ({{.}}){{end}}{{with .Match.City}}, {{.}}{{end}}
+{{with .Match.League}}Serie: {{.}}
+{{end}}
+/{{.Club}}
+`,
+	},
+	KindScheduleChange: {
+		Subject: `Ändrat spelschema: {{len .Changes}} {{if eq (len .Changes) 1}}match{{else}}matcher{{end}}`,
+		Body: `Hej!
+
+Följande matcher har flyttats:
+{{range .Changes}}
+{{.After.Team}} – {{.After.Opponent}}
+  Förut: {{.Before.DateRaw}} {{.Before.TimeRaw}}, {{.Before.Venue}}{{with .Before.City}}, {{.}}{{end}}
+  Nu:    {{.After.DateRaw}} {{.After.TimeRaw}}, {{.After.Venue}}{{with .After.City}}, {{.}}{{end}}
+{{end}}
+/{{.Club}}
+`,
+	},
+	KindResult: {
+		Subject: `Resultat: {{.Match.Team}} {{.Match.GoalsFor}}–{{.Match.GoalsAgainst}} {{.Match.Opponent}}`,
+		Body: `Hej!
+
+{{.Match.Team}} – {{.Match.Opponent}} slutade {{.Match.GoalsFor}}–{{.Match.GoalsAgainst}}{{with .Match.Overtime}} efter förlängning{{end}}{{with .Match.Shootout}} ({{.For}}–{{.Against}} efter straffar){{end}}.
+{{range $i, $p := .Match.Periods}}
+Period {{inc $i}}: {{$p.For}}–{{$p.Against}}{{end}}
+{{with .Match.TopScorerTeam}}
+Bästa målskytt: {{.}}
+{{end}}
+/{{.Club}}
+`,
+	},
+}
+
+var funcs = template.FuncMap{"inc": func(i int) int { return i + 1 }}
+
+// samples are used to check that an edited template renders, with and
+// without the optional parts.
+func samples() []Data {
+	d := sample()
+	withGather := d
+	withGather.Gather = &Gather{Time: "13:30", Place: "Entrén"}
+	return []Data{d, withGather}
+}
+
+// sample is used for previews.
+func sample() Data {
+	m := matches.Match{
+		DateRaw: "2025-09-20", TimeRaw: "14:30", Weekday: "Lördag", League: "F16",
+		Team: "IFK X", Opponent: "BK Y", Venue: "Hallen", City: "Lund",
+		GoalsFor: 3, GoalsAgainst: 1, Periods: []matches.Score{{For: 2, Against: 0}, {For: 1, Against: 1}},
+	}
+	moved := m
+	moved.DateRaw = "2025-09-21"
+	return Data{Club: "X-Matches", Match: &m, Changes: []matches.ScheduleChange{{Before: m, After: moved}}}
+}
+
+func known(name Kind) bool {
+	for _, k := range kinds {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Templates stores admin edits of the built-in templates.
+type Templates struct {
+	db *sql.DB
+}
+
+func NewTemplates(db *sql.DB) *Templates { return &Templates{db: db} }
+
+// Get returns the stored template for name, or the built-in one.
+func (t *Templates) Get(ctx context.Context, name Kind) (Template, error) {
+	if !known(name) {
+		return Template{}, ErrNotFound
+	}
+	tpl := Template{Name: name}
+	var updated time.Time
+	err := t.db.QueryRowContext(ctx, `SELECT subject, body, updated_at FROM email_templates WHERE name = ?`, string(name)).
+		Scan(&tpl.Subject, &tpl.Body, &updated)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		d := defaults[name]
+		tpl.Subject, tpl.Body = d.Subject, d.Body
+		return tpl, nil
+	case err != nil:
+		return Template{}, err
+	}
+	tpl.Custom, tpl.UpdatedAt = true, &updated
+	return tpl, nil
+}
+
+// List returns all templates in a fixed order.
+func (t *Templates) List(ctx context.Context) ([]Template, error) {
+	out := make([]Template, 0, len(kinds))
+	for _, k := range kinds {
+		tpl, err := t.Get(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tpl)
+	}
+	return out, nil
+}
+
+// Save validates tpl by rendering it with sample data and stores it.
+func (t *Templates) Save(ctx context.Context, tpl Template) (Template, error) {
+	if !known(tpl.Name) {
+		return Template{}, ErrNotFound
+	}
+	if strings.TrimSpace(tpl.Subject) == "" || strings.TrimSpace(tpl.Body) == "" {
+		return Template{}, fmt.Errorf("%w: subject and body are required", ErrInvalid)
+	}
+	for _, d := range samples() {
+		if _, _, err := render(tpl, d); err != nil {
+			return Template{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+	if _, err := t.db.ExecContext(ctx,
+		`INSERT INTO email_templates(name, subject, body, updated_at) VALUES(?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(name) DO UPDATE SET subject = excluded.subject, body = excluded.body, updated_at = excluded.updated_at`,
+		string(tpl.Name), tpl.Subject, tpl.Body,
+	); err != nil {
+		return Template{}, err
+	}
+	return t.Get(ctx, tpl.Name)
+}
+
+// Reset drops an edited template so the built-in one is used again.
+func (t *Templates) Reset(ctx context.Context, name Kind) error {
+	if !known(name) {
+		return ErrNotFound
+	}
+	_, err := t.db.ExecContext(ctx, `DELETE FROM email_templates WHERE name = ?`, string(name))
+	return err
+}
+
+// Render produces the subject and body for name.
+func (t *Templates) Render(ctx context.Context, name Kind, data Data) (string, string, error) {
+	tpl, err := t.Get(ctx, name)
+	if err != nil {
+		return "", "", err
+	}
+	return render(tpl, data)
+}
+
+func render(tpl Template, data Data) (string, string, error) {
+	exec := func(part, src string, w io.Writer) error {
+		parsed, err := template.New(string(tpl.Name) + "." + part).Funcs(funcs).Option("missingkey=error").Parse(src)
+		if err != nil {
+			return err
+		}
+		return parsed.Execute(w, data)
+	}
+	var subject, body bytes.Buffer
+	if err := exec("subject", tpl.Subject, &subject); err != nil {
+		return "", "", err
+	}
+	if err := exec("body", tpl.Body, &body); err != nil {
+		return "", "", err
+	}
+	// A subject must stay on one line
+	return strings.Join(strings.Fields(subject.String()), " "), body.String(), nil
+}
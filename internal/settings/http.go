@@ -0,0 +1,93 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes mounts /api/admin/settings and /api/admin/leagues behind the given admin middleware.
+func RegisterAdminRoutes(r *gin.Engine, svc *Service, admin gin.HandlerFunc) {
+	g := r.Group("/api/admin/settings")
+	g.Use(admin)
+
+	g.GET("", func(c *gin.Context) {
+		cur, err := svc.Get(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, cur)
+	})
+
+	// PUT accepts the full object; omitted fields keep their current value.
+	g.PUT("", func(c *gin.Context) {
+		cur, err := svc.Get(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64<<10))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		next := cur
+		if err := json.Unmarshal(body, &next); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		saved, err := svc.Update(c.Request.Context(), next)
+		if errors.Is(err, ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, saved)
+	})
+
+	leagues := r.Group("/api/admin/leagues")
+	leagues.Use(admin)
+
+	leagues.GET("", func(c *gin.Context) {
+		list, err := svc.Leagues(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	leagues.PUT("/:name", func(c *gin.Context) {
+		var req LeagueRules
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		req.Name = c.Param("name")
+		saved, err := svc.PutLeague(c.Request.Context(), req)
+		if errors.Is(err, ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, saved)
+	})
+
+	leagues.DELETE("/:name", func(c *gin.Context) {
+		if err := svc.DeleteLeague(c.Request.Context(), c.Param("name")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,145 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LeagueRules describes how matches in a league are played.
+type LeagueRules struct {
+	Name          string `json:"name"`
+	Periods       int    `json:"periods"`        // 2 = halves, 3 = thirds
+	PeriodMinutes int    `json:"period_minutes"` // 0 = unknown
+	GatherMinutes int    `json:"gather_minutes"` // before kick-off, for matches without a gather time; 0 = none
+}
+
+// DefaultLeagueRules applies to leagues without a stored configuration.
+func DefaultLeagueRules(name string) LeagueRules {
+	return LeagueRules{Name: name, Periods: 2}
+}
+
+func (l LeagueRules) Validate() error {
+	if strings.TrimSpace(l.Name) == "" {
+		return errors.New("league name is required")
+	}
+	if l.Periods < 1 || l.Periods > 9 {
+		return errors.New("periods must be between 1 and 9")
+	}
+	if l.PeriodMinutes < 0 {
+		return errors.New("period_minutes must not be negative")
+	}
+	if l.GatherMinutes < 0 || l.GatherMinutes > 24*60 {
+		return errors.New("gather_minutes must be between 0 and 1440")
+	}
+	return nil
+}
+
+// GatherOffset is how long before kick-off the team meets by default.
+func (l LeagueRules) GatherOffset() time.Duration {
+	return time.Duration(l.GatherMinutes) * time.Minute
+}
+
+func leagueKey(name string) string { return strings.ToLower(strings.TrimSpace(name)) }
+
+// League returns the rules for the named league, or the defaults if it isn't configured.
+// A nil Service or a read error also yields the defaults.
+func (s *Service) League(ctx context.Context, name string) LeagueRules {
+	if s == nil {
+		return DefaultLeagueRules(name)
+	}
+	all, err := s.leagueMap(ctx)
+	if err != nil {
+		return DefaultLeagueRules(name)
+	}
+	if l, ok := all[leagueKey(name)]; ok {
+		return l
+	}
+	return DefaultLeagueRules(name)
+}
+
+// Leagues lists all configured leagues ordered by name.
+func (s *Service) Leagues(ctx context.Context) ([]LeagueRules, error) {
+	if s == nil {
+		return []LeagueRules{}, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT name, periods, COALESCE(period_minutes, 0), COALESCE(gather_minutes, 0) FROM leagues ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []LeagueRules{}
+	for rows.Next() {
+		var l LeagueRules
+		if err := rows.Scan(&l.Name, &l.Periods, &l.PeriodMinutes, &l.GatherMinutes); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// PutLeague creates or replaces the rules for a league.
+func (s *Service) PutLeague(ctx context.Context, l LeagueRules) (LeagueRules, error) {
+	l.Name = strings.TrimSpace(l.Name)
+	if err := l.Validate(); err != nil {
+		return LeagueRules{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	var minutes, gather any
+	if l.PeriodMinutes > 0 {
+		minutes = l.PeriodMinutes
+	}
+	if l.GatherMinutes > 0 {
+		gather = l.GatherMinutes
+	}
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO leagues(name, periods, period_minutes, gather_minutes, updated_at) VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(name) DO UPDATE SET periods = excluded.periods, period_minutes = excluded.period_minutes,
+		   gather_minutes = excluded.gather_minutes, updated_at = excluded.updated_at`,
+		l.Name, l.Periods, minutes, gather,
+	); err != nil {
+		return LeagueRules{}, err
+	}
+	s.dropLeagueCache()
+	return l, nil
+}
+
+// DeleteLeague removes a league configuration; its matches fall back to the defaults.
+func (s *Service) DeleteLeague(ctx context.Context, name string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM leagues WHERE name = ?`, strings.TrimSpace(name)); err != nil {
+		return err
+	}
+	s.dropLeagueCache()
+	return nil
+}
+
+func (s *Service) leagueMap(ctx context.Context) (map[string]LeagueRules, error) {
+	s.mu.RLock()
+	if s.leagues != nil {
+		m := s.leagues
+		s.mu.RUnlock()
+		return m, nil
+	}
+	s.mu.RUnlock()
+
+	list, err := s.Leagues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]LeagueRules, len(list))
+	for _, l := range list {
+		m[leagueKey(l.Name)] = l
+	}
+	s.mu.Lock()
+	s.leagues = m
+	s.mu.Unlock()
+	return m, nil
+}
+
+func (s *Service) dropLeagueCache() {
+	s.mu.Lock()
+	s.leagues = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,249 @@
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings holds runtime configuration that admins can change without a restart.
+type Settings struct {
+	ClubName              string `json:"club_name"`
+	OurTeam               string `json:"our_team"`
+	MatchDurationMinutes  int    `json:"match_duration_minutes"`
+	PointsWin             int    `json:"points_win"`
+	PointsDraw            int    `json:"points_draw"`
+	PointsLoss            int    `json:"points_loss"`
+	PointsOvertimeWin     int    `json:"points_overtime_win"`
+	PointsOvertimeLoss    int    `json:"points_overtime_loss"`
+	Timezone              string `json:"timezone"`
+	RegistrationOpen      bool   `json:"registration_open"`
+	ReminderMinutes       int    `json:"reminder_minutes"`        // email reminder before kick-off; 0 = off
+	GatherReminderMinutes int    `json:"gather_reminder_minutes"` // email reminder before gathering; 0 = off
+}
+
+// Defaults returns the values used when nothing has been stored yet.
+func Defaults() Settings {
+	return Settings{
+		ClubName:              "X-Matches",
+		MatchDurationMinutes:  60,
+		PointsWin:             2,
+		PointsDraw:            1,
+		PointsLoss:            0,
+		PointsOvertimeWin:     2,
+		PointsOvertimeLoss:    1,
+		Timezone:              "Europe/Stockholm",
+		RegistrationOpen:      true,
+		ReminderMinutes:       24 * 60,
+		GatherReminderMinutes: 2 * 60,
+	}
+}
+
+// Location resolves Timezone, falling back to UTC if it cannot be loaded.
+func (s Settings) Location() *time.Location {
+	if loc, err := time.LoadLocation(s.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// MatchDuration is the default length of a match without an explicit end time.
+func (s Settings) MatchDuration() time.Duration {
+	return time.Duration(s.MatchDurationMinutes) * time.Minute
+}
+
+// Validate checks that the settings are usable.
+func (s Settings) Validate() error {
+	if s.MatchDurationMinutes <= 0 || s.MatchDurationMinutes > 24*60 {
+		return errors.New("match_duration_minutes must be between 1 and 1440")
+	}
+	if s.PointsWin < 0 || s.PointsDraw < 0 || s.PointsLoss < 0 || s.PointsOvertimeWin < 0 || s.PointsOvertimeLoss < 0 {
+		return errors.New("points must not be negative")
+	}
+	if s.ReminderMinutes < 0 || s.ReminderMinutes > 14*24*60 || s.GatherReminderMinutes < 0 || s.GatherReminderMinutes > 14*24*60 {
+		return errors.New("reminder minutes must be between 0 and 20160")
+	}
+	if strings.TrimSpace(s.Timezone) == "" {
+		return errors.New("timezone is required")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", s.Timezone)
+	}
+	return nil
+}
+
+// ErrInvalid wraps validation failures returned by Update.
+var ErrInvalid = errors.New("invalid settings")
+
+// Service reads and writes settings, keeping a cached copy in memory
+// and notifying subscribers whenever they change.
+type Service struct {
+	db *sql.DB
+
+	mu      sync.RWMutex
+	cur     Settings
+	loaded  bool
+	subs    []func(Settings)
+	leagues map[string]LeagueRules // keyed by lower-cased name; nil until loaded
+}
+
+func NewService(db *sql.DB) *Service { return &Service{db: db} }
+
+// Get returns the current settings, loading them from the database on first use.
+func (s *Service) Get(ctx context.Context) (Settings, error) {
+	s.mu.RLock()
+	if s.loaded {
+		cur := s.cur
+		s.mu.RUnlock()
+		return cur, nil
+	}
+	s.mu.RUnlock()
+
+	cur, err := s.load(ctx)
+	if err != nil {
+		return Settings{}, err
+	}
+	s.mu.Lock()
+	s.cur, s.loaded = cur, true
+	s.mu.Unlock()
+	return cur, nil
+}
+
+// Current is like Get but falls back to defaults if the settings cannot be read.
+// A nil Service also yields defaults, which keeps consumers simple in tests.
+func (s *Service) Current(ctx context.Context) Settings {
+	if s == nil {
+		return Defaults()
+	}
+	cur, err := s.Get(ctx)
+	if err != nil {
+		return Defaults()
+	}
+	return cur
+}
+
+// Update validates and stores the given settings, then notifies subscribers.
+func (s *Service) Update(ctx context.Context, next Settings) (Settings, error) {
+	next.ClubName = strings.TrimSpace(next.ClubName)
+	next.OurTeam = strings.TrimSpace(next.OurTeam)
+	next.Timezone = strings.TrimSpace(next.Timezone)
+	if err := next.Validate(); err != nil {
+		return Settings{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Settings{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for k, v := range encode(next) {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO settings(key, value, updated_at) VALUES(?, ?, CURRENT_TIMESTAMP)
+			 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+			k, v,
+		); err != nil {
+			return Settings{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Settings{}, err
+	}
+
+	s.mu.Lock()
+	s.cur, s.loaded = next, true
+	subs := append([]func(Settings){}, s.subs...)
+	s.mu.Unlock()
+	for _, fn := range subs {
+		fn(next)
+	}
+	return next, nil
+}
+
+// Subscribe registers fn to be called with the new settings after every update.
+func (s *Service) Subscribe(fn func(Settings)) {
+	s.mu.Lock()
+	s.subs = append(s.subs, fn)
+	s.mu.Unlock()
+}
+
+func (s *Service) load(ctx context.Context) (Settings, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key, value FROM settings`)
+	if err != nil {
+		return Settings{}, err
+	}
+	defer rows.Close()
+	kv := map[string]string{}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return Settings{}, err
+		}
+		kv[k] = v
+	}
+	if err := rows.Err(); err != nil {
+		return Settings{}, err
+	}
+	return decode(kv), nil
+}
+
+// ----- key/value mapping -----
+
+func encode(s Settings) map[string]string {
+	return map[string]string{
+		"club_name":               s.ClubName,
+		"our_team":                s.OurTeam,
+		"match_duration_minutes":  strconv.Itoa(s.MatchDurationMinutes),
+		"points_win":              strconv.Itoa(s.PointsWin),
+		"points_draw":             strconv.Itoa(s.PointsDraw),
+		"points_loss":             strconv.Itoa(s.PointsLoss),
+		"points_overtime_win":     strconv.Itoa(s.PointsOvertimeWin),
+		"points_overtime_loss":    strconv.Itoa(s.PointsOvertimeLoss),
+		"timezone":                s.Timezone,
+		"registration_open":       strconv.FormatBool(s.RegistrationOpen),
+		"reminder_minutes":        strconv.Itoa(s.ReminderMinutes),
+		"gather_reminder_minutes": strconv.Itoa(s.GatherReminderMinutes),
+	}
+}
+
+// decode starts from Defaults so that keys added later get sensible values
+// in databases that predate them; unparsable values are ignored the same way.
+func decode(kv map[string]string) Settings {
+	s := Defaults()
+	str := func(k string, dst *string) {
+		if v, ok := kv[k]; ok {
+			*dst = v
+		}
+	}
+	num := func(k string, dst *int) {
+		if v, ok := kv[k]; ok {
+			if n, err := strconv.Atoi(v); err == nil {
+				*dst = n
+			}
+		}
+	}
+	flag := func(k string, dst *bool) {
+		if v, ok := kv[k]; ok {
+			if b, err := strconv.ParseBool(v); err == nil {
+				*dst = b
+			}
+		}
+	}
+	str("club_name", &s.ClubName)
+	str("our_team", &s.OurTeam)
+	num("match_duration_minutes", &s.MatchDurationMinutes)
+	num("points_win", &s.PointsWin)
+	num("points_draw", &s.PointsDraw)
+	num("points_loss", &s.PointsLoss)
+	num("points_overtime_win", &s.PointsOvertimeWin)
+	num("points_overtime_loss", &s.PointsOvertimeLoss)
+	str("timezone", &s.Timezone)
+	flag("registration_open", &s.RegistrationOpen)
+	num("reminder_minutes", &s.ReminderMinutes)
+	num("gather_reminder_minutes", &s.GatherReminderMinutes)
+	return s
+}
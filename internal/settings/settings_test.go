@@ -0,0 +1,143 @@
+package settings
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "modernc.org/sqlite"
+
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestService_DefaultsWhenEmpty(t *testing.T) {
+	svc := NewService(newTestDB(t))
+	got, err := svc.Get(context.Background())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got != Defaults() {
+		t.Fatalf("expected defaults, got %+v", got)
+	}
+}
+
+func TestService_UpdatePersistsAndNotifies(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewService(db)
+	var notified []Settings
+	svc.Subscribe(func(s Settings) { notified = append(notified, s) })
+
+	next := Defaults()
+	next.OurTeam = "  H43 Lund HF "
+	next.Timezone = "Europe/Helsinki"
+	next.RegistrationOpen = false
+	if _, err := svc.Update(context.Background(), next); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(notified) != 1 || notified[0].OurTeam != "H43 Lund HF" {
+		t.Fatalf("subscriber not notified correctly: %+v", notified)
+	}
+
+	// A fresh service must read the stored values back from the table.
+	got, err := NewService(db).Get(context.Background())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.OurTeam != "H43 Lund HF" || got.Timezone != "Europe/Helsinki" || got.RegistrationOpen {
+		t.Fatalf("unexpected settings after reload: %+v", got)
+	}
+}
+
+func TestService_UpdateRejectsInvalid(t *testing.T) {
+	svc := NewService(newTestDB(t))
+	bad := Defaults()
+	bad.Timezone = "Mars/Olympus"
+	if _, err := svc.Update(context.Background(), bad); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for timezone, got %v", err)
+	}
+	bad = Defaults()
+	bad.MatchDurationMinutes = 0
+	if _, err := svc.Update(context.Background(), bad); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for duration, got %v", err)
+	}
+	bad = Defaults()
+	bad.ReminderMinutes = -1
+	if _, err := svc.Update(context.Background(), bad); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for reminder minutes, got %v", err)
+	}
+}
+
+func TestAdminRoutes_PutMergesFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := NewService(newTestDB(t))
+	r := gin.New()
+	RegisterAdminRoutes(r, svc, func(c *gin.Context) { c.Next() })
+
+	body, _ := json.Marshal(map[string]any{"club_name": "IK Sund", "points_win": 3})
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var out Settings
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if out.ClubName != "IK Sund" || out.PointsWin != 3 || out.PointsDraw != Defaults().PointsDraw {
+		t.Fatalf("unexpected merge result: %+v", out)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/admin/settings", bytes.NewReader([]byte(`{"timezone":"nope"}`)))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid timezone, got %d", w.Code)
+	}
+}
+
+func TestService_LeagueRules(t *testing.T) {
+	svc := NewService(newTestDB(t))
+	ctx := context.Background()
+
+	if got := svc.League(ctx, "Division 3"); got.Periods != 2 {
+		t.Fatalf("expected default 2 periods, got %+v", got)
+	}
+	if _, err := svc.PutLeague(ctx, LeagueRules{Name: "Innebandy P13", Periods: 3, PeriodMinutes: 15, GatherMinutes: 45}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if got := svc.League(ctx, "innebandy p13"); got.Periods != 3 || got.PeriodMinutes != 15 || got.GatherOffset() != 45*time.Minute {
+		t.Fatalf("league lookup should be case-insensitive, got %+v", got)
+	}
+	if _, err := svc.PutLeague(ctx, LeagueRules{Name: "X", Periods: 0}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	if _, err := svc.PutLeague(ctx, LeagueRules{Name: "X", Periods: 2, GatherMinutes: -5}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for a negative gather offset, got %v", err)
+	}
+	if err := svc.DeleteLeague(ctx, "Innebandy P13"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got := svc.League(ctx, "Innebandy P13"); got.Periods != 2 {
+		t.Fatalf("expected defaults after delete, got %+v", got)
+	}
+}
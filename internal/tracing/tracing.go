@@ -0,0 +1,57 @@
+// Package tracing exports OpenTelemetry spans over OTLP/HTTP. It is off unless
+// an OTLP endpoint is configured, so the instrumentation in the other packages
+// costs next to nothing in a default install.
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName is used when OTEL_SERVICE_NAME is not set.
+const ServiceName = "xmatches"
+
+// Enabled reports whether an OTLP endpoint is configured.
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs the global tracer provider and propagator. The exporter reads
+// the standard OTEL_EXPORTER_OTLP_* variables (endpoint, headers, timeout) and
+// the sampler OTEL_TRACES_SAMPLER/OTEL_TRACES_SAMPLER_ARG. The returned func
+// flushes buffered spans and must be called before the process exits.
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+	exp, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Install(exp), nil
+}
+
+// Install sets a tracer provider that batches spans to exp. Tests pass an
+// in-memory exporter.
+func Install(exp sdktrace.SpanExporter) (shutdown func(context.Context) error) {
+	name := os.Getenv("OTEL_SERVICE_NAME")
+	if name == "" {
+		name = ServiceName
+	}
+	// Merge keeps OTEL_RESOURCE_ATTRIBUTES from the default resource
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(name)))
+	if err != nil {
+		res = resource.Default()
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestSetup_DisabledWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := Setup(context.Background())
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if Enabled() {
+		t.Fatal("expected tracing to be off")
+	}
+}
+
+// keepSpans ignores Shutdown, which would otherwise clear the recorded spans.
+type keepSpans struct{ *tracetest.InMemoryExporter }
+
+func (keepSpans) Shutdown(context.Context) error { return nil }
+
+func TestInstall_ExportsWithServiceName(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "xmatches-test")
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	exp := tracetest.NewInMemoryExporter()
+	shutdown := Install(keepSpans{exp})
+	_, span := otel.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	// Shutdown flushes the batcher
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "work" {
+		t.Fatalf("expected one exported span, got %+v", spans)
+	}
+	if v, ok := spans[0].Resource.Set().Value(semconv.ServiceNameKey); !ok || v.AsString() != "xmatches-test" {
+		t.Fatalf("unexpected service name: %v", spans[0].Resource)
+	}
+}
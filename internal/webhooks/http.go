@@ -0,0 +1,119 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type webhookReq struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func (r webhookReq) toWebhook() Webhook {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return Webhook{URL: r.URL, Secret: r.Secret, Events: r.Events, Active: active}
+}
+
+func errStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// RegisterAdminRoutes mounts /api/admin/webhooks behind the given admin middleware.
+func RegisterAdminRoutes(r *gin.Engine, svc *Service, admin gin.HandlerFunc) {
+	g := r.Group("/api/admin/webhooks")
+	g.Use(admin)
+
+	g.GET("", func(c *gin.Context) {
+		list, err := svc.List(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	// The response includes the signing secret; it is not shown again.
+	g.POST("", func(c *gin.Context) {
+		var req webhookReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		w, err := svc.Create(c.Request.Context(), req.toWebhook())
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, w)
+	})
+
+	g.PUT("/:id", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		var req webhookReq
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+		w, err := svc.Update(c.Request.Context(), id, req.toWebhook())
+		if err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, w)
+	})
+
+	g.DELETE("/:id", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err := svc.Delete(c.Request.Context(), id); err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// Queue a "ping" delivery to check the receiver and its signature handling
+	g.POST("/:id/ping", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err := svc.Ping(c.Request.Context(), id); err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"queued": true})
+	})
+
+	// Delivery log, newest first: ?webhook_id=, ?status=pending|delivered|failed, ?limit=
+	g.GET("/deliveries", func(c *gin.Context) {
+		webhookID, _ := strconv.ParseInt(c.Query("webhook_id"), 10, 64)
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		list, err := svc.Deliveries(c.Request.Context(), webhookID, c.Query("status"), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	})
+
+	g.POST("/deliveries/:id/redeliver", func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err := svc.Redeliver(c.Request.Context(), id); err != nil {
+			c.JSON(errStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"queued": true})
+	})
+}
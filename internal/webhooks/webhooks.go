@@ -0,0 +1,712 @@
+// Package webhooks delivers signed HTTP callbacks when matches change.
+//
+// Deliveries are queued in SQLite and sent by a background loop, so a slow or
+// unreachable receiver never blocks the request that caused the change.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/matches"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/xaitan80/X-Matches/internal/webhooks")
+
+// Events a webhook can subscribe to.
+const (
+	EventMatchCreated    = "match.created"
+	EventMatchUpdated    = "match.updated"
+	EventMatchResult     = "match.result"
+	EventMatchDeleted    = "match.deleted"
+	EventImportCompleted = "import.completed"
+	EventPing            = "ping"
+)
+
+var knownEvents = []string{EventMatchCreated, EventMatchUpdated, EventMatchResult, EventMatchDeleted, EventImportCompleted}
+
+// Delivery states.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-XMatches-Event"
+	HeaderDelivery  = "X-XMatches-Delivery"
+	HeaderSignature = "X-XMatches-Signature"
+)
+
+var (
+	ErrInvalid  = errors.New("invalid webhook")
+	ErrNotFound = errors.New("not found")
+)
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // only returned when created
+	Events    []string  `json:"events"`           // empty = all events
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the webhook subscribes to event.
+func (w Webhook) Wants(event string) bool {
+	if event == EventPing || len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnown(event string) bool {
+	for _, k := range knownEvents {
+		if event == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	for _, e := range w.Events {
+		if !isKnown(e) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+type Delivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int64           `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	ResponseCode  *int            `json:"response_code,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// Sign returns the signature header value for body: "sha256=" + hex HMAC-SHA256.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// JobDeliver is the job type that sends one delivery.
+const JobDeliver = "webhooks.deliver"
+
+// JobFanOut is the job type that queues one event for many payloads, such as
+// a match.deleted per match when the whole schedule is wiped.
+const JobFanOut = "webhooks.fanout"
+
+type Service struct {
+	db     *sql.DB
+	client *http.Client
+	now    func() time.Time
+	jobs   *jobs.Queue
+
+	MaxAttempts  int           // attempts before a delivery is marked failed
+	BaseBackoff  time.Duration // wait after the first failure; doubles per attempt
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db:           db,
+		client:       &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		now:          time.Now,
+		MaxAttempts:  8,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// UseJobs hands deliveries to q: each delivery becomes a job that retries on
+// the webhook backoff schedule. Without a queue, call Run or ProcessDue.
+func (s *Service) UseJobs(q *jobs.Queue) {
+	s.jobs = q
+	q.Handle(JobDeliver, s.runJob)
+	q.Handle(JobFanOut, s.runFanOut)
+}
+
+type fanOutJob struct {
+	Event string            `json:"event"`
+	Data  []json.RawMessage `json:"data"`
+}
+
+func (s *Service) runFanOut(ctx context.Context, j jobs.Job) (any, error) {
+	var p fanOutJob
+	if err := j.Decode(&p); err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	data := make([]any, len(p.Data))
+	for i, d := range p.Data {
+		data[i] = d
+	}
+	ids, err := s.insertDeliveries(ctx, p.Event, data)
+	if err != nil {
+		return nil, err
+	}
+	// The deliveries are stored; a retry would only queue them twice
+	if err := s.scheduleAll(ctx, ids); err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	return map[string]int{"deliveries": len(ids)}, nil
+}
+
+type deliverJob struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// schedule enqueues a job for delivery id when a queue is in use.
+func (s *Service) schedule(ctx context.Context, id int64) error {
+	if s.jobs == nil {
+		return nil
+	}
+	_, err := s.jobs.Enqueue(ctx, JobDeliver, deliverJob{DeliveryID: id}, jobs.MaxAttempts(s.MaxAttempts))
+	return err
+}
+
+func (s *Service) runJob(ctx context.Context, j jobs.Job) (any, error) {
+	var p deliverJob
+	if err := j.Decode(&p); err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	var d due
+	var status string
+	var active bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT d.id, d.webhook_id, d.event, d.payload, d.attempts, d.status, w.url, w.secret, w.active
+		 FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id WHERE d.id = ?`,
+		p.DeliveryID,
+	).Scan(&d.id, &d.webhookID, &d.event, &d.body, &d.attempts, &status, &d.url, &d.secret, &active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil // webhook deleted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusPending || !active {
+		return map[string]string{"skipped": status}, nil
+	}
+	code, sendErr := s.send(ctx, d)
+	failed, next, err := s.record(ctx, d, code, sendErr)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case sendErr == nil:
+		return map[string]int{"response_code": code}, nil
+	case failed:
+		return nil, jobs.Permanent(sendErr)
+	}
+	return nil, jobs.RetryAt(next, sendErr)
+}
+
+// backoff returns the wait before retrying after the given number of attempts.
+func (s *Service) backoff(attempts int) time.Duration {
+	d := s.BaseBackoff
+	for i := 1; i < attempts && d < s.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > s.MaxBackoff {
+		d = s.MaxBackoff
+	}
+	return d
+}
+
+// ----- Webhook CRUD -----
+
+func joinEvents(events []string) string { return strings.Join(events, ",") }
+
+func splitEvents(s string) []string {
+	out := []string{}
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *Service) List(ctx context.Context) ([]Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, url, events, active, created_at FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Webhook{}
+	for rows.Next() {
+		var w Webhook
+		var events string
+		if err := rows.Scan(&w.ID, &w.URL, &events, &w.Active, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		w.Events = splitEvents(events)
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// Create registers a webhook. A secret is generated when none is given; it is
+// only returned here.
+func (s *Service) Create(ctx context.Context, w Webhook) (Webhook, error) {
+	w.URL = strings.TrimSpace(w.URL)
+	if err := w.Validate(); err != nil {
+		return Webhook{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if w.Secret == "" {
+		sec, err := newSecret()
+		if err != nil {
+			return Webhook{}, err
+		}
+		w.Secret = sec
+	}
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO webhooks(url, secret, events, active) VALUES(?, ?, ?, ?) RETURNING id, created_at`,
+		w.URL, w.Secret, joinEvents(w.Events), w.Active,
+	).Scan(&w.ID, &w.CreatedAt)
+	return w, err
+}
+
+// Update replaces url, events and active. The secret is kept unless a new one is given.
+func (s *Service) Update(ctx context.Context, id int64, w Webhook) (Webhook, error) {
+	w.URL = strings.TrimSpace(w.URL)
+	if err := w.Validate(); err != nil {
+		return Webhook{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE webhooks SET url = ?, events = ?, active = ?, secret = COALESCE(NULLIF(?, ''), secret) WHERE id = ?`,
+		w.URL, joinEvents(w.Events), w.Active, w.Secret, id,
+	)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Webhook{}, ErrNotFound
+	}
+	w.ID, w.Secret = id, ""
+	return w, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ----- Queue -----
+
+// envelope is the JSON body posted to receivers.
+type envelope struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Enqueue queues event for every active webhook that subscribes to it. Events
+// outside the documented set are ignored.
+func (s *Service) Enqueue(ctx context.Context, event string, data any) error {
+	if !isKnown(event) {
+		return nil
+	}
+	hooks, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	var body []byte
+	for _, w := range hooks {
+		if !w.Active || !w.Wants(event) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(envelope{Event: event, CreatedAt: s.now().UTC(), Data: data}); err != nil {
+				return err
+			}
+		}
+		if err := s.insertDelivery(ctx, w.ID, event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueMany queues event once for every item of data. With a queue it
+// only adds a job that stores the deliveries, so a request that wipes the
+// schedule makes one write however many matches it held; without one the
+// deliveries are stored in a single transaction.
+func (s *Service) EnqueueMany(ctx context.Context, event string, data []any) error {
+	if !isKnown(event) || len(data) == 0 {
+		return nil
+	}
+	if s.jobs == nil {
+		_, err := s.insertDeliveries(ctx, event, data)
+		return err
+	}
+	hooks, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(hooks, func(w Webhook) bool { return w.Active && w.Wants(event) }) {
+		return nil
+	}
+	p := fanOutJob{Event: event, Data: make([]json.RawMessage, len(data))}
+	for i, d := range data {
+		if p.Data[i], err = json.Marshal(d); err != nil {
+			return err
+		}
+	}
+	_, err = s.jobs.Enqueue(ctx, JobFanOut, p)
+	return err
+}
+
+// Listen queues deliveries for the changes published on hub.
+func (s *Service) Listen(hub *matches.Hub) {
+	hub.Listen(func(ctx context.Context, c matches.Change) {
+		var err error
+		switch c.Type {
+		case matches.ChangeAllDeleted:
+			// A match.deleted per match, as receivers only know single matches
+			data := make([]any, len(c.MatchIDs))
+			for i, id := range c.MatchIDs {
+				data[i] = map[string]int64{"match_id": id}
+			}
+			err = s.EnqueueMany(ctx, EventMatchDeleted, data)
+		case matches.ChangeDeleted:
+			err = s.Enqueue(ctx, c.Type, map[string]int64{"match_id": c.MatchID})
+		case matches.ChangeImported:
+			err = s.Enqueue(ctx, c.Type, c.Import)
+		default:
+			err = s.Enqueue(ctx, c.Type, c.Match)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "webhooks: enqueue", "change", c.Type, "err", err)
+		}
+	})
+}
+
+// Ping queues a test delivery to one webhook regardless of its event filter.
+func (s *Service) Ping(ctx context.Context, id int64) error {
+	var exists int
+	if err := s.db.QueryRowContext(ctx, `SELECT 1 FROM webhooks WHERE id = ?`, id).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	body, err := json.Marshal(envelope{Event: EventPing, CreatedAt: s.now().UTC(), Data: map[string]string{"message": "pong"}})
+	if err != nil {
+		return err
+	}
+	return s.insertDelivery(ctx, id, EventPing, body)
+}
+
+func (s *Service) insertDelivery(ctx context.Context, webhookID int64, event string, body []byte) error {
+	var id int64
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_deliveries(webhook_id, event, payload, next_attempt_ms) VALUES(?, ?, ?, ?) RETURNING id`,
+		webhookID, event, string(body), s.now().UnixMilli(),
+	).Scan(&id)
+	if err != nil {
+		return err
+	}
+	return s.schedule(ctx, id)
+}
+
+// insertDeliveries stores event for every active webhook that subscribes to
+// it, once per item of data, in one transaction and returns the new ids.
+func (s *Service) insertDeliveries(ctx context.Context, event string, data []any) ([]int64, error) {
+	hooks, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	now := s.now()
+	var ids []int64
+	for _, d := range data {
+		body, err := json.Marshal(envelope{Event: event, CreatedAt: now.UTC(), Data: d})
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range hooks {
+			if !w.Active || !w.Wants(event) {
+				continue
+			}
+			var id int64
+			if err := tx.QueryRowContext(ctx,
+				`INSERT INTO webhook_deliveries(webhook_id, event, payload, next_attempt_ms) VALUES(?, ?, ?, ?) RETURNING id`,
+				w.ID, event, string(body), now.UnixMilli(),
+			).Scan(&id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// scheduleAll enqueues a job for each delivery, carrying on past failures.
+func (s *Service) scheduleAll(ctx context.Context, ids []int64) error {
+	var errs []error
+	for _, id := range ids {
+		if err := s.schedule(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("delivery %d: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Deliveries lists the newest deliveries, optionally for one webhook and/or status.
+func (s *Service) Deliveries(ctx context.Context, webhookID int64, status string, limit int) ([]Delivery, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	q := `SELECT id, webhook_id, event, payload, status, attempts, next_attempt_ms, response_code, COALESCE(last_error, ''), created_at, delivered_at
+	      FROM webhook_deliveries WHERE 1=1`
+	var args []any
+	if webhookID != 0 {
+		q += ` AND webhook_id = ?`
+		args = append(args, webhookID)
+	}
+	if status != "" {
+		q += ` AND status = ?`
+		args = append(args, status)
+	}
+	q += ` ORDER BY id DESC LIMIT ` + strconv.Itoa(limit)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Delivery{}
+	for rows.Next() {
+		var d Delivery
+		var payload string
+		var nextMs int64
+		var code sql.NullInt64
+		var delivered sql.NullTime
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &nextMs, &code, &d.LastError, &d.CreatedAt, &delivered); err != nil {
+			return nil, err
+		}
+		d.Payload = json.RawMessage(payload)
+		d.NextAttemptAt = time.UnixMilli(nextMs).UTC()
+		if code.Valid {
+			c := int(code.Int64)
+			d.ResponseCode = &c
+		}
+		if delivered.Valid {
+			d.DeliveredAt = &delivered.Time
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Redeliver puts a delivery back in the queue for an immediate attempt.
+func (s *Service) Redeliver(ctx context.Context, id int64) error {
+	var prev string
+	if err := s.db.QueryRowContext(ctx, `SELECT status FROM webhook_deliveries WHERE id = ?`, id).Scan(&prev); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = ?, next_attempt_ms = ? WHERE id = ?`,
+		StatusPending, s.now().UnixMilli(), id,
+	); err != nil {
+		return err
+	}
+	// A pending delivery already has a job waiting to retry it
+	if prev == StatusPending {
+		return nil
+	}
+	return s.schedule(ctx, id)
+}
+
+// ----- Delivery -----
+
+type due struct {
+	id, webhookID int64
+	event, body   string
+	attempts      int
+	url, secret   string
+}
+
+// ProcessDue sends every delivery whose next attempt is due and returns how
+// many were attempted.
+func (s *Service) ProcessDue(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret
+		 FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		 WHERE d.status = ? AND d.next_attempt_ms <= ? AND w.active = 1
+		 ORDER BY d.id LIMIT 50`,
+		StatusPending, s.now().UnixMilli(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var list []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.id, &d.webhookID, &d.event, &d.body, &d.attempts, &d.url, &d.secret); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		list = append(list, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, d := range list {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		code, sendErr := s.send(ctx, d)
+		if _, _, err := s.record(ctx, d, code, sendErr); err != nil {
+			return 0, err
+		}
+	}
+	return len(list), nil
+}
+
+func (s *Service) send(ctx context.Context, d due) (code int, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.send", trace.WithAttributes(
+		attribute.Int64("webhook.id", d.webhookID),
+		attribute.Int64("webhook.delivery_id", d.id),
+		attribute.String("webhook.event", d.event),
+		attribute.Int("webhook.attempt", d.attempts+1)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	body := []byte(d.body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "X-Matches-Webhooks/1")
+	req.Header.Set(HeaderEvent, d.event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.id, 10))
+	req.Header.Set(HeaderSignature, Sign(d.secret, body))
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver responded %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// record stores the outcome of an attempt and schedules a retry on failure.
+// It reports whether the delivery gave up and when the next attempt is due.
+func (s *Service) record(ctx context.Context, d due, code int, sendErr error) (failed bool, next time.Time, err error) {
+	attempts := d.attempts + 1
+	var respCode any
+	if code != 0 {
+		respCode = code
+	}
+	if sendErr == nil {
+		_, err := s.db.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = ?, attempts = ?, response_code = ?, last_error = NULL, delivered_at = ? WHERE id = ?`,
+			StatusDelivered, attempts, respCode, s.now().UTC(), d.id,
+		)
+		return false, time.Time{}, err
+	}
+	status := StatusPending
+	if attempts >= s.MaxAttempts {
+		status = StatusFailed
+	}
+	next = s.now().Add(s.backoff(attempts))
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = ?, attempts = ?, response_code = ?, last_error = ?, next_attempt_ms = ? WHERE id = ?`,
+		status, attempts, respCode, sendErr.Error(), next.UnixMilli(), d.id,
+	)
+	return status == StatusFailed, next, err
+}
+
+// Run processes due deliveries until ctx is cancelled; not needed with UseJobs.
+func (s *Service) Run(ctx context.Context) {
+	t := time.NewTicker(s.PollInterval)
+	defer t.Stop()
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "webhooks", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
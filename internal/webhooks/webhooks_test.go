@@ -0,0 +1,336 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	_ "modernc.org/sqlite"
+
+	"github.com/xaitan80/X-Matches/internal/audit"
+	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/matches"
+	"github.com/xaitan80/X-Matches/internal/settings"
+)
+
+func newTestService(t *testing.T) (*Service, *time.Time) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := dbpkg.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	svc := NewService(db)
+	now := time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, &now
+}
+
+func TestService_SignedDeliveryAndFilter(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	var got []*http.Request
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, r)
+		bodies = append(bodies, b)
+	}))
+	defer srv.Close()
+
+	hook, err := svc.Create(ctx, Webhook{URL: srv.URL, Events: []string{EventMatchResult}, Active: true})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if hook.Secret == "" {
+		t.Fatal("expected a generated secret")
+	}
+
+	_ = svc.Enqueue(ctx, EventMatchUpdated, map[string]int{"id": 1}) // filtered out
+	_ = svc.Enqueue(ctx, "board.updated", nil)                       // not a webhook event
+	if err := svc.Enqueue(ctx, EventMatchResult, map[string]int{"id": 1}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	n, err := svc.ProcessDue(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("process: n=%d err=%v", n, err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(got))
+	}
+	if sig := got[0].Header.Get(HeaderSignature); sig != Sign(hook.Secret, bodies[0]) {
+		t.Fatalf("bad signature %q", sig)
+	}
+	if ev := got[0].Header.Get(HeaderEvent); ev != EventMatchResult {
+		t.Fatalf("bad event header %q", ev)
+	}
+	var env struct {
+		Event string         `json:"event"`
+		Data  map[string]int `json:"data"`
+	}
+	if err := json.Unmarshal(bodies[0], &env); err != nil || env.Data["id"] != 1 {
+		t.Fatalf("bad body %s", bodies[0])
+	}
+
+	list, _ := svc.Deliveries(ctx, hook.ID, "", 0)
+	if len(list) != 1 || list[0].Status != StatusDelivered || list[0].ResponseCode == nil || *list[0].ResponseCode != 200 {
+		t.Fatalf("unexpected delivery log: %+v", list)
+	}
+}
+
+func TestService_RetriesWithBackoff(t *testing.T) {
+	svc, now := newTestService(t)
+	svc.MaxAttempts = 3
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if _, err := svc.Create(ctx, Webhook{URL: srv.URL, Active: true}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	_ = svc.Enqueue(ctx, EventMatchCreated, nil)
+
+	svc.ProcessDue(ctx)
+	list, _ := svc.Deliveries(ctx, 0, "", 0)
+	if list[0].Status != StatusPending || list[0].Attempts != 1 || !list[0].NextAttemptAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("expected retry in 30s: %+v", list[0])
+	}
+
+	// Not due yet
+	if n, _ := svc.ProcessDue(ctx); n != 0 {
+		t.Fatalf("expected nothing due, got %d", n)
+	}
+	*now = now.Add(30 * time.Second)
+	svc.ProcessDue(ctx)
+	list, _ = svc.Deliveries(ctx, 0, "", 0)
+	if !list[0].NextAttemptAt.Equal(now.Add(60 * time.Second)) {
+		t.Fatalf("expected backoff to double: %+v", list[0])
+	}
+	*now = now.Add(time.Minute)
+	svc.ProcessDue(ctx)
+	list, _ = svc.Deliveries(ctx, 0, StatusFailed, 0)
+	if len(list) != 1 || list[0].Attempts != 3 || calls.Load() != 3 {
+		t.Fatalf("expected failed after 3 attempts: %+v (calls=%d)", list, calls.Load())
+	}
+
+	if err := svc.Redeliver(ctx, list[0].ID); err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if n, _ := svc.ProcessDue(ctx); n != 1 {
+		t.Fatalf("expected redelivery, got %d", n)
+	}
+}
+
+func TestService_SendIsTraced(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	hook, _ := svc.Create(ctx, Webhook{URL: srv.URL, Active: true})
+	_ = svc.Enqueue(ctx, EventMatchCreated, nil)
+	svc.ProcessDue(ctx)
+
+	var send sdktrace.ReadOnlySpan
+	for _, s := range rec.Ended() {
+		if s.Name() == "webhooks.send" {
+			send = s
+		}
+	}
+	if send == nil {
+		t.Fatal("expected a webhooks.send span")
+	}
+	if send.Status().Description != "receiver responded 410 Gone" {
+		t.Fatalf("expected the failure on the span: %+v", send.Status())
+	}
+	if traceparent == "" || !bytes.Contains([]byte(traceparent), []byte(send.SpanContext().TraceID().String())) {
+		t.Fatalf("expected the trace to propagate to the receiver: %q", traceparent)
+	}
+	for _, a := range send.Attributes() {
+		if a.Key == "webhook.id" && a.Value.AsInt64() != hook.ID {
+			t.Fatalf("unexpected webhook id: %v", a.Value)
+		}
+	}
+}
+
+func TestAdminRoutes_CreateValidates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc, _ := newTestService(t)
+	r := gin.New()
+	RegisterAdminRoutes(r, svc, func(c *gin.Context) { c.Next() })
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/webhooks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := post(`{"url":"ftp://example.com"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad url, got %d", w.Code)
+	}
+	if w := post(`{"url":"https://example.com/hook","events":["match.exploded"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown event, got %d", w.Code)
+	}
+	w := post(`{"url":"https://example.com/hook","secret":"s3cret","events":["match.result"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/webhooks", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if bytes.Contains(rec.Body.Bytes(), []byte("s3cret")) {
+		t.Fatalf("list must not expose secrets: %s", rec.Body.String())
+	}
+}
+
+func TestService_DeliversThroughJobQueue(t *testing.T) {
+	svc, now := newTestService(t)
+	svc.MaxAttempts = 2
+	q := jobs.New(svc.db)
+	svc.UseJobs(q)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := svc.Create(ctx, Webhook{URL: srv.URL, Active: true}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	_ = svc.Enqueue(ctx, EventMatchCreated, nil)
+
+	if ran, err := q.RunNext(ctx); !ran || err != nil {
+		t.Fatalf("expected the delivery job to run: ran=%v err=%v", ran, err)
+	}
+	pending, _ := q.List(ctx, jobs.Filter{Status: jobs.StatusPending})
+	if len(pending) != 1 || !pending[0].RunAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("expected the job to wait for the delivery backoff: %+v", pending)
+	}
+
+	// The queue runs on wall-clock time, so the retry is already due
+	q.RunNext(ctx)
+	list, _ := svc.Deliveries(ctx, 0, StatusDelivered, 0)
+	if len(list) != 1 || list[0].Attempts != 2 || calls.Load() != 2 {
+		t.Fatalf("expected delivery on the second attempt: %+v (calls=%d)", list, calls.Load())
+	}
+	done, _ := q.List(ctx, jobs.Filter{Status: jobs.StatusDone})
+	if len(done) != 1 || string(done[0].Result) != `{"response_code":200}` {
+		t.Fatalf("expected a finished job: %+v", done)
+	}
+}
+
+func TestService_ListenDeleteAll(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+	repo := matches.NewRepository(svc.db, settings.NewService(svc.db))
+	svc.Listen(repo.Hub())
+
+	hook, err := svc.Create(ctx, Webhook{URL: "http://example.invalid/hook", Events: []string{EventMatchDeleted}, Active: true})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	a, _ := repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", HomeTeam: "A", AwayTeam: "B"})
+	b, _ := repo.Create(ctx, matches.Match{DateRaw: "2025-09-27", HomeTeam: "A", AwayTeam: "C"})
+	if n, err := repo.DeleteAll(ctx); err != nil || n != 2 {
+		t.Fatalf("delete all: n=%d err=%v", n, err)
+	}
+
+	list, _ := svc.Deliveries(ctx, hook.ID, "", 0)
+	got := map[int64]bool{}
+	for _, d := range list {
+		var env struct {
+			Data struct {
+				MatchID int64 `json:"match_id"`
+			} `json:"data"`
+		}
+		if d.Event != EventMatchDeleted || json.Unmarshal(d.Payload, &env) != nil {
+			t.Fatalf("unexpected delivery %+v", d)
+		}
+		got[env.Data.MatchID] = true
+	}
+	if len(list) != 2 || !got[a.ID] || !got[b.ID] {
+		t.Fatalf("expected a match.deleted delivery per match: %+v", list)
+	}
+}
+
+func TestService_ListenDeleteAllFansOutInAJob(t *testing.T) {
+	svc, _ := newTestService(t)
+	q := jobs.New(svc.db)
+	svc.UseJobs(q)
+	ctx := context.Background()
+	repo := matches.NewRepository(svc.db, settings.NewService(svc.db))
+	svc.Listen(repo.Hub())
+
+	hook, err := svc.Create(ctx, Webhook{URL: "http://example.invalid/hook", Events: []string{EventMatchDeleted}, Active: true})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, opp := range []string{"B", "C", "D"} {
+		repo.Create(ctx, matches.Match{DateRaw: "2025-09-20", HomeTeam: "A", AwayTeam: opp})
+	}
+	if n, err := repo.DeleteAll(audit.WithActor(ctx, 7)); err != nil || n != 3 {
+		t.Fatalf("delete all: n=%d err=%v", n, err)
+	}
+
+	// The request only queued one job, as the user who wiped the schedule
+	pending, _ := q.List(ctx, jobs.Filter{Status: jobs.StatusPending})
+	if len(pending) != 1 || pending[0].Type != JobFanOut {
+		t.Fatalf("expected a single fan-out job: %+v", pending)
+	}
+	var user int64
+	svc.db.QueryRow(`SELECT user_id FROM jobs WHERE id = ?`, pending[0].ID).Scan(&user)
+	if user != 7 {
+		t.Fatalf("expected the job to keep the actor, got %d", user)
+	}
+	if list, _ := svc.Deliveries(ctx, hook.ID, "", 0); len(list) != 0 {
+		t.Fatalf("expected no deliveries before the job ran: %+v", list)
+	}
+
+	if ran, err := q.RunNext(ctx); !ran || err != nil {
+		t.Fatalf("expected the fan-out job to run: ran=%v err=%v", ran, err)
+	}
+	if list, _ := svc.Deliveries(ctx, hook.ID, StatusPending, 0); len(list) != 3 {
+		t.Fatalf("expected a match.deleted delivery per match: %+v", list)
+	}
+	pending, _ = q.List(ctx, jobs.Filter{Status: jobs.StatusPending})
+	if len(pending) != 3 || pending[0].Type != JobDeliver {
+		t.Fatalf("expected a delivery job each: %+v", pending)
+	}
+}
@@ -1,29 +1,57 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"embed"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	_ "time/tzdata"
 
+	"github.com/XSAM/otelsql"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
 	_ "modernc.org/sqlite"
 
+	"github.com/xaitan80/X-Matches/internal/audit"
 	"github.com/xaitan80/X-Matches/internal/auth"
+	"github.com/xaitan80/X-Matches/internal/backup"
+	"github.com/xaitan80/X-Matches/internal/calsync"
 	dbpkg "github.com/xaitan80/X-Matches/internal/db"
+	"github.com/xaitan80/X-Matches/internal/jobs"
+	"github.com/xaitan80/X-Matches/internal/logging"
 	"github.com/xaitan80/X-Matches/internal/matches"
+	"github.com/xaitan80/X-Matches/internal/metrics"
+	"github.com/xaitan80/X-Matches/internal/notify"
+	"github.com/xaitan80/X-Matches/internal/settings"
+	"github.com/xaitan80/X-Matches/internal/tracing"
+	"github.com/xaitan80/X-Matches/internal/webhooks"
 )
 
 //go:embed web/* internal/media/* internal/media2/*
 var webFS embed.FS
 
 func main() {
+	// JSON logs on stdout (LOG_FORMAT=text for local use, LOG_LEVEL=debug|info|warn|error)
+	logging.Setup()
+
+	// OpenTelemetry spans over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		logging.Fatal("tracing", "err", err)
+	}
+
 	dsn := env("DB_PATH", "xmatches.db")
 	// Ensure SQLite enforces foreign keys on all connections
 	// modernc.org/sqlite supports DSN pragma via _pragma=foreign_keys(1)
@@ -35,24 +63,118 @@ func main() {
 		}
 	}
 
-	// Öppna DB (modernc driver name: "sqlite")
-	sqlDB, err := sql.Open("sqlite", dsn)
+	// Background workers write concurrently with requests; wait for the lock instead of failing with SQLITE_BUSY
+	if !strings.Contains(strings.ToLower(dsn), "busy_timeout") {
+		dsn += "&_pragma=busy_timeout(5000)"
+	}
+
+	// Öppna DB (modernc driver name: "sqlite"), with a span per query
+	sqlDB, err := otelsql.Open("sqlite", dsn,
+		otelsql.WithAttributes(attribute.String("db.system", "sqlite")),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitRows: true, OmitConnResetSession: true, DisableErrSkip: true}))
 	if err != nil {
-		log.Fatalf("open db: %v", err)
+		logging.Fatal("open db", "err", err)
 	}
 	defer sqlDB.Close()
 
 	// Migrera (goose via embed)
 	if err := dbpkg.Migrate(sqlDB); err != nil {
-		log.Fatalf("migrate: %v", err)
+		logging.Fatal("migrate", "err", err)
+	}
+
+	// Cancelled on SIGINT/SIGTERM (docker stop); starts the shutdown at the end of main
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Background loops that must finish before the database is closed
+	var bg sync.WaitGroup
+	background := func(fn func(context.Context)) {
+		bg.Add(1)
+		go func() {
+			defer bg.Done()
+			fn(ctx)
+		}()
 	}
 
+	// Runtime settings (editable via /api/admin/settings)
+	cfg := settings.NewService(sqlDB)
+
 	// Init sqlc-queries
-	q := dbpkg.New(sqlDB)
-	repo := matches.NewRepository(q)
+	repo := matches.NewRepository(sqlDB, cfg)
+	repo.SetLocation(cfg.Current(context.Background()).Location())
+	cfg.Subscribe(func(s settings.Settings) {
+		repo.SetLocation(s.Location())
+		slog.Info("settings updated", "timezone", s.Timezone, "registration_open", s.RegistrationOpen)
+	})
+
+	// Durable job queue for work that should not block a request (imports, email, webhooks, backups)
+	q := jobs.New(sqlDB)
+	repo.UseJobs(q)
+
+	// Outbound webhooks: queue a delivery for every match change and send them in the background
+	wh := webhooks.NewService(sqlDB)
+	wh.UseJobs(q)
+	wh.Listen(repo.Hub())
+
+	// Email to subscribed users (SMTP_ADDR, otherwise MAIL_DIR or the log)
+	// Messages are queued as jobs, so the listener only does a quick insert per recipient
+	mail := notify.NewService(sqlDB, notify.MailerFromEnv(), cfg)
+	mail.UseJobs(q)
+	repo.Hub().Listen(func(ctx context.Context, c matches.Change) {
+		switch {
+		case c.Type == matches.ChangeResult && c.Match != nil && c.Match.Played:
+			if _, err := mail.Result(ctx, *c.Match); err != nil {
+				slog.ErrorContext(ctx, "notify: result", "match_id", c.Match.ID, "err", err)
+			}
+		case c.Type == matches.ChangeImported && c.Import != nil && len(c.Import.Changes) > 0:
+			if _, err := mail.ScheduleChanges(ctx, c.Import.Changes); err != nil {
+				slog.ErrorContext(ctx, "notify: schedule changes", "err", err)
+			}
+		}
+	})
+	background(notify.NewScheduler(mail, repo, cfg).Run)
+
+	// Database backups (BACKUP_DIR), optionally every BACKUP_INTERVAL (e.g. 24h)
+	backups := backup.NewService(sqlDB, q, os.Getenv("BACKUP_DIR"))
+	if d := envDuration("BACKUP_INTERVAL", 0); d > 0 && backups.Dir != "" {
+		background(func(ctx context.Context) { backups.Run(ctx, d) })
+	}
+
+	// Two-way sync with an external CalDAV calendar (CALDAV_SYNC_URL) every CALDAV_SYNC_INTERVAL
+	winner, err := calsync.ParseWinner(os.Getenv("CALDAV_SYNC_WINNER"))
+	if err != nil {
+		logging.Fatal("CALDAV_SYNC_WINNER", "err", err)
+	}
+	var calClient *calsync.Client
+	if u := os.Getenv("CALDAV_SYNC_URL"); u != "" {
+		calClient = calsync.NewClient(u, os.Getenv("CALDAV_SYNC_USERNAME"), os.Getenv("CALDAV_SYNC_PASSWORD"))
+	}
+	calSync := calsync.NewService(sqlDB, repo, cfg, q, calClient, winner)
+	if d := envDuration("CALDAV_SYNC_INTERVAL", 5*time.Minute); d > 0 && calClient != nil {
+		background(func(ctx context.Context) { calSync.Run(ctx, d) })
+	}
+
+	// Deleted matches are purged from the trash after TRASH_RETENTION (0 keeps them)
+	if d := envDuration("TRASH_RETENTION", matches.DefaultTrashRetention); d > 0 {
+		background(func(ctx context.Context) { repo.RunTrashPurge(ctx, d) })
+	}
+
+	workers, err := strconv.Atoi(env("JOB_WORKERS", "2"))
+	if err != nil || workers < 1 {
+		logging.Fatal("JOB_WORKERS must be a positive number", "value", os.Getenv("JOB_WORKERS"))
+	}
+	q.Start(workers)
 
 	// HTTP
-	r := gin.Default()
+	r := gin.New()
+	// The tracing middleware goes first so request logs carry the trace id
+	r.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		switch c.Request.URL.Path {
+		case "/healthz", "/readyz", "/metrics":
+			return false
+		}
+		return true
+	})))
+	r.Use(logging.RequestID(), logging.AccessLog(), gin.Recovery())
 	// Configure explicit trusted proxies to avoid gin's trust-all warning
 	// Default trusts only loopback addresses; override via TRUSTED_PROXIES env (comma-separated CIDRs/IPs)
 	tp := strings.Split(env("TRUSTED_PROXIES", "127.0.0.1,::1"), ",")
@@ -60,16 +182,39 @@ func main() {
 		tp[i] = strings.TrimSpace(tp[i])
 	}
 	if err := r.SetTrustedProxies(tp); err != nil {
-		log.Fatalf("trusted proxies: %v", err)
+		logging.Fatal("trusted proxies", "err", err)
+	}
+	// Prometheus on /metrics for METRICS_ALLOW (client IP, resolved via the trusted proxies) or a METRICS_TOKEN bearer
+	allow, err := metrics.ParseAllow(env("METRICS_ALLOW", "127.0.0.1,::1"))
+	if err != nil {
+		logging.Fatal("METRICS_ALLOW", "err", err)
 	}
+	mx := metrics.New(sqlDB)
+	r.Use(mx.Middleware())
+	metrics.RegisterRoutes(r, mx, metrics.Access{Token: os.Getenv("METRICS_TOKEN"), Allow: allow})
+	repo.Hub().Listen(func(_ context.Context, c matches.Change) {
+		if c.Type == matches.ChangeImported && c.Import != nil {
+			mx.ObserveImport(*c.Import)
+		}
+	})
 
 	// API
-	auth.RegisterRoutes(r, sqlDB)
+	auth.RegisterRoutes(r, sqlDB, cfg)
 	// Auth-aware frontend routing
 	authRepo := auth.NewRepository(sqlDB)
-	matches.RegisterRoutes(r, repo, auth.AuthRequired(authRepo))
+	matches.RegisterRoutes(r, repo, cfg, auth.AuthRequired(authRepo))
 	// Admin API
 	auth.RegisterAdminRoutes(r, authRepo)
+	settings.RegisterAdminRoutes(r, cfg, auth.AdminRequired(authRepo))
+	webhooks.RegisterAdminRoutes(r, wh, auth.AdminRequired(authRepo))
+	notify.RegisterRoutes(r, mail, authRepo)
+	matches.RegisterFeedRoutes(r, repo, cfg, authRepo)
+	matches.RegisterCalDAV(r, repo, cfg, authRepo)
+	notify.RegisterAdminRoutes(r, mail, auth.AdminRequired(authRepo))
+	jobs.RegisterAdminRoutes(r, q, auth.AdminRequired(authRepo))
+	backup.RegisterAdminRoutes(r, backups, auth.AdminRequired(authRepo))
+	calsync.RegisterAdminRoutes(r, calSync, auth.AdminRequired(authRepo))
+	audit.RegisterAdminRoutes(r, audit.NewService(sqlDB), auth.AdminRequired(authRepo))
 
 	// Auth-aware frontend routing
 
@@ -112,6 +257,36 @@ func main() {
 		c.Data(http.StatusOK, "text/html; charset=utf-8", f)
 	})
 
+	// Live scorekeeping page (mobile friendly)
+	r.GET("/live", auth.AuthRequired(authRepo), func(c *gin.Context) {
+		f, err := webFS.ReadFile("web/live.html")
+		if err != nil {
+			c.String(http.StatusInternalServerError, "missing live")
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", f)
+	})
+
+	// Public scoreboard for a TV/kiosk, plus the scorekeeper's token-protected remote
+	board := func(c *gin.Context) {
+		f, err := webFS.ReadFile("web/board.html")
+		if err != nil {
+			c.String(http.StatusInternalServerError, "missing board")
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", f)
+	}
+	r.GET("/board/today", board)
+	r.GET("/board/:match_id", board)
+	r.GET("/board/:match_id/control", func(c *gin.Context) {
+		f, err := webFS.ReadFile("web/board-control.html")
+		if err != nil {
+			c.String(http.StatusInternalServerError, "missing board control")
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", f)
+	})
+
 	// Admin page (superuser only)
 	r.GET("/admin", auth.AdminRequired(authRepo), func(c *gin.Context) {
 		f, err := webFS.ReadFile("web/admin.html")
@@ -178,19 +353,76 @@ func main() {
 	})
 
 	addr := env("ADDR", ":8080")
-	// Liveness/Readiness
+	// Liveness: the process is up and serving requests
 	r.GET("/healthz", func(c *gin.Context) {
-		if err := sqlDB.Ping(); err != nil {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	// Readiness: migrations applied and the database writable. Turns 503 as
+	// soon as shutdown starts so a load balancer stops sending traffic here.
+	var draining atomic.Bool
+	r.GET("/readyz", func(c *gin.Context) {
+		if draining.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"ok": false, "error": "shutting down"})
+			return
+		}
+		if err := dbpkg.Ready(c.Request.Context(), sqlDB); err != nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"ok": false, "error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	})
 
-	log.Printf("Lyssnar på %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatal(err)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       envDuration("HTTP_READ_TIMEOUT", time.Minute),
+		WriteTimeout:      envDuration("HTTP_WRITE_TIMEOUT", time.Minute),
+		IdleTimeout:       envDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+	}
+	// Event streams never go idle on their own; hang them up so Shutdown can finish
+	srv.RegisterOnShutdown(repo.Hub().Close)
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("Lyssnar", "addr", addr)
+	select {
+	case err := <-errc:
+		logging.Fatal("listen", "err", err)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process right away
+
+	slog.Info("Stänger ner")
+	draining.Store(true)
+	if d := envDuration("SHUTDOWN_DELAY", 0); d > 0 {
+		time.Sleep(d)
 	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), envDuration("SHUTDOWN_TIMEOUT", 30*time.Second))
+	defer cancel()
+	// Stop accepting connections and let in-flight requests finish
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("http shutdown", "err", err)
+	}
+	// Let running jobs finish; unfinished ones are picked up after the next start
+	if err := q.Stop(shutdownCtx); err != nil {
+		slog.Error("jobs: stop", "err", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		bg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		slog.Error("background loops did not stop", "err", shutdownCtx.Err())
+	}
+	// Flush buffered spans
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing: shutdown", "err", err)
+	}
+	slog.Info("Nedstängd")
 }
 
 func env(k, def string) string {
@@ -199,3 +431,16 @@ func env(k, def string) string {
 	}
 	return def
 }
+
+// envDuration reads a Go duration such as 30s from k.
+func envDuration(k string, def time.Duration) time.Duration {
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		logging.Fatal("invalid duration", "env", k, "value", v)
+	}
+	return d
+}